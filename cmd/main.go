@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
@@ -14,6 +15,7 @@ import (
 	cfg "github.com/shayanmkpr/task-pool/config"
 	"github.com/shayanmkpr/task-pool/internal/api"
 	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
 	"github.com/shayanmkpr/task-pool/internal/store"
 	"github.com/shayanmkpr/task-pool/internal/taskpool"
 )
@@ -43,21 +45,133 @@ func main() {
 
 	lg.Info("Application started")
 
-	memoryStore := store.NewMemoryStore()
-	pool := taskpool.NewTaskPool(config.PoolSize, memoryStore)
-	workerManager := taskpool.NewWorkerManager(config.WorkerCount, memoryStore)
+	taskStore, err := newStore(config)
+	if err != nil {
+		lg.Error("failed to initialise store", "backend", config.Store, "error", err)
+		panic(err)
+	}
+	lg.Info("using task store", "backend", config.Store)
+	if closer, ok := taskStore.(io.Closer); ok {
+		defer func() {
+			if err := closer.Close(); err != nil {
+				lg.Error("failed to close store", "error", err)
+			}
+		}()
+	}
+
+	queues, err := taskpool.ParseQueueCapacities(config.Queues)
+	if err != nil {
+		lg.Error("invalid -queues", "error", err)
+		panic(err)
+	}
+	workerQueues, err := taskpool.ParseQueueWeights(config.WorkerQueues)
+	if err != nil {
+		lg.Error("invalid -worker-queues", "error", err)
+		panic(err)
+	}
+
+	tenants, err := taskpool.ParseTenantLimits(config.TenantWeights, config.TenantQuotas, config.TenantConcurrency)
+	if err != nil {
+		lg.Error("invalid tenant limits", "error", err)
+		panic(err)
+	}
+	concurrency, err := taskpool.ParseConcurrencyLimits(config.TypeConcurrency, config.KeyConcurrency)
+	if err != nil {
+		lg.Error("invalid concurrency limits", "error", err)
+		panic(err)
+	}
+	apiKeys, err := api.ParseAPIKeys(config.APIKeys)
+	if err != nil {
+		lg.Error("invalid -api-keys", "error", err)
+		panic(err)
+	}
+
+	opts := []taskpool.Option{
+		taskpool.WithAgingInterval(config.AgingInterval),
+		taskpool.WithTimeouts(config.TaskTimeout, config.MaxTaskTimeout),
+		taskpool.WithDeadLetters(taskStore),
+		taskpool.WithEventHistory(config.EventHistory),
+		taskpool.WithIdempotency(taskStore, config.IdempotencyWindow),
+		taskpool.WithWorkflows(taskStore),
+		taskpool.WithPauses(taskStore),
+		taskpool.WithTenants(tenants),
+		taskpool.WithConcurrencyLimits(concurrency),
+	}
+	for name, capacity := range queues {
+		opts = append(opts, taskpool.WithQueue(name, capacity))
+	}
+	pool := taskpool.NewTaskPool(config.PoolSize, taskStore, opts...)
+	registerHandlers(pool.Handlers)
+	if err := pool.LoadPauses(context.Background()); err != nil {
+		lg.Error("failed to restore paused state", "error", err)
+	}
+	if config.OverflowDir != "" {
+		// -fsync=always also syncs every spill; other policies leave it to the OS.
+		policy, _ := store.ParseSyncPolicy(config.FsyncPolicy)
+		if err := pool.EnableOverflow(context.Background(), lg, config.OverflowDir, store.SegmentOptions{SyncPolicy: policy}); err != nil {
+			lg.Error("failed to open overflow", "dir", config.OverflowDir, "error", err)
+			panic(err)
+		}
+		defer func() {
+			if err := pool.CloseOverflow(); err != nil {
+				lg.Error("failed to close overflow", "error", err)
+			}
+		}()
+	}
+	// Recover before starting workers so recovered tasks go ahead of any
+	// spilled to the overflow.
+	if n, err := pool.Recover(context.Background(), lg); err != nil {
+		lg.Error("task recovery stopped", "recovered", n, "error", err)
+	} else {
+		lg.Info("task recovery finished", "recovered", n)
+	}
+	workerManager := taskpool.NewWorkerManager(config.WorkerCount, taskStore)
 
 	workerManager.InitiateWorkers(pool)
+	if err := workerManager.AssignQueues(workerQueues); err != nil {
+		lg.Error("invalid -worker-queues", "error", err)
+		panic(err)
+	}
 	workerManager.MonitorWorkers(lg)
 
+	var autoscaler *taskpool.Autoscaler
+	if config.Autoscale {
+		autoscaler, err = taskpool.NewAutoscaler(pool, workerManager, taskpool.AutoscaleConfig{
+			MinWorkers:        config.MinWorkers,
+			MaxWorkers:        config.MaxWorkers,
+			Interval:          config.AutoscaleInterval,
+			BacklogPerWorker:  config.ScaleUpBacklog,
+			MaxQueueWait:      config.ScaleUpWait,
+			IdleUtilization:   config.ScaleDownUtilization,
+			ScaleDownDelay:    config.ScaleDownDelay,
+			ScaleUpCooldown:   config.ScaleUpCooldown,
+			ScaleDownCooldown: config.ScaleDownCooldown,
+		}, lg)
+		if err != nil {
+			lg.Error("failed to start autoscaler", "error", err)
+			panic(err)
+		}
+		autoscaler.Start()
+		lg.Info("autoscaling workers", "min", config.MinWorkers, "max", config.MaxWorkers)
+	}
+
+	cronRunner := taskpool.NewCronRunner(pool, taskStore, lg)
+	if err := cronRunner.Start(context.Background()); err != nil {
+		lg.Error("failed to start schedules", "error", err)
+	}
+
 	// Set up HTTP server
-	handler := api.NewHandler(pool, memoryStore, lg)
+	handler := api.NewHandler(pool, taskStore, lg)
 	mux := http.NewServeMux()
 	api.RegisterTaskRoutes(mux, handler)
+	api.RegisterScheduleRoutes(mux, api.NewScheduleHandler(cronRunner, lg))
+	api.RegisterDeadLetterRoutes(mux, api.NewDeadLetterHandler(pool, taskStore, lg))
+	api.RegisterWorkflowRoutes(mux, api.NewWorkflowHandler(pool, lg))
+	api.RegisterAdminRoutes(mux, api.NewAdminHandler(pool, workerManager, lg))
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", config.Port), // fix
-		Handler:      api.RequestLogger(mux),
+		Handler:      api.RequestLogger(api.ResolveTenants(mux, apiKeys)),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -86,11 +200,46 @@ func main() {
 		lg.Error("server forced to shutdown", "error", err)
 	}
 
-	lg.Info("waiting for workers to complete...")
+	// Stop adding scheduled tasks and resizing workers before draining
+	cronRunner.Stop()
+	if autoscaler != nil {
+		autoscaler.Stop()
+	}
+
+	lg.Info("waiting for running tasks to complete...")
+	workerManager.ForceStopWorkers()
 	waitCtx, waitCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer waitCancel()
 	workerManager.WaitForCompletion(waitCtx, lg, 100*time.Millisecond)
-	workerManager.ForceStopWorkers()
 
 	lg.Info("Application finished")
 }
+
+// registerHandlers installs the task types this binary knows how to execute.
+// The built-in "sleep" type is always available.
+func registerHandlers(r *taskpool.Registry) {
+	r.Register("echo", func(ctx context.Context, task *models.Task) (any, error) {
+		return task.Payload, nil
+	})
+}
+
+// newStore builds the task store backend selected by the -store flag.
+func newStore(config *cfg.Config) (store.Store, error) {
+	switch config.Store {
+	case "memory":
+		return store.NewMemoryStore(), nil
+	case "file":
+		policy, err := store.ParseSyncPolicy(config.FsyncPolicy)
+		if err != nil {
+			return nil, err
+		}
+		return store.OpenFileStore(config.DataDir, store.FileOptions{
+			SyncPolicy:      policy,
+			SyncInterval:    config.FsyncInterval,
+			CompactEvery:    config.CompactEvery,
+			CompactInterval: config.CompactInterval,
+		})
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", config.Store)
+	}
+}
@@ -1,12 +1,49 @@
 package config
 
-import "flag"
+import (
+	"flag"
+	"time"
+)
 
 type Config struct {
-	PoolSize    int
-	WorkerCount int
-	Port        int
-	StdOutLog   bool
+	PoolSize        int
+	WorkerCount     int
+	Port            int
+	StdOutLog       bool
+	Store           string
+	DataDir         string
+	FsyncPolicy     string
+	FsyncInterval   time.Duration
+	CompactEvery    int
+	CompactInterval time.Duration
+	AgingInterval   time.Duration
+	TaskTimeout     time.Duration
+	MaxTaskTimeout  time.Duration
+	EventHistory    int
+
+	IdempotencyWindow time.Duration
+	Queues            string
+	WorkerQueues      string
+	OverflowDir       string
+
+	TenantWeights     string
+	TenantQuotas      string
+	TenantConcurrency string
+	APIKeys           string
+
+	TypeConcurrency string
+	KeyConcurrency  string
+
+	Autoscale            bool
+	MinWorkers           int
+	MaxWorkers           int
+	AutoscaleInterval    time.Duration
+	ScaleUpBacklog       int
+	ScaleUpWait          time.Duration
+	ScaleDownUtilization float64
+	ScaleDownDelay       time.Duration
+	ScaleUpCooldown      time.Duration
+	ScaleDownCooldown    time.Duration
 }
 
 func Load() *Config {
@@ -15,6 +52,36 @@ func Load() *Config {
 	flag.IntVar(&cfg.WorkerCount, "workers", 5, "number of workers")
 	flag.IntVar(&cfg.Port, "port", 8080, "http server port")
 	flag.BoolVar(&cfg.StdOutLog, "stdout-log", true, "log to stdout")
+	flag.StringVar(&cfg.Store, "store", "memory", "task store backend (memory, file)")
+	flag.StringVar(&cfg.DataDir, "data-dir", "./data", "directory for the file store")
+	flag.StringVar(&cfg.FsyncPolicy, "fsync", "interval", "file store fsync policy (always, interval, never)")
+	flag.DurationVar(&cfg.FsyncInterval, "fsync-interval", time.Second, "fsync period for -fsync=interval")
+	flag.IntVar(&cfg.CompactEvery, "compact-every", 1000, "WAL records between snapshots (0 disables)")
+	flag.DurationVar(&cfg.CompactInterval, "compact-interval", 5*time.Minute, "time between snapshots (0 disables)")
+	flag.DurationVar(&cfg.AgingInterval, "aging-interval", 30*time.Second, "queue wait that raises a task by one priority level (0 disables aging)")
+	flag.DurationVar(&cfg.TaskTimeout, "task-timeout", 0, "default per-attempt timeout for tasks that do not set one (0 means none)")
+	flag.DurationVar(&cfg.MaxTaskTimeout, "max-task-timeout", time.Hour, "largest per-attempt timeout a task may request (0 means unlimited)")
+	flag.IntVar(&cfg.EventHistory, "event-history", 1000, "events kept in memory for resuming /events streams")
+	flag.DurationVar(&cfg.IdempotencyWindow, "idempotency-window", 24*time.Hour, "how long an Idempotency-Key deduplicates task submissions")
+	flag.StringVar(&cfg.Queues, "queues", "", "named queues and their capacities besides \"default\", e.g. critical=100,bulk=5000")
+	flag.StringVar(&cfg.WorkerQueues, "worker-queues", "", "queues workers take tasks from, weighted, e.g. critical=70,bulk=30 (empty means all equally)")
+	flag.StringVar(&cfg.OverflowDir, "overflow-dir", "", "directory to spill tasks to when a queue is full instead of rejecting them (empty disables)")
+	flag.StringVar(&cfg.TenantWeights, "tenant-weights", "", "tenants' shares of the workers, e.g. teamA=3,teamB=1 (unlisted tenants weigh 1, or as set for *)")
+	flag.StringVar(&cfg.TenantQuotas, "tenant-quotas", "", "most tasks each tenant may have queued, e.g. teamA=500,*=100")
+	flag.StringVar(&cfg.TenantConcurrency, "tenant-concurrency", "", "most tasks each tenant may have running, e.g. teamA=8,*=2")
+	flag.StringVar(&cfg.APIKeys, "api-keys", "", "API keys and the tenant each acts for, e.g. key1=teamA,key2=teamB (empty trusts the X-Tenant header)")
+	flag.StringVar(&cfg.TypeConcurrency, "type-concurrency", "", "most tasks of each type running at once, e.g. email=5,*=20")
+	flag.StringVar(&cfg.KeyConcurrency, "key-concurrency", "", "most tasks running at once per concurrency_key, e.g. customer-42=1,*=2")
+	flag.BoolVar(&cfg.Autoscale, "autoscale", false, "adjust the worker count to the load, starting from -workers")
+	flag.IntVar(&cfg.MinWorkers, "min-workers", 1, "fewest workers the autoscaler keeps")
+	flag.IntVar(&cfg.MaxWorkers, "max-workers", 32, "most workers the autoscaler runs")
+	flag.DurationVar(&cfg.AutoscaleInterval, "autoscale-interval", time.Second, "how often the autoscaler samples the queue")
+	flag.IntVar(&cfg.ScaleUpBacklog, "scale-up-backlog", 1, "queued tasks per worker that trigger a scale up")
+	flag.DurationVar(&cfg.ScaleUpWait, "scale-up-wait", time.Second, "queue wait that triggers a scale up")
+	flag.Float64Var(&cfg.ScaleDownUtilization, "scale-down-utilization", 0.5, "worker utilization below which the pool may scale down")
+	flag.DurationVar(&cfg.ScaleDownDelay, "scale-down-delay", 30*time.Second, "how long the pool must stay idle before scaling down")
+	flag.DurationVar(&cfg.ScaleUpCooldown, "scale-up-cooldown", 5*time.Second, "minimum time between a resize and the next scale up")
+	flag.DurationVar(&cfg.ScaleDownCooldown, "scale-down-cooldown", 30*time.Second, "minimum time between a resize and the next scale down")
 	flag.Parse()
 	return cfg
 }
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+const maxTaskTypeLength = 100
+
+type AdminHandler struct {
+	pool    *taskpool.TaskPool
+	workers *taskpool.WorkerManager
+	logger  *logger.Logger
+}
+
+func NewAdminHandler(pool *taskpool.TaskPool, workers *taskpool.WorkerManager, logger *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		pool:    pool,
+		workers: workers,
+		logger:  logger,
+	}
+}
+
+type WorkerCountRequest struct {
+	Count *int `json:"count"`
+}
+
+// QueueAssignmentRequest assigns workers to queues by weight. An empty
+// assignment puts them back on every queue.
+type QueueAssignmentRequest struct {
+	Queues taskpool.QueueWeights `json:"queues"`
+}
+
+// PauseRequest names what to pause or resume: a task type or a queue. An
+// empty body means the whole pool.
+type PauseRequest struct {
+	Type  string `json:"type,omitempty"`
+	Queue string `json:"queue,omitempty"`
+}
+
+// StatsResponse is the body of GET /admin/stats.
+type StatsResponse struct {
+	Workers     taskpool.WorkerStats        `json:"workers"`
+	Queue       taskpool.QueueStats         `json:"queue"` // totals over every queue
+	Queues      []taskpool.NamedQueueStats  `json:"queues"`
+	Tenants     []taskpool.TenantStats      `json:"tenants"`
+	Concurrency []taskpool.ConcurrencyStats `json:"concurrency"`
+	Pauses      *models.PauseState          `json:"pauses"`
+}
+
+// ConcurrencyResponse is the body of GET and PUT /admin/concurrency.
+type ConcurrencyResponse struct {
+	Limits taskpool.ConcurrencyLimits  `json:"limits"`
+	Usage  []taskpool.ConcurrencyStats `json:"usage"`
+}
+
+// WorkersResponse is the body of GET /admin/workers.
+type WorkersResponse struct {
+	taskpool.WorkerStats
+	Workers []taskpool.WorkerInfo `json:"workers"`
+}
+
+func (h *AdminHandler) getStats(w http.ResponseWriter, r *http.Request) {
+	resp := StatsResponse{
+		Workers:     h.workers.Stats(),
+		Queue:       h.pool.Stats(),
+		Queues:      h.pool.QueueStats(),
+		Tenants:     h.pool.TenantStats(),
+		Concurrency: h.pool.ConcurrencyStats(),
+		Pauses:      h.pool.PauseState(),
+	}
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *AdminHandler) getQueues(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSON(w, http.StatusOK, h.pool.QueueStats()); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *AdminHandler) getTenants(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSON(w, http.StatusOK, h.pool.TenantStats()); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *AdminHandler) getConcurrency(w http.ResponseWriter, r *http.Request) {
+	resp := ConcurrencyResponse{Limits: h.pool.ConcurrencyLimits(), Usage: h.pool.ConcurrencyStats()}
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// setConcurrency replaces the per type and per key concurrency limits.
+func (h *AdminHandler) setConcurrency(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var limits taskpool.ConcurrencyLimits
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&limits); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: `body must be {"types": {"name": limit, ...}, "keys": {"name": limit, ...}}`, Code: codeInvalidRequest})
+		return
+	}
+	if err := h.pool.SetConcurrencyLimits(limits); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+		return
+	}
+	h.logger.Info("concurrency limits updated", "types", limits.Types, "keys", limits.Keys)
+	h.getConcurrency(w, r)
+}
+
+func (h *AdminHandler) getWorkers(w http.ResponseWriter, r *http.Request) {
+	resp := WorkersResponse{WorkerStats: h.workers.Stats(), Workers: h.workers.Workers()}
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+// decodeAssignment reads a QueueAssignmentRequest, writing the error
+// response itself if the body is malformed.
+func (h *AdminHandler) decodeAssignment(w http.ResponseWriter, r *http.Request) (taskpool.QueueWeights, bool) {
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req QueueAssignmentRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: `body must be {"queues": {"name": weight, ...}}`, Code: codeInvalidRequest})
+		return nil, false
+	}
+	return req.Queues, true
+}
+
+// assignQueues assigns every worker, and those started later, to queues.
+func (h *AdminHandler) assignQueues(w http.ResponseWriter, r *http.Request) {
+	weights, ok := h.decodeAssignment(w, r)
+	if !ok {
+		return
+	}
+	if err := h.workers.AssignQueues(weights); err != nil {
+		h.writeAssignmentError(w, err)
+		return
+	}
+	h.logger.Info("workers assigned to queues", "queues", weights.String())
+	h.getWorkers(w, r)
+}
+
+// assignWorkerQueues assigns a single worker to queues.
+func (h *AdminHandler) assignWorkerQueues(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "worker not found", Code: codeWorkerNotFound})
+		return
+	}
+	weights, ok := h.decodeAssignment(w, r)
+	if !ok {
+		return
+	}
+	info, err := h.workers.AssignWorkerQueues(id, weights)
+	if err != nil {
+		h.writeAssignmentError(w, err)
+		return
+	}
+	h.logger.Info("worker assigned to queues", "worker_id", id, "queues", weights.String())
+	if err := writeJSON(w, http.StatusOK, info); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *AdminHandler) writeAssignmentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, taskpool.ErrWorkerNotFound):
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "worker not found", Code: codeWorkerNotFound})
+	case errors.Is(err, taskpool.ErrUnknownQueue) || errors.Is(err, taskpool.ErrInvalidQueueConfig):
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+	default:
+		h.logger.Error("failed to assign queues", "error", err)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+	}
+}
+
+// setWorkers resizes the worker pool. Shrinking retires idle workers first;
+// busy ones finish their task and show up as retiring until they exit.
+func (h *AdminHandler) setWorkers(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("setWorkers handler called", "method", r.Method, "url", r.URL.String())
+
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req WorkerCountRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil || req.Count == nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: `body must be {"count": N}`, Code: codeInvalidRequest})
+		return
+	}
+
+	stats, err := h.workers.Resize(*req.Count)
+	if errors.Is(err, taskpool.ErrInvalidWorkerCount) {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to resize workers", "error", err)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+		return
+	}
+	h.logger.Info("worker pool resized", "count", stats.Count, "retiring", stats.Retiring)
+	if err := writeJSON(w, http.StatusOK, stats); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *AdminHandler) pause(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, h.pool.Pause, h.pool.PauseQueue, "dispatching paused")
+}
+
+func (h *AdminHandler) resume(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, h.pool.Resume, h.pool.ResumeQueue, "dispatching resumed")
+}
+
+type pauseFunc func(ctx context.Context, name string) (*models.PauseState, error)
+
+// setPaused applies a pause or resume to a task type, a queue or, with
+// neither, the whole pool. Paused tasks are still accepted and queued;
+// workers just do not pick them up.
+func (h *AdminHandler) setPaused(w http.ResponseWriter, r *http.Request, byType, byQueue pauseFunc, msg string) {
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req PauseRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("failed to decode request", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON body", Code: codeInvalidRequest})
+		return
+	}
+	taskType, queue := strings.TrimSpace(req.Type), strings.TrimSpace(req.Queue)
+	switch {
+	case len(taskType) > maxTaskTypeLength:
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "type too long", Code: codeInvalidRequest})
+		return
+	case taskType != "" && queue != "":
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "set either type or queue, not both", Code: codeInvalidRequest})
+		return
+	}
+
+	var (
+		state *models.PauseState
+		err   error
+	)
+	if queue != "" {
+		state, err = byQueue(r.Context(), queue)
+	} else {
+		state, err = byType(r.Context(), taskType)
+	}
+	if errors.Is(err, taskpool.ErrUnknownQueue) {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to update pause state", "error", err, "type", taskType, "queue", queue)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+		return
+	}
+	h.logger.Info(msg, "type", taskType, "queue", queue, "global", state.Global, "paused_types", state.Types, "paused_queues", state.Queues)
+	if err := writeJSON(w, http.StatusOK, state); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
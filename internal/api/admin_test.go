@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+func createTestAdminMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(10, st, taskpool.WithQueue("bulk", 5))
+	workers := taskpool.NewWorkerManager(2, st)
+	workers.InitiateWorkers(pool)
+	t.Cleanup(workers.ForceStopWorkers)
+	mux := http.NewServeMux()
+	RegisterAdminRoutes(mux, NewAdminHandler(pool, workers, logger.NewTestLogger()))
+	return mux
+}
+
+// TestSetWorkers tests resizing the worker pool and reading the count back from stats
+func TestSetWorkers(t *testing.T) {
+	mux := createTestAdminMux(t)
+
+	w := doJSON(mux, "PUT", "/admin/workers", json.RawMessage(`{"count": 5}`))
+	var stats taskpool.WorkerStats
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	if w.Code != http.StatusOK || stats.Count != 5 {
+		t.Fatalf("Expected 5 workers, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(mux, "GET", "/admin/stats", nil)
+	var resp StatsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Workers.Count != 5 || resp.Queue.Capacity != 15 || len(resp.Queues) != 2 {
+		t.Errorf("Unexpected stats %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{`{}`, `{"count": -1}`, `{"count": "many"}`, `{"workers": 3}`} {
+		if w := doJSON(mux, "PUT", "/admin/workers", json.RawMessage(body)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestPauseAndResume tests pausing globally and per type, and reading the state from stats
+func TestPauseAndResume(t *testing.T) {
+	mux := createTestAdminMux(t)
+
+	w := doJSON(mux, "POST", "/admin/pause", nil)
+	var state models.PauseState
+	json.Unmarshal(w.Body.Bytes(), &state)
+	if w.Code != http.StatusOK || !state.Global {
+		t.Fatalf("Expected a global pause, got %d: %s", w.Code, w.Body.String())
+	}
+	doJSON(mux, "POST", "/admin/pause", json.RawMessage(`{"type": "echo"}`))
+	w = doJSON(mux, "POST", "/admin/resume", json.RawMessage(`{}`))
+	json.Unmarshal(w.Body.Bytes(), &state)
+	if w.Code != http.StatusOK || state.Global || len(state.Types) != 1 || state.Types[0] != "echo" {
+		t.Fatalf("Expected only echo paused, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(mux, "GET", "/admin/stats", nil)
+	var resp StatsResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Pauses == nil || len(resp.Pauses.Types) != 1 {
+		t.Errorf("Expected pauses in stats, got %s", w.Body.String())
+	}
+
+	if w := doJSON(mux, "POST", "/admin/pause", json.RawMessage(`{"queue": 1}`)); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestQueuesAndAssignments tests per-queue stats and assigning workers to queues
+func TestQueuesAndAssignments(t *testing.T) {
+	mux := createTestAdminMux(t)
+
+	w := doJSON(mux, "GET", "/admin/queues", nil)
+	var queues []taskpool.NamedQueueStats
+	json.Unmarshal(w.Body.Bytes(), &queues)
+	if w.Code != http.StatusOK || len(queues) != 2 || queues[0].Name != "bulk" || queues[1].Name != taskpool.DefaultQueue {
+		t.Fatalf("Unexpected queues %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(mux, "PUT", "/admin/workers/queues", json.RawMessage(`{"queues": {"bulk": 3, "default": 1}}`))
+	var resp WorkersResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Workers) != 2 || resp.Workers[0].Queues["bulk"] != 3 {
+		t.Fatalf("Expected every worker assigned, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(mux, "PUT", "/admin/workers/2/queues", json.RawMessage(`{"queues": {}}`))
+	var info taskpool.WorkerInfo
+	json.Unmarshal(w.Body.Bytes(), &info)
+	if w.Code != http.StatusOK || info.ID != 2 || len(info.Queues) != 0 {
+		t.Errorf("Expected worker 2 on every queue, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for _, c := range []struct {
+		path, body string
+		status     int
+	}{
+		{"/admin/workers/queues", `{"queues": {"missing": 1}}`, http.StatusBadRequest},
+		{"/admin/workers/queues", `{"queues": {"bulk": 0}}`, http.StatusBadRequest},
+		{"/admin/workers/1/queues", `{"weights": {}}`, http.StatusBadRequest},
+		{"/admin/workers/99/queues", `{"queues": {}}`, http.StatusNotFound},
+	} {
+		if w := doJSON(mux, "PUT", c.path, json.RawMessage(c.body)); w.Code != c.status {
+			t.Errorf("%s %s: expected status %d, got %d", c.path, c.body, c.status, w.Code)
+		}
+	}
+
+	w = doJSON(mux, "POST", "/admin/pause", json.RawMessage(`{"queue": "bulk"}`))
+	var state models.PauseState
+	json.Unmarshal(w.Body.Bytes(), &state)
+	if w.Code != http.StatusOK || len(state.Queues) != 1 {
+		t.Errorf("Expected bulk paused, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, body := range []string{`{"queue": "missing"}`, `{"queue": "bulk", "type": "echo"}`} {
+		if w := doJSON(mux, "POST", "/admin/pause", json.RawMessage(body)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestConcurrencyLimits tests setting per type and per key concurrency limits and reading them back
+func TestConcurrencyLimits(t *testing.T) {
+	mux := createTestAdminMux(t)
+
+	w := doJSON(mux, "PUT", "/admin/concurrency", json.RawMessage(`{"types": {"echo": 2}, "keys": {"*": 1}}`))
+	var resp ConcurrencyResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Limits.Types["echo"] != 2 || resp.Limits.Keys[taskpool.AnyKey] != 1 {
+		t.Fatalf("Expected the limits back, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(mux, "GET", "/admin/concurrency", nil)
+	resp = ConcurrencyResponse{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Usage) != 2 || resp.Usage[0].Kind != "type" || resp.Usage[0].Name != "echo" || resp.Usage[0].Limit != 2 {
+		t.Errorf("Unexpected concurrency usage: %s", w.Body.String())
+	}
+
+	for _, body := range []string{`{"types": {"echo": 0}}`, `{"types": {"echo": "two"}}`, `{"limits": {}}`} {
+		if w := doJSON(mux, "PUT", "/admin/concurrency", json.RawMessage(body)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+const (
+	maxBatchSize     = 1000
+	maxBatchBodySize = 16 << 20 // 16MB
+)
+
+type BatchMode string
+
+const (
+	BatchAllOrNothing BatchMode = "all_or_nothing" // reject the whole batch if any task is rejected
+	BatchBestEffort   BatchMode = "best_effort"    // add what can be added, report the rest
+)
+
+type BatchRequest struct {
+	Mode  BatchMode     `json:"mode,omitempty"` // defaults to all_or_nothing
+	Tasks []TaskRequest `json:"tasks"`
+}
+
+// BatchResult is the outcome for the task at Index in the request.
+type BatchResult struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+	Code  string `json:"code,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode     BatchMode     `json:"mode"`
+	Created  int           `json:"created"`
+	Rejected int           `json:"rejected"`
+	Results  []BatchResult `json:"results"`
+}
+
+// createBatch adds up to maxBatchSize tasks in one request. Every item is
+// validated with the same rules as POST /tasks. In all_or_nothing mode a
+// single invalid item or a queue too small for the batch rejects it all;
+// in best_effort mode each item succeeds or fails on its own.
+func (h *Handler) createBatch(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("createBatch handler called", "method", r.Method, "url", r.URL.String())
+
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodySize)
+
+	var req BatchRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.logger.Error("failed to decode request", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON body", Code: codeInvalidRequest})
+		return
+	}
+	switch req.Mode {
+	case "":
+		req.Mode = BatchAllOrNothing
+	case BatchAllOrNothing, BatchBestEffort:
+	default:
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("unknown batch mode %q", req.Mode), Code: codeInvalidRequest})
+		return
+	}
+	if len(req.Tasks) == 0 || len(req.Tasks) > maxBatchSize {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("a batch holds between 1 and %d tasks", maxBatchSize), Code: codeInvalidRequest})
+		return
+	}
+
+	resp := BatchResponse{Mode: req.Mode, Results: make([]BatchResult, len(req.Tasks))}
+	tasks := make([]*models.Task, len(req.Tasks))
+	for i := range req.Tasks {
+		resp.Results[i].Index = i
+		task, err := req.Tasks[i].toTask()
+		if err == nil && req.Mode == BatchAllOrNothing && req.Tasks[i].IdempotencyKey != "" {
+			err = errors.New("idempotency keys are only supported in best_effort batches")
+		}
+		if err == nil && len(req.Tasks[i].IdempotencyKey) > maxIdempotencyKeyLength {
+			err = errors.New("idempotency key too long")
+		}
+		if err == nil && req.Tasks[i].MaxWait != 0 {
+			err = errors.New("max_wait is only supported for single tasks")
+		}
+		if err != nil {
+			resp.Results[i].Error = err.Error()
+			resp.Results[i].Code = codeInvalidRequest
+			resp.Rejected++
+			continue
+		}
+		task.ID = uuid.New().String()
+		task.Tenant = tenantFrom(r.Context())
+		tasks[i] = task
+	}
+	for i, task := range tasks {
+		if task == nil {
+			continue
+		}
+		if err := resolveBatchRefs(task, i, tasks, req.Mode == BatchBestEffort); err != nil {
+			resp.Results[i].Error = err.Error()
+			resp.Results[i].Code = codeInvalidRequest
+			resp.Rejected++
+			tasks[i] = nil
+		}
+	}
+
+	if req.Mode == BatchBestEffort {
+		h.addBestEffort(w, r.Context(), req.Tasks, tasks, &resp)
+		return
+	}
+	if resp.Rejected > 0 {
+		h.logger.Warn("batch rejected", "invalid", resp.Rejected)
+		h.writeBatch(w, http.StatusBadRequest, &resp)
+		return
+	}
+
+	err := h.pool.AddBatch(r.Context(), h.logger, tasks)
+	var batchErr *taskpool.BatchError
+	switch {
+	case err == nil:
+		for i, task := range tasks {
+			resp.Results[i].ID = task.ID
+		}
+		resp.Created = len(tasks)
+		h.logger.Info("batch added", "tasks", len(tasks))
+		h.writeBatch(w, http.StatusCreated, &resp)
+	case errors.Is(err, taskpool.ErrTenantQuotaExceeded):
+		writeError(w, http.StatusTooManyRequests, ErrorResponse{Error: "batch exceeds the tenant's queue quota", Code: codeTenantQuota})
+	case errors.Is(err, taskpool.ErrTaskQueueFull):
+		writeError(w, http.StatusTooManyRequests, ErrorResponse{Error: "task queue cannot hold the whole batch", Code: codeQueueFull})
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusRequestTimeout, ErrorResponse{Error: "request cancelled", Code: codeCancelled})
+	case errors.As(err, &batchErr) && errors.Is(err, taskpool.ErrInvalidTask):
+		resp.Results[batchErr.Index].Error = batchErr.Err.Error()
+		resp.Results[batchErr.Index].Code = codeInvalidRequest
+		resp.Rejected = 1
+		h.writeBatch(w, http.StatusBadRequest, &resp)
+	default:
+		h.logger.Error("failed to add batch", "error", err)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+	}
+}
+
+// resolveBatchRefs replaces "#N" entries in task.DependsOn with the ID
+// assigned to the task at index N. Best-effort batches add tasks in order,
+// so there a task may only depend on an earlier one.
+func resolveBatchRefs(task *models.Task, index int, tasks []*models.Task, bestEffort bool) error {
+	for i, dep := range task.DependsOn {
+		ref, ok := strings.CutPrefix(dep, "#")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(ref)
+		switch {
+		case err != nil || n < 0 || n >= len(tasks):
+			return fmt.Errorf("depends_on %q does not name a task in this batch", dep)
+		case n == index:
+			return errors.New("task cannot depend on itself")
+		case bestEffort && n > index:
+			return fmt.Errorf("depends_on %q must refer to an earlier task in a best_effort batch", dep)
+		case tasks[n] == nil:
+			return fmt.Errorf("depends on task %d, which was rejected", n)
+		}
+		task.DependsOn[i] = tasks[n].ID
+	}
+	return nil
+}
+
+// addBestEffort adds each valid task on its own. Items with an idempotency
+// key are deduplicated exactly like single submissions.
+func (h *Handler) addBestEffort(w http.ResponseWriter, ctx context.Context, reqs []TaskRequest, tasks []*models.Task, resp *BatchResponse) {
+	for i, task := range tasks {
+		if task == nil {
+			continue // failed validation
+		}
+		result := &resp.Results[i]
+		var (
+			id  string
+			err error
+		)
+		if key := reqs[i].IdempotencyKey; key != "" {
+			id, _, err = h.pool.AddTaskIdempotent(ctx, h.logger, key, reqs[i].hash(), task)
+		} else {
+			id, err = h.pool.AddTask(ctx, h.logger, task)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			switch {
+			case errors.Is(err, taskpool.ErrIdempotencyMismatch):
+				result.Code = codeIdempotencyMismatch
+			case errors.Is(err, taskpool.ErrTenantQuotaExceeded):
+				result.Code = codeTenantQuota
+			case errors.Is(err, taskpool.ErrTaskQueueFull):
+				result.Code = codeQueueFull
+			case errors.Is(err, taskpool.ErrInvalidTask):
+				result.Code = codeInvalidRequest
+			default:
+				result.Code = codeInternal
+			}
+			resp.Rejected++
+			continue
+		}
+		result.ID = id
+		resp.Created++
+	}
+	h.logger.Info("best-effort batch added", "created", resp.Created, "rejected", resp.Rejected)
+	h.writeBatch(w, http.StatusOK, resp)
+}
+
+func (h *Handler) writeBatch(w http.ResponseWriter, status int, resp *BatchResponse) {
+	if err := writeJSON(w, status, resp); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
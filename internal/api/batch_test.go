@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+func postBatch(t *testing.T, h *Handler, body string) (*httptest.ResponseRecorder, BatchResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.createBatch(w, httptest.NewRequest("POST", "/tasks/batch", strings.NewReader(body)))
+	var resp BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+// TestCreateBatchAllOrNothing tests that one bad item or a full queue rejects the batch
+func TestCreateBatchAllOrNothing(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(2, st)
+	h := NewHandler(pool, st, logger.NewTestLogger())
+
+	w, resp := postBatch(t, h, `{"tasks": [{"title": "A"}, {"title": ""}]}`)
+	if w.Code != http.StatusBadRequest || resp.Results[1].Error != "title is required" || resp.Results[0].ID != "" {
+		t.Errorf("Expected per-item validation errors, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w, _ := postBatch(t, h, `{"tasks": [{"title": "A"}, {"title": "B"}, {"title": "C"}]}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d for an oversized batch, got %d", http.StatusTooManyRequests, w.Code)
+	}
+
+	w, resp = postBatch(t, h, `{"mode": "all_or_nothing", "tasks": [{"title": "A"}, {"title": "B", "priority": 3}]}`)
+	if w.Code != http.StatusCreated || resp.Created != 2 || resp.Results[0].ID == "" || resp.Results[1].ID == "" {
+		t.Fatalf("Expected both tasks created, got %d: %s", w.Code, w.Body.String())
+	}
+	if pool.Len() != 2 {
+		t.Errorf("Expected 2 queued tasks, got %d", pool.Len())
+	}
+}
+
+// TestCreateBatchBestEffort tests per-item outcomes when only some tasks fit
+func TestCreateBatchBestEffort(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(1, st)
+	h := NewHandler(pool, st, logger.NewTestLogger())
+
+	w, resp := postBatch(t, h, `{"mode": "best_effort", "tasks": [{"title": "A"}, {"title": ""}, {"title": "C"}]}`)
+	if w.Code != http.StatusOK || resp.Created != 1 || resp.Rejected != 2 {
+		t.Fatalf("Unexpected response %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Results[0].ID == "" || resp.Results[1].Code != codeInvalidRequest || resp.Results[2].Code != codeQueueFull {
+		t.Errorf("Unexpected per-item results: %+v", resp.Results)
+	}
+
+	for _, body := range []string{`{"tasks": []}`, `{"mode": "yolo", "tasks": [{"title": "A"}]}`} {
+		if w, _ := postBatch(t, h, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestCreateBatchDependencies tests "#N" references between items of a batch
+func TestCreateBatchDependencies(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(5, st)
+	h := NewHandler(pool, st, logger.NewTestLogger())
+
+	w, resp := postBatch(t, h, `{"tasks": [{"title": "C", "depends_on": ["#1", "#2"]}, {"title": "A"}, {"title": "B"}]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	c, _ := st.GetTask(context.Background(), resp.Results[0].ID)
+	if c.Status != models.Blocked || len(c.DependsOn) != 2 || c.DependsOn[0] != resp.Results[1].ID {
+		t.Errorf("Expected C blocked on A and B, got %s %v", c.Status, c.DependsOn)
+	}
+
+	for body, want := range map[string]int{
+		`{"tasks": [{"title": "A", "depends_on": ["#1"]}, {"title": "B", "depends_on": ["#0"]}]}`:  http.StatusBadRequest,
+		`{"tasks": [{"title": "A", "depends_on": ["#5"]}]}`:                                        http.StatusBadRequest,
+		`{"mode": "best_effort", "tasks": [{"title": "A", "depends_on": ["#1"]}, {"title": "B"}]}`: http.StatusOK,
+	} {
+		w, resp := postBatch(t, h, body)
+		if w.Code != want || resp.Rejected == 0 {
+			t.Errorf("%s: expected status %d with a rejected item, got %d: %s", body, want, w.Code, w.Body.String())
+		}
+	}
+}
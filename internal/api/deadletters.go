@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+type DeadLetterHandler struct {
+	pool   *taskpool.TaskPool
+	store  store.DeadLetterStore
+	logger *logger.Logger
+}
+
+func NewDeadLetterHandler(pool *taskpool.TaskPool, store store.DeadLetterStore, logger *logger.Logger) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		pool:   pool,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// BulkRequeueRequest selects dead letters to requeue. Empty IDs means all.
+type BulkRequeueRequest struct {
+	IDs []string `json:"ids,omitempty"`
+}
+
+type RequeueFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+type BulkRequeueResponse struct {
+	Requeued []string         `json:"requeued"`
+	Failed   []RequeueFailure `json:"failed"`
+}
+
+func (h *DeadLetterHandler) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	dead, err := h.store.ListDeadLetters(r.Context())
+	if err != nil {
+		h.writeDeadLetterError(w, "", err)
+		return
+	}
+	sort.Slice(dead, func(i, j int) bool { return dead[i].FailedAt.Before(dead[j].FailedAt) })
+	if err := writeJSON(w, http.StatusOK, dead); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *DeadLetterHandler) getDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	dl, err := h.store.GetDeadLetter(r.Context(), id)
+	if err != nil {
+		h.writeDeadLetterError(w, id, err)
+		return
+	}
+	if err := writeJSON(w, http.StatusOK, dl); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "task_id", id)
+	}
+}
+
+func (h *DeadLetterHandler) requeue(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	h.logger.Info("requeue handler called", "task_id", id, "method", r.Method, "url", r.URL.String())
+
+	task, err := h.pool.Requeue(r.Context(), h.logger, id)
+	if err != nil {
+		h.writeDeadLetterError(w, id, err)
+		return
+	}
+	if err := writeJSON(w, http.StatusOK, task); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "task_id", id)
+	}
+}
+
+// requeueBulk requeues each selected dead letter independently and reports
+// per-ID failures instead of stopping at the first one.
+func (h *DeadLetterHandler) requeueBulk(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("bulk requeue handler called", "method", r.Method, "url", r.URL.String())
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req BulkRequeueRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("failed to decode request", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON body", Code: codeInvalidRequest})
+		return
+	}
+
+	ctx := r.Context()
+	ids := req.IDs
+	if len(ids) == 0 {
+		dead, err := h.store.ListDeadLetters(ctx)
+		if err != nil {
+			h.writeDeadLetterError(w, "", err)
+			return
+		}
+		sort.Slice(dead, func(i, j int) bool { return dead[i].FailedAt.Before(dead[j].FailedAt) })
+		for _, dl := range dead {
+			ids = append(ids, dl.ID)
+		}
+	}
+
+	resp := BulkRequeueResponse{Requeued: []string{}, Failed: []RequeueFailure{}}
+	for _, id := range ids {
+		if _, err := h.pool.Requeue(ctx, h.logger, id); err != nil {
+			resp.Failed = append(resp.Failed, RequeueFailure{ID: id, Error: err.Error()})
+			continue
+		}
+		resp.Requeued = append(resp.Requeued, id)
+	}
+	h.logger.Info("bulk requeue finished", "requeued", len(resp.Requeued), "failed", len(resp.Failed))
+	if err := writeJSON(w, http.StatusOK, resp); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *DeadLetterHandler) writeDeadLetterError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, store.ErrDeadLetterNotFound):
+		h.logger.Warn("dead letter not found", "task_id", id)
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "dead letter not found", Code: codeDeadLetterNotFound, TaskID: id})
+	case errors.Is(err, taskpool.ErrInvalidTask):
+		writeError(w, http.StatusConflict, ErrorResponse{Error: err.Error(), Code: codeConflict, TaskID: id})
+	case errors.Is(err, taskpool.ErrTenantQuotaExceeded):
+		writeError(w, http.StatusTooManyRequests, ErrorResponse{Error: "tenant quota exceeded", Code: codeTenantQuota, TaskID: id})
+	case errors.Is(err, taskpool.ErrTaskQueueFull):
+		writeError(w, http.StatusTooManyRequests, ErrorResponse{Error: "task queue is full", Code: codeQueueFull, TaskID: id})
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusRequestTimeout, ErrorResponse{Error: "request cancelled", Code: codeCancelled})
+	default:
+		h.logger.Error("dead letter request failed", "error", err, "task_id", id)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+func createTestDeadLetterMux(t *testing.T) (*http.ServeMux, *store.MemoryStore) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(5, st, taskpool.WithDeadLetters(st))
+	ctx := context.Background()
+	for i, id := range []string{"dead-1", "dead-2", "dead-3"} {
+		st.AddDeadLetter(ctx, &models.DeadLetter{
+			ID:       id,
+			Error:    "boom",
+			FailedAt: time.Unix(int64(i), 0),
+			Task:     &models.Task{ID: id, Title: id, Status: models.Failed, Attempts: 1},
+		})
+	}
+	mux := http.NewServeMux()
+	RegisterDeadLetterRoutes(mux, NewDeadLetterHandler(pool, st, logger.NewTestLogger()))
+	return mux, st
+}
+
+// TestListAndRequeueDeadLetter tests browsing dead letters and requeueing one
+func TestListAndRequeueDeadLetter(t *testing.T) {
+	mux, st := createTestDeadLetterMux(t)
+
+	w := doJSON(mux, "GET", "/dead-letters", nil)
+	var list []models.DeadLetter
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || len(list) != 3 || list[0].ID != "dead-1" {
+		t.Fatalf("Unexpected dead-letter listing %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(mux, "POST", "/dead-letters/dead-2/requeue", nil); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	task, err := st.GetTask(context.Background(), "dead-2")
+	if err != nil || task.Status != models.Pending {
+		t.Errorf("Expected requeued task to be pending, got %+v, %v", task, err)
+	}
+
+	w = doJSON(mux, "POST", "/dead-letters/dead-2/requeue", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d requeueing twice, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestBulkRequeueDeadLetters tests requeueing a selection and reporting unknown IDs
+func TestBulkRequeueDeadLetters(t *testing.T) {
+	mux, st := createTestDeadLetterMux(t)
+
+	w := doJSON(mux, "POST", "/dead-letters/requeue", BulkRequeueRequest{IDs: []string{"dead-1", "missing"}})
+	var resp BulkRequeueResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Requeued) != 1 || len(resp.Failed) != 1 || resp.Failed[0].ID != "missing" {
+		t.Fatalf("Unexpected bulk response %d: %s", w.Code, w.Body.String())
+	}
+
+	// An empty body requeues everything left
+	w = doJSON(mux, "POST", "/dead-letters/requeue", nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Requeued) != 2 || len(resp.Failed) != 0 {
+		t.Errorf("Expected remaining 2 dead letters requeued, got %s", w.Body.String())
+	}
+	if left, _ := st.ListDeadLetters(context.Background()); len(left) != 0 {
+		t.Errorf("Expected no dead letters left, got %d", len(left))
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Machine-readable error codes returned in structured error bodies.
+const (
+	codeTaskNotFound = "task_not_found"
+	codeTaskFinished = "task_finished"
+	codeConflict     = "conflict"
+	codeInternal     = "internal_error"
+	codeCancelled    = "request_cancelled"
+
+	codeInvalidRequest   = "invalid_request"
+	codeScheduleNotFound = "schedule_not_found"
+
+	codeDeadLetterNotFound = "dead_letter_not_found"
+	codeQueueFull          = "queue_full"
+
+	codeIdempotencyMismatch = "idempotency_key_reused"
+
+	codeWorkflowNotFound = "workflow_not_found"
+
+	codeWorkerNotFound = "worker_not_found"
+
+	codeUnauthorized = "unauthorized"
+	codeTenantQuota  = "tenant_quota_exceeded"
+)
+
+// ErrorResponse is the JSON body of structured API errors.
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Code   string `json:"code"`
+	TaskID string `json:"task_id,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, resp ErrorResponse) {
+	writeJSON(w, status, resp)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/events"
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// sseKeepAlive is how often an idle stream sends a comment so proxies and
+// clients do not time the connection out.
+const sseKeepAlive = 15 * time.Second
+
+// eventFilter narrows a stream to task events matching every non-empty field.
+// With no filter set, worker events are streamed too.
+type eventFilter struct {
+	taskID   string
+	statuses map[models.Status]bool
+	taskType string
+}
+
+func parseEventFilter(r *http.Request) eventFilter {
+	q := r.URL.Query()
+	f := eventFilter{
+		taskID:   q.Get("task_id"),
+		taskType: q.Get("type"),
+	}
+	for _, s := range splitList(q["status"]) {
+		if f.statuses == nil {
+			f.statuses = make(map[models.Status]bool)
+		}
+		f.statuses[models.Status(s)] = true
+	}
+	return f
+}
+
+func (f eventFilter) match(e events.Event) bool {
+	if f.taskID == "" && f.statuses == nil && f.taskType == "" {
+		return true
+	}
+	if e.Type != events.TaskStatus {
+		return false
+	}
+	if f.taskID != "" && e.TaskID != f.taskID {
+		return false
+	}
+	if f.taskType != "" && e.TaskType != f.taskType {
+		return false
+	}
+	return f.statuses == nil || f.statuses[e.Status]
+}
+
+// streamEvents serves the event bus as Server-Sent Events. Clients resume
+// with the standard Last-Event-ID header (or ?last_event_id= for the first
+// connection); if the requested events are no longer buffered a "gap" event
+// is sent before the oldest ones still available.
+func (h *Handler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("streamEvents handler called", "method", r.Method, "url", r.URL.String())
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var after uint64
+	if lastID != "" {
+		n, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid Last-Event-ID", Code: codeInvalidRequest})
+			return
+		}
+		after = n
+	}
+	filter := parseEventFilter(r)
+
+	rc := http.NewResponseController(w)
+	// The server's write timeout is meant for ordinary requests; a stream
+	// stays open until the client leaves.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	sub, backlog, complete := h.pool.Events.Subscribe(after)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if !complete {
+		fmt.Fprint(w, "event: gap\ndata: {\"error\":\"some events are no longer buffered\"}\n\n")
+	}
+	for _, e := range backlog {
+		if filter.match(e) {
+			writeSSE(w, e)
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("event stream not supported", "error", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case e, ok := <-sub.C:
+			if !ok {
+				// Fell too far behind; the client reconnects with
+				// Last-Event-ID and catches up from the buffer.
+				h.logger.Warn("event stream subscriber cut off")
+				return
+			}
+			if !filter.match(e) {
+				continue
+			}
+			writeSSE(w, e)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, e events.Event) {
+	data, _ := json.Marshal(e)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
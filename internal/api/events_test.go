@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/events"
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// readSSE collects n events from an open stream
+func readSSE(t *testing.T, resp *http.Response, n int) []events.Event {
+	t.Helper()
+	var got []events.Event
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var e events.Event
+			json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)
+			got = append(got, e)
+			if len(got) == n {
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for %d events, got %d", n, len(got))
+	}
+	return got
+}
+
+func openStream(t *testing.T, srv *httptest.Server, query, lastEventID string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("GET", srv.URL+"/events"+query, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", ct)
+	}
+	return resp
+}
+
+// TestStreamEventsFiltered tests live delivery of matching task events only
+func TestStreamEventsFiltered(t *testing.T) {
+	handler, _, pool := createTestHandler()
+	mux := http.NewServeMux()
+	RegisterTaskRoutes(mux, handler)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	resp := openStream(t, srv, "?task_id=wanted", "")
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+	pool.AddTask(ctx, log, &models.Task{ID: "other", Title: "Other"})
+	pool.AddTask(ctx, log, &models.Task{ID: "wanted", Title: "Wanted"})
+	pool.Cancel(ctx, "wanted")
+
+	got := readSSE(t, resp, 2)
+	if got[0].TaskID != "wanted" || got[0].Status != models.Pending || got[1].Status != models.Cancelled {
+		t.Errorf("Unexpected events: %+v", got)
+	}
+	if got[1].ID <= got[0].ID {
+		t.Errorf("Expected increasing event IDs, got %d then %d", got[0].ID, got[1].ID)
+	}
+}
+
+// TestStreamEventsResume tests that Last-Event-ID replays buffered events
+func TestStreamEventsResume(t *testing.T) {
+	handler, _, pool := createTestHandler()
+	mux := http.NewServeMux()
+	RegisterTaskRoutes(mux, handler)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	for _, id := range []string{"a", "b", "c"} {
+		pool.Events.Publish(events.Event{Type: events.TaskStatus, TaskID: id, Status: models.Pending})
+	}
+
+	got := readSSE(t, openStream(t, srv, "", "1"), 2)
+	if got[0].TaskID != "b" || got[1].TaskID != "c" {
+		t.Errorf("Expected events after ID 1 to be replayed, got %+v", got)
+	}
+}
+
+// TestStreamEventsInvalidLastEventID tests that a malformed Last-Event-ID is rejected
+func TestStreamEventsInvalidLastEventID(t *testing.T) {
+	handler, _, _ := createTestHandler()
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	w := httptest.NewRecorder()
+
+	handler.streamEvents(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -2,11 +2,17 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"math/rand"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shayanmkpr/task-pool/internal/logger"
@@ -16,20 +22,36 @@ import (
 )
 
 const (
-	maxRequestBodySize = 1 << 20 // 1MB //fix
-	minTaskDuration    = 1       // seconds //fix
-	maxTaskDuration    = 5       // seconds //fix
-	maxTitleLength     = 200     // characters //fix
-	maxDescLength      = 1000    // characters //fix
+	maxRequestBodySize      = 1 << 20 // 1MB //fix
+	minTaskDuration         = 1       // seconds //fix
+	maxTaskDuration         = 5       // seconds //fix
+	maxTitleLength          = 200     // characters //fix
+	maxDescLength           = 1000    // characters //fix
+	maxTags                 = 20
+	maxTagLength            = 64
+	maxConcurrencyKeyLength = 200
+	maxDependencies         = 100
+	defaultPageSize         = 100
+	maxPageSize             = 1000
+
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 5 * time.Minute
+
+	// maxEnqueueWait bounds max_wait well inside the server's write timeout.
+	maxEnqueueWait = 10 * time.Second
+
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotentReplayHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength = 255
 )
 
 type Handler struct {
 	pool   *taskpool.TaskPool
-	store  *store.MemoryStore
+	store  store.TaskStore
 	logger *logger.Logger
 }
 
-func NewHandler(pool *taskpool.TaskPool, store *store.MemoryStore, logger *logger.Logger) *Handler {
+func NewHandler(pool *taskpool.TaskPool, store store.TaskStore, logger *logger.Logger) *Handler {
 	return &Handler{
 		pool:   pool,
 		store:  store,
@@ -38,8 +60,143 @@ func NewHandler(pool *taskpool.TaskPool, store *store.MemoryStore, logger *logge
 }
 
 type TaskRequest struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	Title          string              `json:"title"`
+	Description    string              `json:"description"`
+	Type           string              `json:"type,omitempty"`
+	Queue          string              `json:"queue,omitempty"` // defaults to "default"
+	ConcurrencyKey string              `json:"concurrency_key,omitempty"`
+	Payload        json.RawMessage     `json:"payload,omitempty"`
+	Tags           []string            `json:"tags,omitempty"`
+	Priority       int                 `json:"priority,omitempty"`
+	Retry          *models.RetryPolicy `json:"retry,omitempty"`
+	Timeout        models.Duration     `json:"timeout,omitempty"`
+	Deadline       *time.Time          `json:"deadline,omitempty"`
+	RunAt          *time.Time          `json:"run_at,omitempty"`
+	Delay          models.Duration     `json:"delay,omitempty"`
+
+	// DependsOn lists the IDs of tasks that must finish before this one
+	// runs. Inside a batch, "#N" refers to the task at index N.
+	DependsOn           []string                `json:"depends_on,omitempty"`
+	OnDependencyFailure models.DependencyPolicy `json:"on_dependency_failure,omitempty"`
+
+	// IdempotencyKey deduplicates retried submissions. The Idempotency-Key
+	// header takes the same role for POST /tasks.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// MaxWait is how long POST /tasks may wait for room in a full queue
+	// before answering 429. Zero rejects at once.
+	MaxWait models.Duration `json:"max_wait,omitempty"`
+}
+
+// toTask validates the request and builds the task it describes, without an ID.
+func (req *TaskRequest) toTask() (*models.Task, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, errors.New("title is required")
+	}
+	if len(title) > maxTitleLength { //fix
+		return nil, errors.New("title too long")
+	}
+	if len(req.Description) > maxDescLength { //fix
+		return nil, errors.New("description too long")
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	if req.Priority < taskpool.MinPriority || req.Priority > taskpool.MaxPriority {
+		return nil, errors.New("priority must be between 0 and 9")
+	}
+	if err := taskpool.ValidateRetryPolicy(req.Retry); err != nil {
+		return nil, err
+	}
+	if req.RunAt != nil && req.Delay != 0 {
+		return nil, errors.New("run_at and delay are mutually exclusive")
+	}
+	if req.Delay < 0 {
+		return nil, errors.New("delay cannot be negative")
+	}
+	if len(req.DependsOn) > maxDependencies {
+		return nil, fmt.Errorf("at most %d dependencies are allowed", maxDependencies)
+	}
+	switch req.OnDependencyFailure {
+	case "", models.DependencyCancel, models.DependencyRun:
+	default:
+		return nil, fmt.Errorf("on_dependency_failure must be %q or %q", models.DependencyCancel, models.DependencyRun)
+	}
+	var dependsOn []string
+	for _, id := range req.DependsOn {
+		if id = strings.TrimSpace(id); id == "" {
+			return nil, errors.New("depends_on cannot contain empty ids")
+		}
+		dependsOn = append(dependsOn, id)
+	}
+	runAt := req.RunAt
+	if req.Delay > 0 {
+		at := time.Now().Add(req.Delay.Std()).UTC()
+		runAt = &at
+	}
+
+	concurrencyKey := strings.TrimSpace(req.ConcurrencyKey)
+	if len(concurrencyKey) > maxConcurrencyKeyLength {
+		return nil, errors.New("concurrency_key too long")
+	}
+
+	taskType := strings.TrimSpace(req.Type)
+	if taskType == "" {
+		taskType = taskpool.DefaultTaskType
+	}
+
+	return &models.Task{
+		Title:          title, //fix
+		Description:    req.Description,
+		Type:           taskType,
+		Queue:          strings.TrimSpace(req.Queue),
+		ConcurrencyKey: concurrencyKey,
+		Payload:        req.Payload,
+		Tags:           tags,
+		Priority:       req.Priority,
+		Retry:          req.Retry,
+		Timeout:        req.Timeout,
+		Deadline:       req.Deadline,
+		RunAt:          runAt,
+		DependsOn:      dependsOn,
+		OnDepFailure:   req.OnDependencyFailure,
+		Duration:       rand.Intn(maxTaskDuration-minTaskDuration+1) + minTaskDuration, //fix
+	}, nil
+}
+
+// hash fingerprints the request so a reused idempotency key can be told
+// apart from a retry of the same submission.
+func (req TaskRequest) hash() string {
+	req.IdempotencyKey = ""
+	req.MaxWait = 0 // how long to wait is not part of the task
+	data, _ := json.Marshal(req)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeTags trims and de-duplicates tags, keeping their order.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) > maxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTags)
+	}
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, errors.New("tags cannot be empty")
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out, nil
 }
 
 func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
@@ -62,42 +219,73 @@ func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid JSON body", http.StatusBadRequest)
 		return
 	}
-	title := strings.TrimSpace(req.Title)
-	if title == "" {
-		h.logger.Warn("title is required")
-		http.Error(w, "title is required", http.StatusBadRequest)
+	task, err := req.toTask()
+	if err != nil {
+		h.logger.Warn("invalid task request", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if len(title) > maxTitleLength { //fix
-		h.logger.Warn("title too long")                        //fix
-		http.Error(w, "title too long", http.StatusBadRequest) //fix
-		return                                                 //fix
+
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key != "" && req.IdempotencyKey != "" && key != req.IdempotencyKey {
+		http.Error(w, "Idempotency-Key header and idempotency_key field differ", http.StatusBadRequest)
+		return
 	}
-	if len(req.Description) > maxDescLength { //fix
-		h.logger.Warn("description too long")                        //fix
-		http.Error(w, "description too long", http.StatusBadRequest) //fix
-		return                                                       //fix
+	if key == "" {
+		key = req.IdempotencyKey
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		http.Error(w, "idempotency key too long", http.StatusBadRequest)
+		return
+	}
+	maxWait := req.MaxWait.Std()
+	if maxWait < 0 || maxWait > maxEnqueueWait {
+		http.Error(w, fmt.Sprintf("max_wait must be between 0 and %s", maxEnqueueWait), http.StatusBadRequest)
+		return
 	}
 
 	ctx := r.Context()
 
 	// Generate a Unique ID
-	newUUID := uuid.New().String()
+	task.ID = uuid.New().String()
+	task.Tenant = tenantFrom(ctx)
 
-	h.logger.Info("adding task to pool", "task_id", newUUID, "title", req.Title)
+	h.logger.Info("adding task to pool", "task_id", task.ID, "title", task.Title)
 
-	taskID, err := h.pool.AddTask(ctx, h.logger, &models.Task{
-		ID:          newUUID,
-		Title:       title, //fix
-		Description: req.Description,
-		Duration:    rand.Intn(maxTaskDuration-minTaskDuration+1) + minTaskDuration, //fix
-	})
+	var (
+		taskID   string
+		replayed bool
+	)
+	if key != "" {
+		taskID, replayed, err = h.pool.AddTaskIdempotentWait(ctx, h.logger, key, req.hash(), task, maxWait)
+	} else {
+		taskID, err = h.pool.AddTaskWait(ctx, h.logger, task, maxWait)
+	}
 	if err != nil {
 		h.logger.Error("failed to add task to pool", "error", err)
+		if errors.Is(err, taskpool.ErrIdempotencyMismatch) {
+			writeError(w, http.StatusUnprocessableEntity, ErrorResponse{Error: err.Error(), Code: codeIdempotencyMismatch, TaskID: taskID})
+			return
+		}
+		if errors.Is(err, taskpool.ErrIdempotencyDisabled) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			http.Error(w, "request cancelled", http.StatusRequestTimeout)
 			return
 		}
+		if errors.Is(err, taskpool.ErrInvalidTask) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, taskpool.ErrTaskQueueFull) {
+			setRetryAfter(w, h.pool.RetryAfter(task))
+		}
+		if errors.Is(err, taskpool.ErrTenantQuotaExceeded) {
+			http.Error(w, "tenant quota exceeded", http.StatusTooManyRequests)
+			return
+		}
 		// check if the error is becuase the task queue is full
 		if errors.Is(err, taskpool.ErrTaskQueueFull) { //fix
 			http.Error(w, "task queue is full", http.StatusTooManyRequests) //fix
@@ -106,8 +294,11 @@ func (h *Handler) createTask(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
-	h.logger.Info("task added successfully", "task_id", taskID)
+	h.logger.Info("task added successfully", "task_id", taskID, "replayed", replayed)
 
+	if replayed {
+		w.Header().Set(idempotentReplayHeader, "true")
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 
@@ -134,7 +325,8 @@ func (h *Handler) getTaskWithID(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Info("retrieving task from store", "task_id", id)
 
-	task, err := h.store.GetTask(ctx, id)
+	// Through the pool, so dead-lettered tasks are found too
+	task, err := h.pool.GetTask(ctx, id)
 	if err != nil {
 		h.logger.Error("failed to retrieve task", "error", err, "task_id", id)
 		http.Error(w, "task not found", http.StatusNotFound)
@@ -150,6 +342,37 @@ func (h *Handler) getTaskWithID(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("response sent successfully", "task_id", id)
 }
 
+// getTaskGraph returns the dependency graph the task belongs to.
+func (h *Handler) getTaskGraph(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	h.logger.Info("getTaskGraph handler called", "task_id", id, "method", r.Method, "url", r.URL.String())
+
+	graph, err := h.pool.Graph(r.Context(), id)
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrTaskNotFound):
+		h.logger.Warn("task not found", "task_id", id)
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "task not found", Code: codeTaskNotFound, TaskID: id})
+		return
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusRequestTimeout, ErrorResponse{Error: "request cancelled", Code: codeCancelled})
+		return
+	default:
+		h.logger.Error("failed to build task graph", "error", err, "task_id", id)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, graph); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "task_id", id)
+	}
+}
+
+// getAllTasks lists tasks matching the query parameters status, type (both
+// repeatable or comma-separated), tag, created_after and created_before
+// (RFC 3339), ordered by sort and order, one page of limit tasks at a time.
+// The body stays a plain array; the cursor for the next page is returned in
+// the X-Next-Cursor header and a Link header.
 func (h *Handler) getAllTasks(w http.ResponseWriter, r *http.Request) {
 	h.logger.Info("getAllTasks handler called", "method", r.Method, "url", r.URL.String())
 
@@ -159,13 +382,24 @@ func (h *Handler) getAllTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	query, err := parseTaskQuery(r)
+	if err != nil {
+		h.logger.Warn("invalid task query", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+		return
+	}
+
 	ctx := r.Context()
 
-	h.logger.Info("retrieving all tasks from store")
+	h.logger.Info("querying tasks from store")
 
-	tasks, err := h.store.ListTasks(ctx)
+	page, err := h.store.QueryTasks(ctx, query)
 	if err != nil {
 		h.logger.Error("failed to retrieve tasks", "error", err)
+		if errors.Is(err, store.ErrInvalidQuery) || errors.Is(err, store.ErrInvalidCursor) {
+			writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+			return
+		}
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			http.Error(w, "request cancelled", http.StatusRequestTimeout)
 			return
@@ -173,13 +407,175 @@ func (h *Handler) getAllTasks(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
-	h.logger.Info("tasks retrieved successfully", "count", len(tasks))
+	h.logger.Info("tasks retrieved successfully", "count", len(page.Tasks))
 
+	if page.NextCursor != "" {
+		next := *r.URL
+		q := next.Query()
+		q.Set("cursor", page.NextCursor)
+		next.RawQuery = q.Encode()
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(tasks); err != nil {
+	if err := json.NewEncoder(w).Encode(page.Tasks); err != nil {
 		h.logger.Error("failed to encode response", "error", err)
 		return
 	}
-	h.logger.Info("response sent successfully", "count", len(tasks))
+	h.logger.Info("response sent successfully", "count", len(page.Tasks))
+}
+
+func parseTaskQuery(r *http.Request) (store.TaskQuery, error) {
+	values := r.URL.Query()
+	q := store.TaskQuery{
+		Tag:    values.Get("tag"),
+		Sort:   store.SortField(values.Get("sort")),
+		Limit:  defaultPageSize,
+		Cursor: values.Get("cursor"),
+	}
+	for _, status := range splitList(values["status"]) {
+		q.Statuses = append(q.Statuses, models.Status(status))
+	}
+	q.Types = splitList(values["type"])
+
+	for name, dst := range map[string]*time.Time{
+		"created_after":  &q.CreatedAfter,
+		"created_before": &q.CreatedBefore,
+	} {
+		if v := values.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return q, fmt.Errorf("%s must be an RFC 3339 time", name)
+			}
+			*dst = t
+		}
+	}
+
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		q.Desc = true
+	default:
+		return q, errors.New("order must be asc or desc")
+	}
+
+	if v := values.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// splitList flattens repeated and comma-separated query values.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+func (h *Handler) cancelTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	h.logger.Info("cancelTask handler called", "task_id", id, "method", r.Method, "url", r.URL.String())
+
+	task, err := h.pool.Cancel(r.Context(), id)
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrTaskNotFound):
+		h.logger.Warn("task not found", "task_id", id)
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "task not found", Code: codeTaskNotFound, TaskID: id})
+		return
+	case errors.Is(err, taskpool.ErrTaskFinished):
+		h.logger.Warn("task already finished", "task_id", id, "status", task.Status)
+		writeError(w, http.StatusConflict, ErrorResponse{
+			Error:  "task already finished",
+			Code:   codeTaskFinished,
+			TaskID: id,
+			Status: string(task.Status),
+		})
+		return
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusRequestTimeout, ErrorResponse{Error: "request cancelled", Code: codeCancelled})
+		return
+	case task != nil:
+		h.logger.Warn("task not cancellable", "task_id", id, "error", err)
+		writeError(w, http.StatusConflict, ErrorResponse{Error: err.Error(), Code: codeConflict, TaskID: id, Status: string(task.Status)})
+		return
+	default:
+		h.logger.Error("failed to cancel task", "error", err, "task_id", id)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+		return
+	}
+
+	// A running task is only signalled; the worker records the final status.
+	status := http.StatusOK
+	if task.Status != models.Cancelled {
+		status = http.StatusAccepted
+	}
+	h.logger.Info("task cancellation requested", "task_id", id, "status", task.Status)
+	if err := writeJSON(w, status, task); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "task_id", id)
+	}
+}
+
+// waitTask long-polls until the task is terminal (200) or the timeout passes
+// (202 with its current state).
+func (h *Handler) waitTask(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	h.logger.Info("waitTask handler called", "task_id", id, "method", r.Method, "url", r.URL.String())
+
+	timeout := defaultWaitTimeout
+	if v := r.URL.Query().Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid timeout", Code: codeInvalidRequest})
+			return
+		}
+		timeout = min(d, maxWaitTimeout)
+	}
+
+	// Let the response outlive the server's default write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 5*time.Second))
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	task, err := h.pool.Wait(ctx, id)
+	switch {
+	case err == nil:
+	case errors.Is(err, store.ErrTaskNotFound):
+		h.logger.Warn("task not found", "task_id", id)
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "task not found", Code: codeTaskNotFound, TaskID: id})
+		return
+	case errors.Is(err, context.DeadlineExceeded) && r.Context().Err() == nil && task != nil:
+		h.logger.Info("wait timed out", "task_id", id, "status", task.Status)
+		if err := writeJSON(w, http.StatusAccepted, task); err != nil {
+			h.logger.Error("failed to encode response", "error", err, "task_id", id)
+		}
+		return
+	case r.Context().Err() != nil:
+		return // the client went away
+	default:
+		h.logger.Error("failed to wait for task", "error", err, "task_id", id)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+		return
+	}
+
+	if err := writeJSON(w, http.StatusOK, task); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "task_id", id)
+	}
+}
+
+// setRetryAfter tells a client turned away with 429 how many whole seconds
+// to wait before trying again.
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
 }
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shayanmkpr/task-pool/internal/logger"
 	"github.com/shayanmkpr/task-pool/internal/models"
@@ -118,8 +120,9 @@ func TestCreateTaskPoolFull(t *testing.T) {
 		Duration:    5,
 		Status:      models.Pending,
 	}
-	store.AddTask(longTask)
-	pool.Tasks <- longTask
+	if _, err := pool.AddTask(context.Background(), log, longTask); err != nil {
+		t.Fatalf("Failed to fill the pool: %v", err)
+	}
 
 	// Try to add another task - should fail because pool is full (by design)
 	taskReq := TaskRequest{
@@ -136,9 +139,40 @@ func TestCreateTaskPoolFull(t *testing.T) {
 	if w.Code != http.StatusTooManyRequests {
 		t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
 	}
+}
 
-	// Clean up
-	<-pool.Tasks
+// TestCreateTaskMaxWait tests that a submission waits for room up to max_wait and gets Retry-After when it still fails
+func TestCreateTaskMaxWait(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(1, store)
+	log := logger.NewTestLogger()
+	handler := NewHandler(pool, store, log)
+	if _, err := pool.AddTask(context.Background(), log, &models.Task{ID: "queued", Title: "Queued", Duration: 1}); err != nil {
+		t.Fatalf("Failed to fill the pool: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.createTask(w, req)
+		return w
+	}
+
+	w := post(`{"title": "Impatient", "max_wait": "50ms"}`)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected 429 with Retry-After: 60 from a queue that is not draining, got %d with %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := post(`{"title": "Too patient", "max_wait": "1h"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// A worker starting takes the queued task and makes room
+	workers := taskpool.NewWorkerManager(1, store)
+	t.Cleanup(workers.ForceStopWorkers)
+	time.AfterFunc(50*time.Millisecond, func() { workers.InitiateWorkers(pool) })
+	if w := post(`{"title": "Patient", "max_wait": "5s"}`); w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
 }
 
 // TestGetTaskWithIDSuccess tests successful task retrieval
@@ -152,7 +186,7 @@ func TestGetTaskWithIDSuccess(t *testing.T) {
 		Status:      models.Pending,
 		Duration:    1,
 	}
-	store.AddTask(task)
+	store.AddTask(context.Background(), task)
 
 	mux := http.NewServeMux()
 	RegisterTaskRoutes(mux, handler)
@@ -195,6 +229,24 @@ func TestGetTaskWithIDNotFound(t *testing.T) {
 	}
 }
 
+// TestGetTaskWithIDDeadLettered tests that a dead-lettered task can still be fetched by ID
+func TestGetTaskWithIDDeadLettered(t *testing.T) {
+	st := store.NewMemoryStore()
+	handler := NewHandler(taskpool.NewTaskPool(5, st, taskpool.WithDeadLetters(st)), st, logger.NewTestLogger())
+	st.AddDeadLetter(context.Background(), &models.DeadLetter{ID: "dead", Task: &models.Task{ID: "dead", Title: "Dead", Status: models.Failed}})
+
+	mux := http.NewServeMux()
+	RegisterTaskRoutes(mux, handler)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/tasks/dead", nil))
+
+	var response models.Task
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.Status != models.Failed {
+		t.Errorf("Expected the dead-lettered task, got %d %s", w.Code, w.Body.String())
+	}
+}
+
 // TestGetAllTasksSuccess tests retrieving all tasks
 func TestGetAllTasksSuccess(t *testing.T) {
 	handler, store, _ := createTestHandler()
@@ -213,8 +265,8 @@ func TestGetAllTasksSuccess(t *testing.T) {
 		Status:      models.Running,
 		Duration:    2,
 	}
-	store.AddTask(task1)
-	store.AddTask(task2)
+	store.AddTask(context.Background(), task1)
+	store.AddTask(context.Background(), task2)
 
 	req := httptest.NewRequest("GET", "/tasks", nil)
 	req.Header.Set("Content-Type", "application/json")
@@ -258,3 +310,418 @@ func TestRecoverPanic(t *testing.T) {
 		t.Errorf("Expected response to contain '%s', got '%s'", expected, w.Body.String())
 	}
 }
+
+// TestCreateTaskWithTypeAndPayload tests that type and payload are stored on the task
+func TestCreateTaskWithTypeAndPayload(t *testing.T) {
+	handler, store, _ := createTestHandler()
+
+	body := `{"title": "Typed Task", "type": "echo", "payload": {"to": "ops@example.com"}}`
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.createTask(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	storedTask, err := store.GetTask(context.Background(), response["id"])
+	if err != nil {
+		t.Fatalf("Failed to retrieve stored task: %v", err)
+	}
+	if storedTask.Type != "echo" {
+		t.Errorf("Expected type 'echo', got '%s'", storedTask.Type)
+	}
+	if string(storedTask.Payload) != `{"to": "ops@example.com"}` {
+		t.Errorf("Payload not stored verbatim, got %s", storedTask.Payload)
+	}
+}
+
+// TestCreateTaskDefaultType tests that untyped tasks get the default type
+func TestCreateTaskDefaultType(t *testing.T) {
+	handler, store, _ := createTestHandler()
+
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Untyped"}`))
+	w := httptest.NewRecorder()
+	handler.createTask(w, req)
+
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	storedTask, err := store.GetTask(context.Background(), response["id"])
+	if err != nil {
+		t.Fatalf("Failed to retrieve stored task: %v", err)
+	}
+	if storedTask.Type != taskpool.DefaultTaskType {
+		t.Errorf("Expected type '%s', got '%s'", taskpool.DefaultTaskType, storedTask.Type)
+	}
+}
+
+// TestCreateTaskQueue tests submitting to the default queue, a named queue and an unknown one
+func TestCreateTaskQueue(t *testing.T) {
+	handler, store, _ := createTestHandler()
+
+	for body, want := range map[string]string{
+		`{"title": "Plain"}`:                     taskpool.DefaultQueue,
+		`{"title": "Named", "queue": "default"}`: taskpool.DefaultQueue,
+	} {
+		req := httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.createTask(w, req)
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if task, err := store.GetTask(context.Background(), response["id"]); err != nil || task.Queue != want {
+			t.Errorf("%s: expected queue %q, got %+v, %v", body, want, task, err)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Lost", "queue": "missing"}`))
+	w := httptest.NewRecorder()
+	handler.createTask(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "unknown queue") {
+		t.Errorf("Expected status %d for an unknown queue, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+// TestCreateTaskPriority tests that priority is validated and stored
+func TestCreateTaskPriority(t *testing.T) {
+	handler, store, _ := createTestHandler()
+
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Urgent", "priority": 9}`))
+	w := httptest.NewRecorder()
+	handler.createTask(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	storedTask, err := store.GetTask(context.Background(), response["id"])
+	if err != nil {
+		t.Fatalf("Failed to retrieve stored task: %v", err)
+	}
+	if storedTask.Priority != 9 {
+		t.Errorf("Expected priority 9, got %d", storedTask.Priority)
+	}
+
+	for _, body := range []string{`{"title": "Too high", "priority": 10}`, `{"title": "Negative", "priority": -1}`} {
+		req := httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.createTask(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", body, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestCreateTaskRetryPolicy tests that retry settings are validated and stored
+func TestCreateTaskRetryPolicy(t *testing.T) {
+	handler, store, _ := createTestHandler()
+
+	body := `{"title": "Retried", "retry": {"max_attempts": 4, "backoff": "linear", "initial_delay": "2s", "max_delay": "10s", "jitter": 0.2}}`
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.createTask(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	storedTask, err := store.GetTask(context.Background(), response["id"])
+	if err != nil {
+		t.Fatalf("Failed to retrieve stored task: %v", err)
+	}
+	if storedTask.Retry == nil || storedTask.Retry.MaxAttempts != 4 || storedTask.Retry.InitialDelay.Std() != 2*time.Second {
+		t.Errorf("Retry policy not stored, got %+v", storedTask.Retry)
+	}
+
+	req = httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Bad", "retry": {"max_attempts": 2, "backoff": "random"}}`))
+	w = httptest.NewRecorder()
+	handler.createTask(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown backoff, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestCancelTask tests cancelling a pending task through the API
+func TestCancelTask(t *testing.T) {
+	handler, store, pool := createTestHandler()
+	mux := http.NewServeMux()
+	RegisterTaskRoutes(mux, handler)
+
+	task := &models.Task{ID: "cancel-me", Title: "Cancel Me"}
+	if _, err := pool.AddTask(context.Background(), logger.NewTestLogger(), task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/tasks/cancel-me/cancel", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	stored, _ := store.GetTask(context.Background(), "cancel-me")
+	if stored.Status != models.Cancelled {
+		t.Errorf("Expected status 'cancelled', got '%s'", stored.Status)
+	}
+}
+
+// TestCancelFinishedTaskConflict tests the structured 409 for finished tasks
+func TestCancelFinishedTaskConflict(t *testing.T) {
+	handler, store, _ := createTestHandler()
+	mux := http.NewServeMux()
+	RegisterTaskRoutes(mux, handler)
+
+	store.AddTask(context.Background(), &models.Task{ID: "finished", Title: "Finished", Status: models.Completed})
+
+	req := httptest.NewRequest("POST", "/tasks/finished/cancel", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Expected JSON error body: %v", err)
+	}
+	if resp.Code != codeTaskFinished || resp.Status != "completed" || resp.TaskID != "finished" {
+		t.Errorf("Unexpected error body: %+v", resp)
+	}
+
+	req = httptest.NewRequest("POST", "/tasks/unknown/cancel", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown task, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestCreateTaskTimeoutValidation tests that timeouts above the server maximum are rejected
+func TestCreateTaskTimeoutValidation(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(5, store, taskpool.WithTimeouts(0, time.Minute))
+	handler := NewHandler(pool, store, logger.NewTestLogger())
+
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Too long", "timeout": "2m"}`))
+	w := httptest.NewRecorder()
+	handler.createTask(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Fine", "timeout": "30s"}`))
+	w = httptest.NewRecorder()
+	handler.createTask(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+// TestCreateTaskDelayed tests run_at and delay handling
+func TestCreateTaskDelayed(t *testing.T) {
+	handler, store, pool := createTestHandler()
+
+	req := httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Later", "delay": "10m"}`))
+	w := httptest.NewRecorder()
+	handler.createTask(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	stored, _ := store.GetTask(context.Background(), response["id"])
+	if stored.Status != models.Scheduled || stored.RunAt == nil || time.Until(*stored.RunAt) < 9*time.Minute {
+		t.Errorf("Expected task scheduled ~10m ahead, got %+v", stored)
+	}
+	if pool.Scheduled() != 1 {
+		t.Errorf("Expected 1 scheduled task, got %d", pool.Scheduled())
+	}
+
+	body := `{"title": "Both", "delay": "1m", "run_at": "2030-01-01T02:00:00Z"}`
+	req = httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+	w = httptest.NewRecorder()
+	handler.createTask(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d when both run_at and delay are set, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestWaitTask tests the long-poll status codes
+func TestWaitTask(t *testing.T) {
+	handler, store, pool := createTestHandler()
+	ctx := context.Background()
+	store.AddTask(ctx, &models.Task{ID: "done", Title: "Done", Status: models.Completed})
+	pool.AddTask(ctx, logger.NewTestLogger(), &models.Task{ID: "queued", Title: "Queued"})
+
+	tests := []struct {
+		id, query string
+		want      int
+	}{
+		{"done", "", http.StatusOK},
+		{"queued", "?timeout=20ms", http.StatusAccepted},
+		{"queued", "?timeout=0s", http.StatusAccepted},
+		{"done", "?timeout=0s", http.StatusOK},
+		{"missing", "?timeout=0s", http.StatusNotFound},
+		{"missing", "?timeout=20ms", http.StatusNotFound},
+		{"queued", "?timeout=soon", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/tasks/"+tt.id+"/wait"+tt.query, nil)
+		req.SetPathValue("id", tt.id)
+		w := httptest.NewRecorder()
+		handler.waitTask(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s%s: expected status %d, got %d", tt.id, tt.query, tt.want, w.Code)
+		}
+	}
+}
+
+// TestGetAllTasksQuery tests filtering, ordering and following the next cursor
+func TestGetAllTasksQuery(t *testing.T) {
+	handler, store, _ := createTestHandler()
+	ctx := context.Background()
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, status := range []models.Status{models.Pending, models.Completed, models.Pending, models.Pending} {
+		store.AddTask(ctx, &models.Task{
+			ID:        fmt.Sprintf("task-%d", i),
+			Title:     "Task",
+			Status:    status,
+			Tags:      []string{"batch"},
+			CreatedAt: base.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	list := func(url string) ([]*models.Task, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		handler.getAllTasks(w, req)
+		var tasks []*models.Task
+		json.Unmarshal(w.Body.Bytes(), &tasks)
+		return tasks, w
+	}
+
+	tasks, w := list("/tasks?status=pending&tag=batch&order=desc&limit=2")
+	if w.Code != http.StatusOK || len(tasks) != 2 || tasks[0].ID != "task-3" || tasks[1].ID != "task-2" {
+		t.Fatalf("Unexpected first page %d: %s", w.Code, w.Body.String())
+	}
+	cursor := w.Header().Get("X-Next-Cursor")
+	if cursor == "" || !strings.Contains(w.Header().Get("Link"), "cursor="+cursor) {
+		t.Fatalf("Expected next cursor and Link header, got %q / %q", cursor, w.Header().Get("Link"))
+	}
+
+	tasks, w = list("/tasks?status=pending&tag=batch&order=desc&limit=2&cursor=" + cursor)
+	if len(tasks) != 1 || tasks[0].ID != "task-0" || w.Header().Get("X-Next-Cursor") != "" {
+		t.Errorf("Unexpected last page: %s (next %q)", w.Body.String(), w.Header().Get("X-Next-Cursor"))
+	}
+
+	for _, bad := range []string{"?limit=0", "?sort=color", "?order=up", "?created_after=yesterday", "?cursor=garbage"} {
+		if _, w := list("/tasks" + bad); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", bad, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+// TestCreateTaskTags tests that tags are trimmed, de-duplicated and validated
+func TestCreateTaskTags(t *testing.T) {
+	handler, store, _ := createTestHandler()
+
+	body := `{"title": "Tagged", "tags": [" billing ", "urgent", "billing"]}`
+	w := httptest.NewRecorder()
+	handler.createTask(w, httptest.NewRequest("POST", "/tasks", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var resp map[string]string
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	task, _ := store.GetTask(context.Background(), resp["id"])
+	if strings.Join(task.Tags, ",") != "billing,urgent" {
+		t.Errorf("Expected tags [billing urgent], got %v", task.Tags)
+	}
+
+	w = httptest.NewRecorder()
+	handler.createTask(w, httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Bad", "tags": [" "]}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an empty tag, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// TestCreateTaskIdempotencyKey tests replay and key reuse through the Idempotency-Key header
+func TestCreateTaskIdempotencyKey(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(5, st, taskpool.WithIdempotency(st, time.Hour))
+	handler := NewHandler(pool, st, logger.NewTestLogger())
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/tasks", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "order-42")
+		w := httptest.NewRecorder()
+		handler.createTask(w, req)
+		return w
+	}
+
+	first := post(`{"title": "Charge"}`)
+	second := post(`{"title": "Charge"}`)
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("Expected both submissions to succeed, got %d and %d", first.Code, second.Code)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("Expected the original response to be replayed, got %s and %s", first.Body.String(), second.Body.String())
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("Expected only the replay to carry Idempotent-Replayed")
+	}
+	if pool.Len() != 1 {
+		t.Errorf("Expected a single queued task, got %d", pool.Len())
+	}
+
+	if w := post(`{"title": "Refund"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status %d for a reused key, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestTaskDependenciesAndGraph tests submitting a dependent task and reading its graph
+func TestTaskDependenciesAndGraph(t *testing.T) {
+	handler, st, pool := createTestHandler()
+	ctx := context.Background()
+	pool.AddTask(ctx, logger.NewTestLogger(), &models.Task{ID: "parent", Title: "Parent"})
+
+	for body, want := range map[string]int{
+		`{"title": "Child", "depends_on": ["missing"]}`:                              http.StatusBadRequest,
+		`{"title": "Child", "depends_on": ["parent"], "on_dependency_failure": "x"}`: http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		handler.createTask(w, httptest.NewRequest("POST", "/tasks", strings.NewReader(body)))
+		if w.Code != want {
+			t.Errorf("%s: expected status %d, got %d", body, want, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.createTask(w, httptest.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Child", "depends_on": ["parent"]}`)))
+	var created map[string]string
+	json.Unmarshal(w.Body.Bytes(), &created)
+	child, err := st.GetTask(ctx, created["id"])
+	if err != nil || child.Status != models.Blocked {
+		t.Fatalf("Expected a blocked child, got %+v, %v", child, err)
+	}
+
+	req := httptest.NewRequest("GET", "/tasks/parent/graph", nil)
+	req.SetPathValue("id", "parent")
+	w = httptest.NewRecorder()
+	handler.getTaskGraph(w, req)
+	var graph models.TaskGraph
+	json.Unmarshal(w.Body.Bytes(), &graph)
+	if w.Code != http.StatusOK || len(graph.Nodes) != 2 || len(graph.Edges) != 1 || graph.Edges[0].To != child.ID {
+		t.Errorf("Unexpected graph %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/tasks/missing/graph", nil)
+	req.SetPathValue("id", "missing")
+	w = httptest.NewRecorder()
+	handler.getTaskGraph(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -37,8 +37,13 @@ func RegisterTaskRoutes(mux *http.ServeMux, h *Handler) {
 		handler http.HandlerFunc
 	}{
 		{"POST", "/tasks", h.createTask},
+		{"POST", "/tasks/batch", h.createBatch},
 		{"GET", "/tasks/{id}", h.getTaskWithID},
 		{"GET", "/tasks", h.getAllTasks},
+		{"POST", "/tasks/{id}/cancel", h.cancelTask},
+		{"GET", "/tasks/{id}/wait", h.waitTask},
+		{"GET", "/tasks/{id}/graph", h.getTaskGraph},
+		{"GET", "/events", h.streamEvents},
 	}
 
 	fmt.Println("\nRegistered routes:")
@@ -50,6 +55,93 @@ func RegisterTaskRoutes(mux *http.ServeMux, h *Handler) {
 	fmt.Println()
 }
 
+func RegisterScheduleRoutes(mux *http.ServeMux, h *ScheduleHandler) {
+	routes := []struct {
+		method  string
+		pattern string
+		handler http.HandlerFunc
+	}{
+		{"POST", "/schedules", h.createSchedule},
+		{"GET", "/schedules", h.listSchedules},
+		{"GET", "/schedules/{id}", h.getSchedule},
+		{"PUT", "/schedules/{id}", h.updateSchedule},
+		{"DELETE", "/schedules/{id}", h.deleteSchedule},
+	}
+
+	for _, route := range routes {
+		pattern := fmt.Sprintf("%s %s", route.method, route.pattern)
+		mux.HandleFunc(pattern, recoverPanic(route.handler))
+		fmt.Printf("  %-6s %s\n", route.method, route.pattern)
+	}
+	fmt.Println()
+}
+
+func RegisterDeadLetterRoutes(mux *http.ServeMux, h *DeadLetterHandler) {
+	routes := []struct {
+		method  string
+		pattern string
+		handler http.HandlerFunc
+	}{
+		{"GET", "/dead-letters", h.listDeadLetters},
+		{"GET", "/dead-letters/{id}", h.getDeadLetter},
+		{"POST", "/dead-letters/{id}/requeue", h.requeue},
+		{"POST", "/dead-letters/requeue", h.requeueBulk},
+	}
+
+	for _, route := range routes {
+		pattern := fmt.Sprintf("%s %s", route.method, route.pattern)
+		mux.HandleFunc(pattern, recoverPanic(route.handler))
+		fmt.Printf("  %-6s %s\n", route.method, route.pattern)
+	}
+	fmt.Println()
+}
+
+func RegisterWorkflowRoutes(mux *http.ServeMux, h *WorkflowHandler) {
+	routes := []struct {
+		method  string
+		pattern string
+		handler http.HandlerFunc
+	}{
+		{"POST", "/workflows", h.createWorkflow},
+		{"GET", "/workflows", h.listWorkflows},
+		{"GET", "/workflows/{id}", h.getWorkflow},
+	}
+
+	for _, route := range routes {
+		pattern := fmt.Sprintf("%s %s", route.method, route.pattern)
+		mux.HandleFunc(pattern, recoverPanic(route.handler))
+		fmt.Printf("  %-6s %s\n", route.method, route.pattern)
+	}
+	fmt.Println()
+}
+
+func RegisterAdminRoutes(mux *http.ServeMux, h *AdminHandler) {
+	routes := []struct {
+		method  string
+		pattern string
+		handler http.HandlerFunc
+	}{
+		{"GET", "/admin/stats", h.getStats},
+		{"GET", "/admin/workers", h.getWorkers},
+		{"PUT", "/admin/workers", h.setWorkers},
+		{"PUT", "/admin/workers/queues", h.assignQueues},
+		{"PUT", "/admin/workers/{id}/queues", h.assignWorkerQueues},
+		{"GET", "/admin/queues", h.getQueues},
+		{"GET", "/admin/tenants", h.getTenants},
+		{"GET", "/admin/concurrency", h.getConcurrency},
+		{"PUT", "/admin/concurrency", h.setConcurrency},
+		{"POST", "/admin/pause", h.pause},
+		{"POST", "/admin/resume", h.resume},
+	}
+
+	for _, route := range routes {
+		pattern := fmt.Sprintf("%s %s", route.method, route.pattern)
+		mux.HandleFunc(pattern, recoverPanic(route.handler))
+		fmt.Printf("  %-6s %s\n", route.method, route.pattern)
+	}
+	fmt.Println()
+}
+
 func recoverPanic(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
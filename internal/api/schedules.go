@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+type ScheduleHandler struct {
+	cron   *taskpool.CronRunner
+	logger *logger.Logger
+}
+
+func NewScheduleHandler(cron *taskpool.CronRunner, logger *logger.Logger) *ScheduleHandler {
+	return &ScheduleHandler{
+		cron:   cron,
+		logger: logger,
+	}
+}
+
+type ScheduleRequest struct {
+	Name    string               `json:"name"`
+	Spec    string               `json:"spec"`
+	Overlap models.OverlapPolicy `json:"overlap,omitempty"`
+	Task    models.TaskTemplate  `json:"task"`
+}
+
+// toSchedule validates the task template with the same rules as POST /tasks.
+// The cron spec and overlap policy are checked by the runner.
+func (req *ScheduleRequest) toSchedule() (*models.Schedule, error) {
+	tpl := req.Task
+	if err := validateTemplate(&tpl); err != nil {
+		return nil, err
+	}
+	return &models.Schedule{
+		Name:    req.Name,
+		Spec:    req.Spec,
+		Overlap: req.Overlap,
+		Task:    tpl,
+	}, nil
+}
+
+// validateTemplate checks a task template with the same rules as POST /tasks
+// and normalises its title, type, queue and tags in place.
+func validateTemplate(tpl *models.TaskTemplate) error {
+	tr := TaskRequest{
+		Title:          tpl.Title,
+		Description:    tpl.Description,
+		Type:           tpl.Type,
+		Queue:          tpl.Queue,
+		ConcurrencyKey: tpl.ConcurrencyKey,
+		Payload:        tpl.Payload,
+		Tags:           tpl.Tags,
+		Priority:       tpl.Priority,
+		Retry:          tpl.Retry,
+		Timeout:        tpl.Timeout,
+	}
+	task, err := tr.toTask()
+	if err != nil {
+		return err
+	}
+	if task.Timeout < 0 {
+		return errors.New("timeout cannot be negative")
+	}
+	tpl.Title = task.Title
+	tpl.Type = task.Type
+	tpl.Queue = task.Queue
+	tpl.ConcurrencyKey = task.ConcurrencyKey
+	tpl.Tags = task.Tags
+	return nil
+}
+
+func (h *ScheduleHandler) decode(w http.ResponseWriter, r *http.Request) (*models.Schedule, bool) {
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+
+	var req ScheduleRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.logger.Error("failed to decode request", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON body", Code: codeInvalidRequest})
+		return nil, false
+	}
+	sched, err := req.toSchedule()
+	if err != nil {
+		h.logger.Warn("invalid schedule request", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+		return nil, false
+	}
+	sched.Tenant = tenantFrom(r.Context()) // Update keeps the creator's tenant
+	return sched, true
+}
+
+func (h *ScheduleHandler) createSchedule(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("createSchedule handler called", "method", r.Method, "url", r.URL.String())
+
+	sched, ok := h.decode(w, r)
+	if !ok {
+		return
+	}
+	created, err := h.cron.Create(r.Context(), sched)
+	if err != nil {
+		h.writeScheduleError(w, "", err)
+		return
+	}
+	h.logger.Info("schedule created", "schedule_id", created.ID, "spec", created.Spec)
+	if err := writeJSON(w, http.StatusCreated, created); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *ScheduleHandler) getSchedule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sched, err := h.cron.Get(r.Context(), id)
+	if err != nil {
+		h.writeScheduleError(w, id, err)
+		return
+	}
+	if err := writeJSON(w, http.StatusOK, sched); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "schedule_id", id)
+	}
+}
+
+func (h *ScheduleHandler) listSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.cron.List(r.Context())
+	if err != nil {
+		h.writeScheduleError(w, "", err)
+		return
+	}
+	if err := writeJSON(w, http.StatusOK, schedules); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *ScheduleHandler) updateSchedule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	h.logger.Info("updateSchedule handler called", "schedule_id", id, "method", r.Method, "url", r.URL.String())
+
+	def, ok := h.decode(w, r)
+	if !ok {
+		return
+	}
+	sched, err := h.cron.Update(r.Context(), id, def)
+	if err != nil {
+		h.writeScheduleError(w, id, err)
+		return
+	}
+	h.logger.Info("schedule updated", "schedule_id", id, "spec", sched.Spec)
+	if err := writeJSON(w, http.StatusOK, sched); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "schedule_id", id)
+	}
+}
+
+func (h *ScheduleHandler) deleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	h.logger.Info("deleteSchedule handler called", "schedule_id", id, "method", r.Method, "url", r.URL.String())
+
+	if err := h.cron.Delete(r.Context(), id); err != nil {
+		h.writeScheduleError(w, id, err)
+		return
+	}
+	h.logger.Info("schedule deleted", "schedule_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ScheduleHandler) writeScheduleError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, taskpool.ErrInvalidSchedule):
+		h.logger.Warn("invalid schedule", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+	case errors.Is(err, store.ErrScheduleNotFound):
+		h.logger.Warn("schedule not found", "schedule_id", id)
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "schedule not found", Code: codeScheduleNotFound})
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusRequestTimeout, ErrorResponse{Error: "request cancelled", Code: codeCancelled})
+	default:
+		h.logger.Error("schedule request failed", "error", err, "schedule_id", id)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+func createTestScheduleMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(5, st)
+	runner := taskpool.NewCronRunner(pool, st, logger.NewTestLogger())
+	t.Cleanup(runner.Stop)
+	mux := http.NewServeMux()
+	RegisterScheduleRoutes(mux, NewScheduleHandler(runner, logger.NewTestLogger()))
+	return mux
+}
+
+func doJSON(mux http.Handler, method, path string, body any) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	return w
+}
+
+// TestScheduleLifecycle tests create, get, list, update and delete
+func TestScheduleLifecycle(t *testing.T) {
+	mux := createTestScheduleMux(t)
+
+	w := doJSON(mux, "POST", "/schedules", ScheduleRequest{
+		Name: "hourly report",
+		Spec: "0 * * * *",
+		Task: models.TaskTemplate{Title: "Report"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Schedule
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.ID == "" || created.NextFireAt == nil || created.Task.Type != taskpool.DefaultTaskType {
+		t.Fatalf("Unexpected schedule: %+v", created)
+	}
+
+	if w := doJSON(mux, "GET", "/schedules/"+created.ID, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d on get, got %d", http.StatusOK, w.Code)
+	}
+
+	w = doJSON(mux, "GET", "/schedules", nil)
+	var list []models.Schedule
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list) != 1 {
+		t.Errorf("Expected 1 schedule, got %d", len(list))
+	}
+
+	w = doJSON(mux, "PUT", "/schedules/"+created.ID, ScheduleRequest{
+		Name: "daily report",
+		Spec: "@daily",
+		Task: models.TaskTemplate{Title: "Report"},
+	})
+	var updated models.Schedule
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.Spec != "@daily" || updated.ID != created.ID {
+		t.Errorf("Unexpected update response %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(mux, "DELETE", "/schedules/"+created.ID, nil); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d on delete, got %d", http.StatusNoContent, w.Code)
+	}
+	if w := doJSON(mux, "GET", "/schedules/"+created.ID, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d after delete, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestCreateScheduleValidation tests that bad specs and templates are rejected
+func TestCreateScheduleValidation(t *testing.T) {
+	mux := createTestScheduleMux(t)
+
+	for name, req := range map[string]ScheduleRequest{
+		"bad spec":       {Name: "x", Spec: "every tuesday", Task: models.TaskTemplate{Title: "T"}},
+		"missing title":  {Name: "x", Spec: "@hourly"},
+		"bad priority":   {Name: "x", Spec: "@hourly", Task: models.TaskTemplate{Title: "T", Priority: 42}},
+		"unknown policy": {Name: "x", Spec: "@hourly", Overlap: "maybe", Task: models.TaskTemplate{Title: "T"}},
+	} {
+		if w := doJSON(mux, "POST", "/schedules", req); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", name, http.StatusBadRequest, w.Code)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+const (
+	apiKeyHeader      = "X-API-Key"
+	tenantHeader      = "X-Tenant"
+	maxTenantLength   = 64
+	tenantNameSymbols = "-_."
+)
+
+var errInvalidAPIKeys = errors.New("invalid API keys")
+
+type tenantKey struct{}
+
+// ResolveTenants works out which tenant each request acts for and makes it
+// available to the handlers. With API keys configured (key to tenant) every
+// request must carry a known key in X-API-Key and acts for that key's
+// tenant. Without keys the X-Tenant header names the tenant, and requests
+// without one act for the default tenant.
+func ResolveTenants(next http.Handler, keys map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := taskpool.DefaultTenant
+		if len(keys) > 0 {
+			var ok bool
+			if tenant, ok = keys[r.Header.Get(apiKeyHeader)]; !ok {
+				writeError(w, http.StatusUnauthorized, ErrorResponse{Error: "missing or unknown API key", Code: codeUnauthorized})
+				return
+			}
+		} else if name := strings.TrimSpace(r.Header.Get(tenantHeader)); name != "" {
+			if !validTenant(name) {
+				writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid X-Tenant header", Code: codeInvalidRequest})
+				return
+			}
+			tenant = name
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant)))
+	})
+}
+
+// tenantFrom returns the tenant resolved for the request by ResolveTenants.
+func tenantFrom(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+		return tenant
+	}
+	return taskpool.DefaultTenant
+}
+
+// validTenant reports whether name is a usable tenant name: letters, digits
+// and "-_." only, so it is safe in logs and idempotency key scopes.
+func validTenant(name string) bool {
+	if name == "" || len(name) > maxTenantLength {
+		return false
+	}
+	for _, c := range name {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.ContainsRune(tenantNameSymbols, c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseAPIKeys parses "key1=teamA,key2=teamB" into the key to tenant map
+// for ResolveTenants.
+func ParseAPIKeys(s string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, tenant, _ := strings.Cut(part, "=")
+		key, tenant = strings.TrimSpace(key), strings.TrimSpace(tenant)
+		if key == "" || !validTenant(tenant) {
+			return nil, fmt.Errorf("%w: each entry must be key=tenant with a tenant of letters, digits and %q", errInvalidAPIKeys, tenantNameSymbols)
+		}
+		if _, dup := keys[key]; dup {
+			return nil, fmt.Errorf("%w: a key is listed twice", errInvalidAPIKeys)
+		}
+		keys[key] = tenant
+	}
+	return keys, nil
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+func createTestTenantServer(t *testing.T, keys map[string]string) (http.Handler, *store.MemoryStore) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(10, st, taskpool.WithTenants(map[string]taskpool.TenantLimits{"team-a": {Quota: 1}}))
+	mux := http.NewServeMux()
+	RegisterTaskRoutes(mux, NewHandler(pool, st, logger.NewTestLogger()))
+	RegisterAdminRoutes(mux, NewAdminHandler(pool, taskpool.NewWorkerManager(0, st), logger.NewTestLogger()))
+	return ResolveTenants(mux, keys), st
+}
+
+func postTask(h http.Handler, header, value string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(TaskRequest{Title: "Tenant task"})
+	req := httptest.NewRequest("POST", "/tasks", bytes.NewReader(body))
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// TestTenantFromHeader tests that tasks take their tenant from X-Tenant and quotas apply per tenant
+func TestTenantFromHeader(t *testing.T) {
+	h, st := createTestTenantServer(t, nil)
+
+	w := postTask(h, tenantHeader, "team-a")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created map[string]string
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if task, _ := st.GetTask(t.Context(), created["id"]); task == nil || task.Tenant != "team-a" {
+		t.Errorf("Expected the task to belong to team-a, got %+v", task)
+	}
+
+	if w := postTask(h, tenantHeader, "team-a"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected team-a's quota to be exceeded, got %d", w.Code)
+	}
+	if w := postTask(h, "", ""); w.Code != http.StatusCreated {
+		t.Errorf("Expected the default tenant to be unaffected, got %d", w.Code)
+	}
+	if w := postTask(h, tenantHeader, "team a:b"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected an invalid tenant name to be rejected, got %d", w.Code)
+	}
+
+	w = doJSON(h, "GET", "/admin/tenants", nil)
+	var stats []taskpool.TenantStats
+	json.Unmarshal(w.Body.Bytes(), &stats)
+	if len(stats) != 2 || stats[0].Name != taskpool.DefaultTenant || stats[1].Name != "team-a" || stats[1].Queued != 1 || stats[1].Quota != 1 {
+		t.Errorf("Unexpected tenant stats: %s", w.Body.String())
+	}
+}
+
+// TestTenantFromAPIKey tests that configured API keys are required and decide the tenant
+func TestTenantFromAPIKey(t *testing.T) {
+	keys, err := ParseAPIKeys("secret-a=team-a, secret-b=team-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, st := createTestTenantServer(t, keys)
+
+	if w := postTask(h, "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a key, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := postTask(h, apiKeyHeader, "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for an unknown key, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// X-Tenant cannot override the key's tenant
+	body, _ := json.Marshal(TaskRequest{Title: "Tenant task"})
+	req := httptest.NewRequest("POST", "/tasks", bytes.NewReader(body))
+	req.Header.Set(apiKeyHeader, "secret-b")
+	req.Header.Set(tenantHeader, "team-a")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var created map[string]string
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if task, _ := st.GetTask(t.Context(), created["id"]); task == nil || task.Tenant != "team-b" {
+		t.Errorf("Expected the task to belong to team-b, got %+v", task)
+	}
+
+	for _, bad := range []string{"key", "key=", "=team", "k=a,k=b", "k=bad tenant"} {
+		if _, err := ParseAPIKeys(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
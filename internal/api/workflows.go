@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+const maxWorkflowNameLength = 200
+
+type WorkflowHandler struct {
+	pool   *taskpool.TaskPool
+	logger *logger.Logger
+}
+
+func NewWorkflowHandler(pool *taskpool.TaskPool, logger *logger.Logger) *WorkflowHandler {
+	return &WorkflowHandler{
+		pool:   pool,
+		logger: logger,
+	}
+}
+
+// WorkflowRequest is a workflow definition: a top-level task, chain, group
+// or chord, optionally named.
+type WorkflowRequest struct {
+	Name string `json:"name,omitempty"`
+	models.WorkflowStep
+}
+
+// validateSteps checks every task template in the definition with the same
+// rules as POST /tasks. The shape of the definition is checked by the pool.
+func validateSteps(path string, s *models.WorkflowStep) error {
+	if s.Task != nil {
+		if err := validateTemplate(s.Task); err != nil {
+			return fmt.Errorf("%s.task: %w", path, err)
+		}
+	}
+	for i := range s.Chain {
+		if err := validateSteps(fmt.Sprintf("%s.chain[%d]", path, i), &s.Chain[i]); err != nil {
+			return err
+		}
+	}
+	for i := range s.Group {
+		if err := validateSteps(fmt.Sprintf("%s.group[%d]", path, i), &s.Group[i]); err != nil {
+			return err
+		}
+	}
+	if s.Chord != nil {
+		for i := range s.Chord.Group {
+			if err := validateSteps(fmt.Sprintf("%s.chord.group[%d]", path, i), &s.Chord.Group[i]); err != nil {
+				return err
+			}
+		}
+		if err := validateSteps(path+".chord.callback", &s.Chord.Callback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *WorkflowHandler) createWorkflow(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("createWorkflow handler called", "method", r.Method, "url", r.URL.String())
+
+	defer r.Body.Close()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodySize)
+
+	var req WorkflowRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.logger.Error("failed to decode request", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid JSON body", Code: codeInvalidRequest})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if len(name) > maxWorkflowNameLength {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "name too long", Code: codeInvalidRequest})
+		return
+	}
+	if err := validateSteps("workflow", &req.WorkflowStep); err != nil {
+		h.logger.Warn("invalid workflow request", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+		return
+	}
+
+	wf := &models.Workflow{Name: name, Tenant: tenantFrom(r.Context()), Definition: req.WorkflowStep}
+	if err := h.pool.SubmitWorkflow(r.Context(), h.logger, wf); err != nil {
+		h.writeWorkflowError(w, "", err)
+		return
+	}
+	state, err := h.pool.Workflow(r.Context(), wf.ID)
+	if err != nil {
+		h.writeWorkflowError(w, wf.ID, err)
+		return
+	}
+	if err := writeJSON(w, http.StatusCreated, state); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "workflow_id", wf.ID)
+	}
+}
+
+func (h *WorkflowHandler) getWorkflow(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	state, err := h.pool.Workflow(r.Context(), id)
+	if err != nil {
+		h.writeWorkflowError(w, id, err)
+		return
+	}
+	if err := writeJSON(w, http.StatusOK, state); err != nil {
+		h.logger.Error("failed to encode response", "error", err, "workflow_id", id)
+	}
+}
+
+func (h *WorkflowHandler) listWorkflows(w http.ResponseWriter, r *http.Request) {
+	states, err := h.pool.Workflows(r.Context())
+	if err != nil {
+		h.writeWorkflowError(w, "", err)
+		return
+	}
+	if err := writeJSON(w, http.StatusOK, states); err != nil {
+		h.logger.Error("failed to encode response", "error", err)
+	}
+}
+
+func (h *WorkflowHandler) writeWorkflowError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, taskpool.ErrInvalidTask):
+		h.logger.Warn("invalid workflow", "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: codeInvalidRequest})
+	case errors.Is(err, store.ErrWorkflowNotFound):
+		h.logger.Warn("workflow not found", "workflow_id", id)
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "workflow not found", Code: codeWorkflowNotFound})
+	case errors.Is(err, taskpool.ErrTenantQuotaExceeded):
+		writeError(w, http.StatusTooManyRequests, ErrorResponse{Error: "workflow's first tasks exceed the tenant's queue quota", Code: codeTenantQuota})
+	case errors.Is(err, taskpool.ErrTaskQueueFull):
+		writeError(w, http.StatusTooManyRequests, ErrorResponse{Error: "task queue cannot hold the workflow's first tasks", Code: codeQueueFull})
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusRequestTimeout, ErrorResponse{Error: "request cancelled", Code: codeCancelled})
+	default:
+		h.logger.Error("workflow request failed", "error", err, "workflow_id", id)
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal server error", Code: codeInternal})
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/taskpool"
+)
+
+func createTestWorkflowMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	st := store.NewMemoryStore()
+	pool := taskpool.NewTaskPool(10, st, taskpool.WithWorkflows(st))
+	mux := http.NewServeMux()
+	RegisterWorkflowRoutes(mux, NewWorkflowHandler(pool, logger.NewTestLogger()))
+	return mux
+}
+
+// TestWorkflowLifecycle tests submitting a chord, reading it back and listing workflows
+func TestWorkflowLifecycle(t *testing.T) {
+	mux := createTestWorkflowMux(t)
+
+	w := doJSON(mux, "POST", "/workflows", json.RawMessage(`{
+		"name": "report",
+		"chain": [
+			{"task": {"title": "  Fetch  "}},
+			{"chord": {
+				"group": [{"task": {"title": "Left"}}, {"task": {"title": "Right"}}],
+				"callback": {"task": {"title": "Merge"}}
+			}}
+		]
+	}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.WorkflowState
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.ID == "" || created.Name != "report" || created.Status != models.Pending || len(created.Tasks) != 4 {
+		t.Fatalf("Unexpected workflow: %s", w.Body.String())
+	}
+	if created.Tasks[0].Title != "Fetch" || created.Tasks[3].Status != models.Blocked || len(created.Tasks[3].DependsOn) != 2 {
+		t.Errorf("Unexpected tasks: %+v", created.Tasks)
+	}
+
+	w = doJSON(mux, "GET", "/workflows/"+created.ID, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), created.ID) {
+		t.Errorf("Expected the workflow back, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doJSON(mux, "GET", "/workflows", nil)
+	var list []models.WorkflowState
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || len(list) != 1 {
+		t.Errorf("Expected 1 workflow, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(mux, "GET", "/workflows/missing", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// TestCreateWorkflowValidation tests malformed definitions and invalid task templates
+func TestCreateWorkflowValidation(t *testing.T) {
+	mux := createTestWorkflowMux(t)
+
+	for _, body := range []string{
+		`{"name": "empty"}`,
+		`{"chain": []}`,
+		`{"group": [{"task": {"title": "A"}}], "chain": [{"task": {"title": "B"}}]}`,
+		`{"chain": [{"task": {"title": "A"}}, {"task": {"title": ""}}]}`,
+		`{"chord": {"group": [{"task": {"title": "A", "priority": 42}}], "callback": {"task": {"title": "B"}}}}`,
+		`{"steps": []}`,
+	} {
+		if w := doJSON(mux, "POST", "/workflows", json.RawMessage(body)); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d: %s", body, http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	}
+}
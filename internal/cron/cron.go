@@ -0,0 +1,222 @@
+// Package cron parses standard five-field cron expressions and the @every
+// shorthand, and computes their next activation time.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSpec = errors.New("invalid cron spec")
+
+// Schedule computes activation times for a parsed spec.
+type Schedule interface {
+	// Next returns the first activation strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// Every fires at a fixed interval.
+type Every struct {
+	Interval time.Duration
+}
+
+func (e Every) Next(t time.Time) time.Time {
+	return t.Add(e.Interval)
+}
+
+// Fields is a five-field cron schedule. Each field is a bit set of the
+// values it matches.
+type Fields struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+}
+
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minuteBounds = bounds{0, 59, nil}
+	hourBounds   = bounds{0, 23, nil}
+	domBounds    = bounds{1, 31, nil}
+	monthBounds  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowBounds = bounds{0, 7, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse accepts "minute hour day-of-month month day-of-week" expressions with
+// *, lists, ranges, steps and month/weekday names, the @hourly/@daily/...
+// descriptors, and "@every <duration>".
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+		}
+		if d < time.Second {
+			return nil, fmt.Errorf("%w: @every interval must be at least 1s", ErrInvalidSpec)
+		}
+		return Every{Interval: d}, nil
+	}
+	if expanded, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: expected 5 fields, got %d", ErrInvalidSpec, len(parts))
+	}
+
+	var (
+		f   Fields
+		err error
+	)
+	if f.minute, err = parseField(parts[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if f.hour, err = parseField(parts[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if f.dom, err = parseField(parts[2], domBounds); err != nil {
+		return nil, err
+	}
+	if f.month, err = parseField(parts[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if f.dow, err = parseField(parts[4], dowBounds); err != nil {
+		return nil, err
+	}
+	if f.dow&(1<<7) != 0 { // 7 is an alias for Sunday
+		f.dow |= 1
+	}
+	f.domStar = strings.HasPrefix(parts[2], "*")
+	f.dowStar = strings.HasPrefix(parts[4], "*")
+	return &f, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		bitsForPart, err := parseRange(part, b)
+		if err != nil {
+			return 0, err
+		}
+		set |= bitsForPart
+	}
+	return set, nil
+}
+
+func parseRange(expr string, b bounds) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(expr, "/")
+
+	var lo, hi uint
+	switch {
+	case rangePart == "*":
+		lo, hi = b.min, b.max
+	default:
+		loStr, hiStr, isRange := strings.Cut(rangePart, "-")
+		var err error
+		if lo, err = parseValue(loStr, b); err != nil {
+			return 0, err
+		}
+		hi = lo
+		if isRange {
+			if hi, err = parseValue(hiStr, b); err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			hi = b.max // "5/15" means "5-max/15"
+		}
+	}
+	if lo > hi {
+		return 0, fmt.Errorf("%w: range %q is backwards", ErrInvalidSpec, expr)
+	}
+
+	step := uint(1)
+	if hasStep {
+		n, err := strconv.ParseUint(stepPart, 10, 8)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("%w: bad step in %q", ErrInvalidSpec, expr)
+		}
+		step = uint(n)
+	}
+
+	var set uint64
+	for v := lo; v <= hi; v += step {
+		set |= 1 << v
+	}
+	return set, nil
+}
+
+func parseValue(s string, b bounds) (uint, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad value %q", ErrInvalidSpec, s)
+	}
+	if uint(n) < b.min || uint(n) > b.max {
+		return 0, fmt.Errorf("%w: value %d out of range %d-%d", ErrInvalidSpec, n, b.min, b.max)
+	}
+	return uint(n), nil
+}
+
+// Next walks forward field by field, jumping to the next matching month,
+// day, hour and minute in turn. Times are evaluated in t's location.
+func (f *Fields) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// A matching time always exists within a few years (Feb 29 at worst).
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if f.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !f.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if f.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if f.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's rule that when both day-of-month and day-of-week
+// are restricted, a day matching either one qualifies.
+func (f *Fields) dayMatches(t time.Time) bool {
+	domMatch := f.dom&(1<<uint(t.Day())) != 0
+	dowMatch := f.dow&(1<<uint(t.Weekday())) != 0
+	if f.domStar || f.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
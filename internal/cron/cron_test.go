@@ -0,0 +1,70 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("bad time %q: %v", s, err)
+	}
+	return parsed
+}
+
+// TestNext tests activation times for a range of expressions
+func TestNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"* * * * *", "2026-03-10T10:15:30Z", "2026-03-10T10:16:00Z"},
+		{"*/15 * * * *", "2026-03-10T10:15:00Z", "2026-03-10T10:30:00Z"},
+		{"0 2 * * *", "2026-03-10T10:15:00Z", "2026-03-11T02:00:00Z"},
+		{"30 9 * * mon-fri", "2026-03-13T10:00:00Z", "2026-03-16T09:30:00Z"}, // Friday -> Monday
+		{"0 0 1 */3 *", "2026-02-10T00:00:00Z", "2026-04-01T00:00:00Z"},
+		{"0 12 29 feb *", "2026-03-01T00:00:00Z", "2028-02-29T12:00:00Z"},
+		{"0 0 13 * fri", "2026-03-10T00:00:00Z", "2026-03-13T00:00:00Z"}, // day-of-month OR weekday
+		{"5,10-12 * * * *", "2026-03-10T10:10:00Z", "2026-03-10T10:11:00Z"},
+		{"0 0 * * 7", "2026-03-10T00:00:00Z", "2026-03-15T00:00:00Z"}, // 7 is Sunday
+		{"@hourly", "2026-03-10T10:15:00Z", "2026-03-10T11:00:00Z"},
+		{"@every 5m", "2026-03-10T10:15:30Z", "2026-03-10T10:20:30Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sched, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			got := sched.Next(mustTime(t, tt.from))
+			if want := mustTime(t, tt.want); !got.Equal(want) {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+// TestParseInvalid tests rejection of malformed expressions
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"10-5 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@every soon",
+		"@every 10ms",
+	} {
+		if _, err := Parse(spec); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("Parse(%q): expected ErrInvalidSpec, got %v", spec, err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package events is the in-process event bus for task lifecycle changes.
+// Every published event gets a monotonically increasing ID and is kept in a
+// bounded ring buffer so subscribers can resume after a disconnect.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+type Type string
+
+const (
+	TaskStatus  Type = "task.status"  // a task moved to a new status
+	WorkerIdle  Type = "worker.idle"  // a worker finished with its task
+	WorkerScale Type = "worker.scale" // the autoscaler resized the worker pool
+)
+
+// DefaultHistory is the number of events kept for Last-Event-ID resumption.
+const DefaultHistory = 1000
+
+// subscriberBuffer is how far a subscriber may fall behind before it is cut
+// off. A cut-off subscriber catches up from the ring buffer by resubscribing.
+const subscriberBuffer = 256
+
+type Event struct {
+	ID       uint64        `json:"id"`
+	Type     Type          `json:"type"`
+	Time     time.Time     `json:"time"`
+	TaskID   string        `json:"task_id,omitempty"`
+	TaskType string        `json:"task_type,omitempty"`
+	Status   models.Status `json:"status,omitempty"`
+	Attempt  int           `json:"attempt,omitempty"`
+	WorkerID int           `json:"worker_id,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Scale    *Scaling      `json:"scale,omitempty"`
+}
+
+// Scaling describes an autoscaler decision and the measurements behind it.
+type Scaling struct {
+	From        int             `json:"from"`
+	To          int             `json:"to"`
+	Reason      string          `json:"reason"`
+	Queued      int             `json:"queued"`
+	OldestWait  models.Duration `json:"oldest_wait"`
+	Busy        int             `json:"busy"`
+	Utilization float64         `json:"utilization"` // busy / workers before the change
+}
+
+// Subscription receives every event published after it was created. C is
+// closed when the subscription is closed or falls too far behind.
+type Subscription struct {
+	C <-chan Event
+
+	bus *Bus
+	ch  chan Event
+}
+
+// Close detaches the subscription from the bus. It is safe to call twice.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.removeLocked(s)
+}
+
+type Bus struct {
+	mu     sync.Mutex
+	lastID uint64
+	ring   []Event // fixed size; holds count events from head on, wrapping
+	head   int     // index of the oldest event in ring
+	count  int
+	subs   map[*Subscription]struct{}
+	now    func() time.Time
+}
+
+func NewBus(history int) *Bus {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Bus{
+		ring: make([]Event, history),
+		subs: make(map[*Subscription]struct{}),
+		now:  time.Now,
+	}
+}
+
+// Publish assigns e the next ID and delivers it to every subscriber without
+// blocking. It is safe to call while holding other locks.
+func (b *Bus) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e.ID = b.lastID
+	if e.Time.IsZero() {
+		e.Time = b.now().UTC()
+	}
+	if b.count < len(b.ring) {
+		b.ring[(b.head+b.count)%len(b.ring)] = e
+		b.count++
+	} else {
+		// Full: overwrite the oldest.
+		b.ring[b.head] = e
+		b.head = (b.head + 1) % len(b.ring)
+	}
+
+	for s := range b.subs {
+		select {
+		case s.ch <- e:
+		default:
+			b.removeLocked(s)
+		}
+	}
+	return e
+}
+
+// Subscribe starts a subscription. When after is non-zero the events
+// published after that ID are returned as a backlog, atomically with the
+// subscription so nothing is missed or repeated in between. complete is
+// false when some of those events have already left the ring buffer.
+func (b *Bus) Subscribe(after uint64) (sub *Subscription, backlog []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if after > 0 && after < b.lastID {
+		// IDs are consecutive, so the backlog starts skip events past head.
+		oldest := b.lastID - uint64(b.count) + 1
+		skip := 0
+		if after+1 < oldest {
+			complete = false
+		} else {
+			skip = int(after + 1 - oldest)
+		}
+		backlog = make([]Event, 0, b.count-skip)
+		for i := skip; i < b.count; i++ {
+			backlog = append(backlog, b.ring[(b.head+i)%len(b.ring)])
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: ch, bus: b, ch: ch}
+	b.subs[sub] = struct{}{}
+	return sub, backlog, complete
+}
+
+// LastID returns the ID of the most recently published event.
+func (b *Bus) LastID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastID
+}
+
+func (b *Bus) removeLocked(s *Subscription) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.ch)
+}
+
+// ForTask builds a status event from the task's current state.
+func ForTask(task *models.Task) Event {
+	e := Event{
+		Type:     TaskStatus,
+		TaskID:   task.ID,
+		TaskType: task.Type,
+		Status:   task.Status,
+		Attempt:  task.Attempts,
+	}
+	if task.Status.Terminal() {
+		e.Error = task.Error
+	}
+	return e
+}
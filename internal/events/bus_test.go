@@ -0,0 +1,66 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+func TestPublishAssignsIncreasingIDs(t *testing.T) {
+	b := NewBus(10)
+	sub, _, _ := b.Subscribe(0)
+	defer sub.Close()
+
+	for i := 1; i <= 3; i++ {
+		b.Publish(Event{Type: TaskStatus, TaskID: "t", Status: models.Pending})
+	}
+	for want := uint64(1); want <= 3; want++ {
+		if e := <-sub.C; e.ID != want || e.Time.IsZero() {
+			t.Fatalf("Expected event %d with a timestamp, got %+v", want, e)
+		}
+	}
+}
+
+func TestSubscribeResumesFromRing(t *testing.T) {
+	b := NewBus(3)
+	for i := 0; i < 5; i++ {
+		b.Publish(Event{Type: TaskStatus})
+	}
+
+	// Events 3..5 are still buffered
+	sub, backlog, complete := b.Subscribe(3)
+	sub.Close()
+	if !complete || len(backlog) != 2 || backlog[0].ID != 4 || backlog[1].ID != 5 {
+		t.Errorf("Expected complete backlog [4 5], got %v (complete=%v)", backlog, complete)
+	}
+
+	// Event 2 has been overwritten
+	sub, backlog, complete = b.Subscribe(1)
+	sub.Close()
+	if complete || len(backlog) != 3 || backlog[0].ID != 3 {
+		t.Errorf("Expected truncated backlog starting at 3, got %v (complete=%v)", backlog, complete)
+	}
+
+	sub, backlog, complete = b.Subscribe(5)
+	sub.Close()
+	if !complete || len(backlog) != 0 {
+		t.Errorf("Expected empty backlog when up to date, got %v", backlog)
+	}
+}
+
+func TestSlowSubscriberIsCutOff(t *testing.T) {
+	b := NewBus(10)
+	sub, _, _ := b.Subscribe(0)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.Publish(Event{Type: TaskStatus})
+	}
+
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("Expected %d buffered events before close, got %d", subscriberBuffer, n)
+	}
+	sub.Close() // closing again must not panic
+}
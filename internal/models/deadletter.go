@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DeadLetter is a task that failed permanently, either by exhausting its
+// retries or by failing in a way that is not retried. It is keyed by the
+// task's ID and keeps the task exactly as it was when it gave up.
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Task     *Task     `json:"task"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Clone returns a copy of the dead letter that shares no mutable state with d.
+func (d *DeadLetter) Clone() *DeadLetter {
+	if d == nil {
+		return nil
+	}
+	cp := *d
+	cp.Task = d.Task.Clone()
+	return &cp
+}
@@ -0,0 +1,23 @@
+package models
+
+// TaskGraph is the dependency graph a task belongs to: every task reachable
+// from it by following depends_on in either direction.
+type TaskGraph struct {
+	Root  string      `json:"root"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a task in a TaskGraph.
+type GraphNode struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Status    Status   `json:"status"`
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// GraphEdge points from a task to one of its dependents.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
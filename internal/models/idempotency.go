@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// IdempotencyRecord maps a client-supplied idempotency key to the task its
+// first submission created. RequestHash lets a reused key with a different
+// request be told apart from a genuine retry.
+type IdempotencyRecord struct {
+	Key         string    `json:"key"`
+	TaskID      string    `json:"task_id"`
+	RequestHash string    `json:"request_hash"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the record no longer deduplicates at now.
+func (r *IdempotencyRecord) Expired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
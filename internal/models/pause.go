@@ -0,0 +1,32 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// PauseState records which tasks workers are not allowed to pick up: all of
+// them, or those of particular types or queues. Paused tasks are still
+// accepted and queued; they wait until resumed.
+type PauseState struct {
+	Global    bool      `json:"global"`
+	Types     []string  `json:"types"`  // sorted task types
+	Queues    []string  `json:"queues"` // sorted queue names
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+func (s *PauseState) Clone() *PauseState {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Types = slices.Clone(s.Types)
+	if c.Types == nil {
+		c.Types = []string{}
+	}
+	c.Queues = slices.Clone(s.Queues)
+	if c.Queues == nil {
+		c.Queues = []string{}
+	}
+	return &c
+}
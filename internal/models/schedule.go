@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OverlapPolicy decides what a schedule does when it fires while the task
+// from its previous firing is still active.
+type OverlapPolicy string
+
+const (
+	OverlapSkip  OverlapPolicy = "skip"  // drop this firing
+	OverlapQueue OverlapPolicy = "queue" // run it once the previous task finishes
+	OverlapAllow OverlapPolicy = "allow" // run concurrently
+)
+
+// TaskTemplate is the task a schedule submits every time it fires, or a
+// single step of a workflow.
+type TaskTemplate struct {
+	Title          string          `json:"title"`
+	Description    string          `json:"description"`
+	Type           string          `json:"type,omitempty"`
+	Queue          string          `json:"queue,omitempty"`
+	ConcurrencyKey string          `json:"concurrency_key,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Tags           []string        `json:"tags,omitempty"`
+	Priority       int             `json:"priority,omitempty"`
+	Retry          *RetryPolicy    `json:"retry,omitempty"`
+	Timeout        Duration        `json:"timeout,omitempty"`
+}
+
+func (t TaskTemplate) clone() TaskTemplate {
+	cp := t
+	cp.Payload = cloneRaw(t.Payload)
+	if t.Tags != nil {
+		cp.Tags = append([]string(nil), t.Tags...)
+	}
+	if t.Retry != nil {
+		retry := *t.Retry
+		cp.Retry = &retry
+	}
+	return cp
+}
+
+// Schedule is a recurring task definition.
+type Schedule struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Spec        string        `json:"spec"`             // five-field cron expression or "@every 5m"
+	Tenant      string        `json:"tenant,omitempty"` // owns the tasks the schedule submits
+	Task        TaskTemplate  `json:"task"`
+	Overlap     OverlapPolicy `json:"overlap"`
+	CreatedAt   time.Time     `json:"created_at"`
+	LastFireAt  *time.Time    `json:"last_fire_at,omitempty"`
+	NextFireAt  *time.Time    `json:"next_fire_at,omitempty"`
+	LastTaskID  string        `json:"last_task_id,omitempty"`
+	LastError   string        `json:"last_error,omitempty"`
+	FireCount   int           `json:"fire_count"`
+	SkipCount   int           `json:"skip_count"`
+	QueuedFires int           `json:"queued_fires"` // firings deferred by the queue overlap policy
+}
+
+// Clone returns a copy of the schedule that shares no mutable state with s.
+func (s *Schedule) Clone() *Schedule {
+	if s == nil {
+		return nil
+	}
+	cp := *s
+	cp.Task = s.Task.clone()
+	if s.LastFireAt != nil {
+		last := *s.LastFireAt
+		cp.LastFireAt = &last
+	}
+	if s.NextFireAt != nil {
+		next := *s.NextFireAt
+		cp.NextFireAt = &next
+	}
+	return &cp
+}
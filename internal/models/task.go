@@ -1,18 +1,141 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type Status string
 
 const (
+	Scheduled Status = "scheduled" // waiting for run_at before entering the queue
+	Blocked   Status = "blocked"   // waiting for the tasks it depends on
 	Pending   Status = "pending"
 	Running   Status = "running"
+	Retrying  Status = "retrying" // failed an attempt, waiting for the backoff delay
 	Completed Status = "completed"
 	Failed    Status = "failed"
+	Cancelled Status = "cancelled"
+	TimedOut  Status = "timed_out"
+)
+
+// Terminal reports whether a task in this status will never run again.
+func (s Status) Terminal() bool {
+	switch s {
+	case Completed, Failed, Cancelled, TimedOut:
+		return true
+	default:
+		return false
+	}
+}
+
+// DependencyPolicy decides what happens to a task when one of the tasks it
+// depends on fails, is cancelled or times out.
+type DependencyPolicy string
+
+const (
+	DependencyCancel DependencyPolicy = "cancel" // cancel the task (the default)
+	DependencyRun    DependencyPolicy = "run"    // run it anyway once every parent is finished
+)
+
+type Backoff string
+
+const (
+	BackoffConstant    Backoff = "constant"
+	BackoffLinear      Backoff = "linear"
+	BackoffExponential Backoff = "exponential"
 )
 
+// RetryPolicy controls how a failed task is retried. MaxAttempts counts the
+// first run, so 1 means no retries.
+type RetryPolicy struct {
+	MaxAttempts  int      `json:"max_attempts"`
+	Backoff      Backoff  `json:"backoff,omitempty"`
+	InitialDelay Duration `json:"initial_delay,omitempty"`
+	MaxDelay     Duration `json:"max_delay,omitempty"`
+	Jitter       float64  `json:"jitter,omitempty"` // fraction of the delay to randomise, 0-1
+}
+
+// Attempt records the outcome of a single execution of a task.
+type Attempt struct {
+	Number     int       `json:"number"` // counts on across requeues, unlike Task.Attempts
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+}
+
 type Task struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Duration    int    `json:"duration"` // in seconds //fix
-	Status      Status `json:"status"`
+	ID             string           `json:"id"`
+	Title          string           `json:"title"`
+	Description    string           `json:"description"`
+	Type           string           `json:"type,omitempty"`
+	Queue          string           `json:"queue,omitempty"`
+	Tenant         string           `json:"tenant,omitempty"`
+	ConcurrencyKey string           `json:"concurrency_key,omitempty"` // e.g. a customer ID with its own concurrency limit
+	Payload        json.RawMessage  `json:"payload,omitempty"`
+	Tags           []string         `json:"tags,omitempty"`
+	Priority       int              `json:"priority"` // higher runs first
+	Duration       int              `json:"duration"` // in seconds //fix
+	Status         Status           `json:"status"`
+	CreatedAt      time.Time        `json:"created_at"`
+	RunAt          *time.Time       `json:"run_at,omitempty"`
+	Timeout        Duration         `json:"timeout,omitempty"`  // per attempt
+	Deadline       *time.Time       `json:"deadline,omitempty"` // absolute, across all attempts
+	Retry          *RetryPolicy     `json:"retry,omitempty"`
+	Attempts       int              `json:"attempts"`
+	LastError      string           `json:"last_error,omitempty"`
+	History        []Attempt        `json:"history,omitempty"`
+	NextAttemptAt  *time.Time       `json:"next_attempt_at,omitempty"`
+	DependsOn      []string         `json:"depends_on,omitempty"`
+	OnDepFailure   DependencyPolicy `json:"on_dependency_failure,omitempty"`
+	PassResults    bool             `json:"pass_results,omitempty"` // set Input from the parents' results on release
+	Input          json.RawMessage  `json:"input,omitempty"`
+	WorkflowID     string           `json:"workflow_id,omitempty"` // set on tasks created by a workflow
+	ScheduleID     string           `json:"schedule_id,omitempty"` // set on tasks created by a recurring schedule
+	Result         json.RawMessage  `json:"result,omitempty"`
+	Error          string           `json:"error,omitempty"`
+}
+
+// Clone returns a copy of the task that shares no mutable state with t.
+func (t *Task) Clone() *Task {
+	if t == nil {
+		return nil
+	}
+	cp := *t
+	cp.Payload = cloneRaw(t.Payload)
+	cp.Input = cloneRaw(t.Input)
+	cp.Result = cloneRaw(t.Result)
+	if t.Tags != nil {
+		cp.Tags = append([]string(nil), t.Tags...)
+	}
+	if t.DependsOn != nil {
+		cp.DependsOn = append([]string(nil), t.DependsOn...)
+	}
+	if t.Retry != nil {
+		retry := *t.Retry
+		cp.Retry = &retry
+	}
+	if t.History != nil {
+		cp.History = append([]Attempt(nil), t.History...)
+	}
+	if t.RunAt != nil {
+		runAt := *t.RunAt
+		cp.RunAt = &runAt
+	}
+	if t.Deadline != nil {
+		deadline := *t.Deadline
+		cp.Deadline = &deadline
+	}
+	if t.NextAttemptAt != nil {
+		next := *t.NextAttemptAt
+		cp.NextAttemptAt = &next
+	}
+	return &cp
+}
+
+func cloneRaw(raw json.RawMessage) json.RawMessage {
+	if raw == nil {
+		return nil
+	}
+	return append(json.RawMessage(nil), raw...)
 }
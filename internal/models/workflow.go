@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WorkflowStep is one node of a workflow definition. Exactly one field is
+// set: a single task, a chain of steps run one after another, a group of
+// steps run in parallel, or a chord.
+type WorkflowStep struct {
+	Task  *TaskTemplate  `json:"task,omitempty"`
+	Chain []WorkflowStep `json:"chain,omitempty"`
+	Group []WorkflowStep `json:"group,omitempty"`
+	Chord *Chord         `json:"chord,omitempty"`
+}
+
+// Chord runs Group in parallel and then Callback with the group's results.
+type Chord struct {
+	Group    []WorkflowStep `json:"group"`
+	Callback WorkflowStep   `json:"callback"`
+}
+
+// Workflow is a submitted composite of tasks. Its status is not stored but
+// derived from its tasks; see WorkflowState.
+type Workflow struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name,omitempty"`
+	Tenant     string       `json:"tenant,omitempty"`
+	Definition WorkflowStep `json:"definition"`
+	TaskIDs    []string     `json:"task_ids"` // in definition order
+	Outputs    []string     `json:"outputs"`  // tasks whose results make up the workflow's result
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// WorkflowState is a workflow together with the current state of its tasks.
+type WorkflowState struct {
+	*Workflow
+	Status Status          `json:"status"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Tasks  []GraphNode     `json:"tasks"`
+}
+
+// Clone returns a copy of the workflow that shares no mutable state with w.
+func (w *Workflow) Clone() *Workflow {
+	if w == nil {
+		return nil
+	}
+	cp := *w
+	cp.Definition = w.Definition.clone()
+	cp.TaskIDs = append([]string(nil), w.TaskIDs...)
+	cp.Outputs = append([]string(nil), w.Outputs...)
+	return &cp
+}
+
+func (s WorkflowStep) clone() WorkflowStep {
+	cp := WorkflowStep{
+		Chain: cloneSteps(s.Chain),
+		Group: cloneSteps(s.Group),
+	}
+	if s.Task != nil {
+		tpl := s.Task.clone()
+		cp.Task = &tpl
+	}
+	if s.Chord != nil {
+		cp.Chord = &Chord{Group: cloneSteps(s.Chord.Group), Callback: s.Chord.Callback.clone()}
+	}
+	return cp
+}
+
+func cloneSteps(steps []WorkflowStep) []WorkflowStep {
+	if steps == nil {
+		return nil
+	}
+	out := make([]WorkflowStep, len(steps))
+	for i, s := range steps {
+		out[i] = s.clone()
+	}
+	return out
+}
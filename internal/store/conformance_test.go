@@ -0,0 +1,27 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/store"
+	"github.com/shayanmkpr/task-pool/internal/store/storetest"
+)
+
+// TestMemoryStoreConformance runs the shared TaskStore suite against MemoryStore
+func TestMemoryStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return store.NewMemoryStore()
+	})
+}
+
+// TestFileStoreConformance runs the shared TaskStore suite against FileStore
+func TestFileStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		s, err := store.OpenFileStore(t.TempDir(), store.DefaultFileOptions())
+		if err != nil {
+			t.Fatalf("Failed to open file store: %v", err)
+		}
+		t.Cleanup(func() { s.Close() })
+		return s
+	})
+}
@@ -0,0 +1,736 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.json"
+)
+
+// SyncPolicy controls when WAL appends are flushed to stable storage.
+type SyncPolicy string
+
+const (
+	SyncAlways   SyncPolicy = "always"   // fsync after every record
+	SyncInterval SyncPolicy = "interval" // fsync in the background every SyncInterval
+	SyncNever    SyncPolicy = "never"    // leave flushing to the OS
+)
+
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch p := SyncPolicy(s); p {
+	case SyncAlways, SyncInterval, SyncNever:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown fsync policy %q", s)
+	}
+}
+
+type FileOptions struct {
+	SyncPolicy      SyncPolicy
+	SyncInterval    time.Duration
+	CompactEvery    int           // WAL records between snapshots, 0 disables
+	CompactInterval time.Duration // time between snapshots, 0 disables
+}
+
+func DefaultFileOptions() FileOptions {
+	return FileOptions{
+		SyncPolicy:      SyncInterval,
+		SyncInterval:    time.Second,
+		CompactEvery:    1000,
+		CompactInterval: 5 * time.Minute,
+	}
+}
+
+type walOp string
+
+const (
+	opPutTask        walOp = "put_task"
+	opDeleteTask     walOp = "delete_task"
+	opPutSchedule    walOp = "put_schedule"
+	opDeleteSchedule walOp = "delete_schedule"
+	opPutDeadLetter  walOp = "put_dead_letter"
+	opDelDeadLetter  walOp = "delete_dead_letter"
+	opPutIdemKey     walOp = "put_idempotency_key"
+	opDelIdemKey     walOp = "delete_idempotency_key"
+	opPurgeIdemKeys  walOp = "purge_idempotency_keys"
+	opPutWorkflow    walOp = "put_workflow"
+	opDeleteWorkflow walOp = "delete_workflow"
+	opPutPauseState  walOp = "put_pause_state"
+)
+
+type walRecord struct {
+	Op       walOp            `json:"op"`
+	ID       string           `json:"id,omitempty"`
+	Task     *models.Task     `json:"task,omitempty"`
+	Schedule *models.Schedule `json:"schedule,omitempty"`
+
+	DeadLetter *models.DeadLetter `json:"dead_letter,omitempty"`
+
+	Idempotency *models.IdempotencyRecord `json:"idempotency,omitempty"`
+	Time        time.Time                 `json:"time,omitzero"`
+
+	Workflow *models.Workflow `json:"workflow,omitempty"`
+
+	Pauses *models.PauseState `json:"pauses,omitempty"`
+}
+
+type snapshot struct {
+	Tasks     []*models.Task     `json:"tasks"`
+	Schedules []*models.Schedule `json:"schedules,omitempty"`
+
+	DeadLetters []*models.DeadLetter `json:"dead_letters,omitempty"`
+
+	IdempotencyKeys []models.IdempotencyRecord `json:"idempotency_keys,omitempty"`
+
+	Workflows []*models.Workflow `json:"workflows,omitempty"`
+
+	Pauses *models.PauseState `json:"pauses,omitempty"`
+}
+
+// FileStore keeps the working set in a MemoryStore and makes it durable with
+// a write-ahead log that is periodically folded into a snapshot. Every
+// mutation is appended to the log before it is applied in memory, so a
+// restart replays snapshot + log and ends up in the last acknowledged state.
+type FileStore struct {
+	mu         sync.Mutex // serialises mutations and owns the WAL handle
+	mem        *MemoryStore
+	dir        string
+	opts       FileOptions
+	wal        *os.File
+	walRecords int
+	compactAt  int  // walRecords that trigger the next compaction, pushed back when one fails
+	dirty      bool // appended since last fsync
+	closing    bool // set by the first Close
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ Store = (*FileStore)(nil)
+
+// OpenFileStore loads (or creates) the store rooted at dir.
+func OpenFileStore(dir string, opts FileOptions) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	s := &FileStore{
+		mem:       NewMemoryStore(),
+		dir:       dir,
+		opts:      opts,
+		compactAt: opts.CompactEvery,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	if err := s.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := s.replayWAL(); err != nil {
+		return nil, err
+	}
+
+	wal, err := os.OpenFile(s.path(walFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	s.wal = wal
+
+	go s.background()
+	return s, nil
+}
+
+func (s *FileStore) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+func (s *FileStore) loadSnapshot() error {
+	data, err := os.ReadFile(s.path(snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	for _, t := range snap.Tasks {
+		s.mem.tasks[t.ID] = t
+	}
+	for _, sc := range snap.Schedules {
+		s.mem.schedules[sc.ID] = sc
+	}
+	for _, dl := range snap.DeadLetters {
+		s.mem.dead[dl.ID] = dl
+	}
+	for _, rec := range snap.IdempotencyKeys {
+		s.mem.idem[rec.Key] = rec
+	}
+	for _, wf := range snap.Workflows {
+		s.mem.workflows[wf.ID] = wf
+	}
+	s.mem.pauses = snap.Pauses
+	return nil
+}
+
+// replayWAL applies every complete record in the log. A torn final record
+// (the process died mid-append) is truncated away so new appends start clean.
+// A corrupt record with more of the log after it is not a torn write, so
+// it fails the replay rather than throw away the records behind it.
+func (s *FileStore) replayWAL() error {
+	f, err := os.OpenFile(s.path(walFileName), os.O_RDWR, 0o644)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open wal: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var good int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			break // a partial line without newline is a torn write
+		}
+		if err != nil {
+			return fmt.Errorf("read wal: %w", err)
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				break // the last line, torn after its newline was written
+			}
+			return fmt.Errorf("corrupt wal record at offset %d: %w", good, err)
+		}
+		s.apply(rec)
+		s.walRecords++
+		good += int64(len(line))
+	}
+
+	if err := f.Truncate(good); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	return nil
+}
+
+// apply mutates the in-memory state. Records are idempotent so replaying a
+// log on top of a snapshot that already contains its effects is harmless.
+func (s *FileStore) apply(rec walRecord) {
+	switch rec.Op {
+	case opPutTask:
+		if rec.Task != nil {
+			s.mem.mu.Lock()
+			s.mem.tasks[rec.Task.ID] = rec.Task.Clone()
+			s.mem.mu.Unlock()
+		}
+	case opDeleteTask:
+		s.mem.mu.Lock()
+		delete(s.mem.tasks, rec.ID)
+		s.mem.mu.Unlock()
+	case opPutSchedule:
+		if rec.Schedule != nil {
+			s.mem.mu.Lock()
+			s.mem.schedules[rec.Schedule.ID] = rec.Schedule.Clone()
+			s.mem.mu.Unlock()
+		}
+	case opDeleteSchedule:
+		s.mem.mu.Lock()
+		delete(s.mem.schedules, rec.ID)
+		s.mem.mu.Unlock()
+	case opPutDeadLetter:
+		if rec.DeadLetter != nil {
+			s.mem.mu.Lock()
+			s.mem.dead[rec.DeadLetter.ID] = rec.DeadLetter.Clone()
+			s.mem.mu.Unlock()
+		}
+	case opDelDeadLetter:
+		s.mem.mu.Lock()
+		delete(s.mem.dead, rec.ID)
+		s.mem.mu.Unlock()
+	case opPutIdemKey:
+		if rec.Idempotency != nil {
+			s.mem.mu.Lock()
+			s.mem.idem[rec.Idempotency.Key] = *rec.Idempotency
+			s.mem.mu.Unlock()
+		}
+	case opDelIdemKey:
+		s.mem.mu.Lock()
+		delete(s.mem.idem, rec.ID)
+		s.mem.mu.Unlock()
+	case opPurgeIdemKeys:
+		s.mem.mu.Lock()
+		s.mem.purgeIdempotencyLocked(rec.Time)
+		s.mem.mu.Unlock()
+	case opPutWorkflow:
+		if rec.Workflow != nil {
+			s.mem.mu.Lock()
+			s.mem.workflows[rec.Workflow.ID] = rec.Workflow.Clone()
+			s.mem.mu.Unlock()
+		}
+	case opDeleteWorkflow:
+		s.mem.mu.Lock()
+		delete(s.mem.workflows, rec.ID)
+		s.mem.mu.Unlock()
+	case opPutPauseState:
+		if rec.Pauses != nil {
+			s.mem.mu.Lock()
+			s.mem.pauses = rec.Pauses.Clone()
+			s.mem.mu.Unlock()
+		}
+	}
+}
+
+// append writes rec to the WAL and applies it. Callers must hold s.mu.
+func (s *FileStore) append(rec walRecord) error {
+	if s.wal == nil {
+		return errors.New("file store is closed")
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode wal record: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := s.wal.Write(data); err != nil {
+		return fmt.Errorf("write wal: %w", err)
+	}
+	if s.opts.SyncPolicy == SyncAlways {
+		if err := s.wal.Sync(); err != nil {
+			return fmt.Errorf("sync wal: %w", err)
+		}
+	} else {
+		s.dirty = true
+	}
+
+	s.apply(rec)
+	s.walRecords++
+
+	if s.opts.CompactEvery > 0 && s.walRecords >= s.compactAt {
+		// The record is already durable and applied, so a failed snapshot
+		// must not fail the write. The WAL keeps growing until a later
+		// compaction succeeds.
+		if err := s.compactLocked(); err != nil {
+			slog.Error("file store compaction failed", "dir", s.dir, "wal_records", s.walRecords, "error", err)
+			s.compactAt = s.walRecords + s.opts.CompactEvery
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) AddTask(ctx context.Context, task *models.Task) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateTask(task); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(walRecord{Op: opPutTask, Task: task})
+}
+
+func (s *FileStore) GetTask(ctx context.Context, id string) (*models.Task, error) {
+	return s.mem.GetTask(ctx, id)
+}
+
+func (s *FileStore) ListTasks(ctx context.Context) ([]*models.Task, error) {
+	return s.mem.ListTasks(ctx)
+}
+
+func (s *FileStore) UpdateTask(ctx context.Context, task *models.Task) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateTask(task); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.mem.GetTask(ctx, task.ID); err != nil {
+		return err
+	}
+	return s.append(walRecord{Op: opPutTask, Task: task})
+}
+
+func (s *FileStore) DeleteTask(ctx context.Context, id string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.mem.GetTask(ctx, id); err != nil {
+		return err
+	}
+	return s.append(walRecord{Op: opDeleteTask, ID: id})
+}
+
+func (s *FileStore) AddSchedule(ctx context.Context, schedule *models.Schedule) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(walRecord{Op: opPutSchedule, Schedule: schedule})
+}
+
+func (s *FileStore) QueryTasks(ctx context.Context, q TaskQuery) (*TaskPage, error) {
+	return s.mem.QueryTasks(ctx, q)
+}
+
+func (s *FileStore) GetSchedule(ctx context.Context, id string) (*models.Schedule, error) {
+	return s.mem.GetSchedule(ctx, id)
+}
+
+func (s *FileStore) ListSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	return s.mem.ListSchedules(ctx)
+}
+
+func (s *FileStore) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.mem.GetSchedule(ctx, schedule.ID); err != nil {
+		return err
+	}
+	return s.append(walRecord{Op: opPutSchedule, Schedule: schedule})
+}
+
+func (s *FileStore) DeleteSchedule(ctx context.Context, id string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.mem.GetSchedule(ctx, id); err != nil {
+		return err
+	}
+	return s.append(walRecord{Op: opDeleteSchedule, ID: id})
+}
+
+func (s *FileStore) AddDeadLetter(ctx context.Context, dl *models.DeadLetter) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateDeadLetter(dl); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(walRecord{Op: opPutDeadLetter, DeadLetter: dl})
+}
+
+func (s *FileStore) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	return s.mem.GetDeadLetter(ctx, id)
+}
+
+func (s *FileStore) ListDeadLetters(ctx context.Context) ([]*models.DeadLetter, error) {
+	return s.mem.ListDeadLetters(ctx)
+}
+
+func (s *FileStore) DeleteDeadLetter(ctx context.Context, id string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.mem.GetDeadLetter(ctx, id); err != nil {
+		return err
+	}
+	return s.append(walRecord{Op: opDelDeadLetter, ID: id})
+}
+
+func (s *FileStore) TakeDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dl, err := s.mem.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.append(walRecord{Op: opDelDeadLetter, ID: id}); err != nil {
+		return nil, err
+	}
+	return dl, nil
+}
+
+// ClaimIdempotencyKey checks and logs the claim under the write lock, so two
+// concurrent claims of the same key cannot both succeed.
+func (s *FileStore) ClaimIdempotencyKey(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, false, err
+	}
+	if rec == nil || rec.Key == "" {
+		return nil, false, ErrEmptyIdempotencyKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem.mu.RLock()
+	existing, ok := s.mem.idem[rec.Key]
+	s.mem.mu.RUnlock()
+	if ok && !existing.Expired(rec.CreatedAt) {
+		return &existing, false, nil
+	}
+	claimed := *rec
+	if err := s.append(walRecord{Op: opPutIdemKey, Idempotency: &claimed}); err != nil {
+		return nil, false, err
+	}
+	return &claimed, true, nil
+}
+
+func (s *FileStore) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(walRecord{Op: opDelIdemKey, ID: key})
+}
+
+func (s *FileStore) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	if err := checkCtx(ctx); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem.mu.RLock()
+	expired := 0
+	for _, rec := range s.mem.idem {
+		if rec.Expired(now) {
+			expired++
+		}
+	}
+	s.mem.mu.RUnlock()
+	if expired == 0 {
+		return 0, nil
+	}
+	if err := s.append(walRecord{Op: opPurgeIdemKeys, Time: now}); err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (s *FileStore) AddWorkflow(ctx context.Context, wf *models.Workflow) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateWorkflow(wf); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(walRecord{Op: opPutWorkflow, Workflow: wf})
+}
+
+func (s *FileStore) GetWorkflow(ctx context.Context, id string) (*models.Workflow, error) {
+	return s.mem.GetWorkflow(ctx, id)
+}
+
+func (s *FileStore) ListWorkflows(ctx context.Context) ([]*models.Workflow, error) {
+	return s.mem.ListWorkflows(ctx)
+}
+
+func (s *FileStore) DeleteWorkflow(ctx context.Context, id string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.mem.GetWorkflow(ctx, id); err != nil {
+		return err
+	}
+	return s.append(walRecord{Op: opDeleteWorkflow, ID: id})
+}
+
+func (s *FileStore) GetPauseState(ctx context.Context) (*models.PauseState, error) {
+	return s.mem.GetPauseState(ctx)
+}
+
+func (s *FileStore) SetPauseState(ctx context.Context, state *models.PauseState) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if state == nil {
+		return ErrNilPauseState
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.append(walRecord{Op: opPutPauseState, Pauses: state})
+}
+
+// Compact writes a snapshot of the current state and truncates the WAL.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compactLocked()
+}
+
+func (s *FileStore) compactLocked() error {
+	tasks, err := s.mem.ListTasks(context.Background())
+	if err != nil {
+		return err
+	}
+	schedules, err := s.mem.ListSchedules(context.Background())
+	if err != nil {
+		return err
+	}
+	dead, err := s.mem.ListDeadLetters(context.Background())
+	if err != nil {
+		return err
+	}
+	s.mem.mu.RLock()
+	keys := make([]models.IdempotencyRecord, 0, len(s.mem.idem))
+	for _, rec := range s.mem.idem {
+		keys = append(keys, rec)
+	}
+	s.mem.mu.RUnlock()
+	workflows, err := s.mem.ListWorkflows(context.Background())
+	if err != nil {
+		return err
+	}
+	s.mem.mu.RLock()
+	pauses := s.mem.pauses.Clone()
+	s.mem.mu.RUnlock()
+	data, err := json.Marshal(snapshot{Tasks: tasks, Schedules: schedules, DeadLetters: dead, IdempotencyKeys: keys, Workflows: workflows, Pauses: pauses})
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := writeFileAtomic(s.path(snapshotFileName), data); err != nil {
+		return err
+	}
+
+	// The snapshot is durable, so the log can start over. A crash before the
+	// truncate just replays records the snapshot already contains.
+	if err := s.wal.Truncate(0); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	if err := s.wal.Sync(); err != nil {
+		return fmt.Errorf("sync wal: %w", err)
+	}
+	s.walRecords = 0
+	s.compactAt = s.opts.CompactEvery
+	s.dirty = false
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", tmp, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %s: %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename %s: %w", tmp, err)
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Some platforms refuse to fsync directories; the rename is still atomic.
+	_ = d.Sync()
+	return nil
+}
+
+func (s *FileStore) background() {
+	defer close(s.done)
+
+	var syncC, compactC <-chan time.Time
+	if s.opts.SyncPolicy == SyncInterval && s.opts.SyncInterval > 0 {
+		t := time.NewTicker(s.opts.SyncInterval)
+		defer t.Stop()
+		syncC = t.C
+	}
+	if s.opts.CompactInterval > 0 {
+		t := time.NewTicker(s.opts.CompactInterval)
+		defer t.Stop()
+		compactC = t.C
+	}
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-syncC:
+			s.mu.Lock()
+			if s.dirty && s.wal != nil {
+				if err := s.wal.Sync(); err == nil {
+					s.dirty = false
+				}
+			}
+			s.mu.Unlock()
+		case <-compactC:
+			s.mu.Lock()
+			if s.walRecords > 0 && s.wal != nil {
+				if err := s.compactLocked(); err != nil {
+					slog.Error("file store compaction failed", "dir", s.dir, "wal_records", s.walRecords, "error", err)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close flushes the WAL and releases the file handle. Only the first call
+// does anything, so concurrent calls are safe.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	if s.wal == nil || s.closing {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closing = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	syncErr := s.wal.Sync()
+	closeErr := s.wal.Close()
+	s.wal = nil
+	return errors.Join(syncErr, closeErr)
+}
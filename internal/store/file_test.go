@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+func openTestFileStore(t *testing.T, dir string, opts FileOptions) *FileStore {
+	t.Helper()
+	s, err := OpenFileStore(dir, opts)
+	if err != nil {
+		t.Fatalf("Failed to open file store: %v", err)
+	}
+	return s
+}
+
+// TestFileStoreReopen tests that tasks survive closing and reopening the store
+func TestFileStoreReopen(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	opts := DefaultFileOptions()
+	opts.SyncPolicy = SyncAlways
+
+	s := openTestFileStore(t, dir, opts)
+	task := &models.Task{ID: "durable", Title: "Durable Task", Status: models.Pending}
+	if err := s.AddTask(ctx, task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	task.Status = models.Running
+	if err := s.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+	if err := s.AddTask(ctx, &models.Task{ID: "deleted", Title: "Deleted"}); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	if err := s.DeleteTask(ctx, "deleted"); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	s.Close()
+
+	reopened := openTestFileStore(t, dir, opts)
+	defer reopened.Close()
+
+	got, err := reopened.GetTask(ctx, "durable")
+	if err != nil {
+		t.Fatalf("Task lost after reopen: %v", err)
+	}
+	if got.Status != models.Running {
+		t.Errorf("Expected status 'running', got '%s'", got.Status)
+	}
+	if _, err := reopened.GetTask(ctx, "deleted"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Deleted task came back after reopen: %v", err)
+	}
+}
+
+// TestFileStoreSchedulesSurviveCompaction tests that schedules, dead letters, idempotency keys, workflows and pauses are part of snapshots
+func TestFileStoreSchedulesSurviveCompaction(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s := openTestFileStore(t, dir, DefaultFileOptions())
+	if err := s.AddSchedule(ctx, &models.Schedule{ID: "hourly", Spec: "@hourly"}); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+	key := &models.IdempotencyRecord{Key: "k", TaskID: "t", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if _, _, err := s.ClaimIdempotencyKey(ctx, key); err != nil {
+		t.Fatalf("ClaimIdempotencyKey failed: %v", err)
+	}
+	dead := &models.DeadLetter{ID: "dead", Task: &models.Task{ID: "dead", Status: models.Failed}, Error: "boom"}
+	if err := s.AddDeadLetter(ctx, dead); err != nil {
+		t.Fatalf("AddDeadLetter failed: %v", err)
+	}
+	if err := s.AddWorkflow(ctx, &models.Workflow{ID: "wf", TaskIDs: []string{"a", "b"}}); err != nil {
+		t.Fatalf("AddWorkflow failed: %v", err)
+	}
+	if err := s.SetPauseState(ctx, &models.PauseState{Types: []string{"echo"}}); err != nil {
+		t.Fatalf("SetPauseState failed: %v", err)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	s.Close()
+
+	reopened := openTestFileStore(t, dir, DefaultFileOptions())
+	defer reopened.Close()
+	if got, err := reopened.GetPauseState(ctx); err != nil || len(got.Types) != 1 {
+		t.Errorf("Pause state lost after compaction and reopen: %+v, %v", got, err)
+	}
+	if got, err := reopened.GetSchedule(ctx, "hourly"); err != nil || got.Spec != "@hourly" {
+		t.Errorf("Schedule lost after compaction and reopen: %+v, %v", got, err)
+	}
+	if got, err := reopened.GetDeadLetter(ctx, "dead"); err != nil || got.Error != "boom" {
+		t.Errorf("Dead letter lost after compaction and reopen: %+v, %v", got, err)
+	}
+	if existing, claimed, _ := reopened.ClaimIdempotencyKey(ctx, &models.IdempotencyRecord{Key: "k", CreatedAt: time.Now()}); claimed || existing.TaskID != "t" {
+		t.Errorf("Idempotency key lost after compaction and reopen: %+v", existing)
+	}
+	if got, err := reopened.GetWorkflow(ctx, "wf"); err != nil || len(got.TaskIDs) != 2 {
+		t.Errorf("Workflow lost after compaction and reopen: %+v, %v", got, err)
+	}
+}
+
+// TestFileStoreCompaction tests that compaction folds the WAL into a snapshot
+func TestFileStoreCompaction(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	opts := DefaultFileOptions()
+	opts.CompactEvery = 3
+
+	s := openTestFileStore(t, dir, opts)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if err := s.AddTask(ctx, &models.Task{ID: id, Title: id}); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+	s.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err != nil {
+		t.Fatalf("Expected snapshot after compaction: %v", err)
+	}
+
+	reopened := openTestFileStore(t, dir, opts)
+	defer reopened.Close()
+	tasks, err := reopened.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 4 {
+		t.Errorf("Expected 4 tasks after compaction and reopen, got %d", len(tasks))
+	}
+}
+
+// TestFileStoreCompactionFailure tests that a failed compaction does not fail the write that triggered it
+func TestFileStoreCompactionFailure(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	opts := DefaultFileOptions()
+	opts.CompactEvery = 2
+
+	s := openTestFileStore(t, dir, opts)
+	defer s.Close()
+	// A non-empty directory where the snapshot goes makes the rename fail
+	blocker := filepath.Join(dir, snapshotFileName)
+	if err := os.MkdirAll(filepath.Join(blocker, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b", "c"} {
+		if err := s.AddTask(ctx, &models.Task{ID: id, Title: id}); err != nil {
+			t.Fatalf("AddTask(%s) failed: %v", id, err)
+		}
+	}
+	if _, err := s.GetTask(ctx, "b"); err != nil {
+		t.Errorf("Task written during a failed compaction is missing: %v", err)
+	}
+
+	// The next threshold retries and succeeds
+	os.RemoveAll(blocker)
+	s.AddTask(ctx, &models.Task{ID: "d", Title: "d"})
+	if info, err := os.Stat(blocker); err != nil || info.IsDir() {
+		t.Errorf("Expected a snapshot once compaction could succeed: %v", err)
+	}
+}
+
+// TestFileStoreTornWrite tests recovery from a partially written WAL record
+func TestFileStoreTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s := openTestFileStore(t, dir, DefaultFileOptions())
+	if err := s.AddTask(ctx, &models.Task{ID: "whole", Title: "Whole"}); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	s.Close()
+
+	// Simulate a crash in the middle of an append
+	f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open wal: %v", err)
+	}
+	f.WriteString(`{"op":"put_task","task":{"id":"torn"`)
+	f.Close()
+
+	reopened := openTestFileStore(t, dir, DefaultFileOptions())
+	if _, err := reopened.GetTask(ctx, "whole"); err != nil {
+		t.Errorf("Complete record lost: %v", err)
+	}
+	if _, err := reopened.GetTask(ctx, "torn"); err == nil {
+		t.Error("Torn record should not have been applied")
+	}
+
+	// New appends must land after the truncated tail
+	if err := reopened.AddTask(ctx, &models.Task{ID: "after", Title: "After"}); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	reopened.Close()
+
+	again := openTestFileStore(t, dir, DefaultFileOptions())
+	defer again.Close()
+	if _, err := again.GetTask(ctx, "after"); err != nil {
+		t.Errorf("Record appended after torn write was lost: %v", err)
+	}
+}
+
+// TestFileStoreCorruptRecord tests that a bad record in the middle of the WAL fails the open instead of truncating what follows
+func TestFileStoreCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s := openTestFileStore(t, dir, DefaultFileOptions())
+	s.AddTask(ctx, &models.Task{ID: "before", Title: "Before"})
+	s.Close()
+
+	wal := filepath.Join(dir, walFileName)
+	f, err := os.OpenFile(wal, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open wal: %v", err)
+	}
+	f.WriteString("garbage\n" + `{"op":"put_task","task":{"id":"after","title":"After"}}` + "\n")
+	f.Close()
+	info, _ := os.Stat(wal)
+
+	if _, err := OpenFileStore(dir, DefaultFileOptions()); err == nil {
+		t.Fatal("Expected a corrupt record to fail the open")
+	}
+	if after, _ := os.Stat(wal); after.Size() != info.Size() {
+		t.Errorf("Expected the wal to be left alone, size went from %d to %d", info.Size(), after.Size())
+	}
+}
+
+// TestFileStoreCorruptLastRecord tests that an undecodable final line is treated as a torn write
+func TestFileStoreCorruptLastRecord(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s := openTestFileStore(t, dir, DefaultFileOptions())
+	s.AddTask(ctx, &models.Task{ID: "whole", Title: "Whole"})
+	s.Close()
+
+	f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open wal: %v", err)
+	}
+	f.WriteString(`{"op":"put_task","task":{"id":"torn"` + "\n")
+	f.Close()
+
+	reopened := openTestFileStore(t, dir, DefaultFileOptions())
+	defer reopened.Close()
+	if _, err := reopened.GetTask(ctx, "whole"); err != nil {
+		t.Errorf("Complete record lost: %v", err)
+	}
+}
+
+// TestFileStoreConcurrentClose tests that closing the store from several goroutines at once is safe
+func TestFileStoreConcurrentClose(t *testing.T) {
+	s := openTestFileStore(t, t.TempDir(), DefaultFileOptions())
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Close(); err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -2,41 +2,50 @@ package store
 
 import (
 	"context"
-	"errors"
 	"sync"
+	"time"
 
 	"github.com/shayanmkpr/task-pool/internal/models"
 )
 
 type MemoryStore struct {
-	mu    sync.RWMutex            // for reading memory safe
-	tasks map[string]*models.Task // assigining ids to tasks
+	mu        sync.RWMutex            // for reading memory safe
+	tasks     map[string]*models.Task // assigining ids to tasks
+	schedules map[string]*models.Schedule
+	dead      map[string]*models.DeadLetter
+	idem      map[string]models.IdempotencyRecord
+	workflows map[string]*models.Workflow
+	pauses    *models.PauseState
 }
 
+var _ Store = (*MemoryStore)(nil)
+
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		tasks: make(map[string]*models.Task),
+		tasks:     make(map[string]*models.Task),
+		schedules: make(map[string]*models.Schedule),
+		dead:      make(map[string]*models.DeadLetter),
+		idem:      make(map[string]models.IdempotencyRecord),
+		workflows: make(map[string]*models.Workflow),
 	}
 }
 
-func (s *MemoryStore) AddTask(task *models.Task) error { //fix
-	if task == nil { //fix
-		return errors.New("task cannot be nil") //fix
+func (s *MemoryStore) AddTask(ctx context.Context, task *models.Task) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
 	}
-	if task.ID == "" { //fix
-		return errors.New("task ID cannot be empty") //fix
+	if err := validateTask(task); err != nil {
+		return err
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.tasks[task.ID] = task
+	s.tasks[task.ID] = task.Clone()
 	return nil
 }
 
 func (s *MemoryStore) GetTask(ctx context.Context, id string) (*models.Task, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
 	}
 
 	s.mu.RLock()
@@ -44,30 +53,332 @@ func (s *MemoryStore) GetTask(ctx context.Context, id string) (*models.Task, err
 	task, exists := s.tasks[id]
 
 	if !exists {
-		return nil, errors.New("task not found")
+		return nil, ErrTaskNotFound
 	}
-	return task, nil
+	return task.Clone(), nil
 }
 
 func (s *MemoryStore) ListTasks(ctx context.Context) ([]*models.Task, error) {
-
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
 	}
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	tasks := make([]*models.Task, 0, len(s.tasks))
 	for _, t := range s.tasks {
-		tasks = append(tasks, t)
+		tasks = append(tasks, t.Clone())
 	}
 	return tasks, nil
 }
 
-func (s *MemoryStore) UpdateTask(task *models.Task) {
+// QueryTasks filters under the read lock without copying; stored tasks are
+// never mutated in place, so the matches can be sorted and paged after the
+// lock is released.
+func (s *MemoryStore) QueryTasks(ctx context.Context, q TaskQuery) (*TaskPage, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	after, err := q.prepare()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	var matches []*models.Task
+	for _, t := range s.tasks {
+		if q.match(t) {
+			matches = append(matches, t)
+		}
+	}
+	s.mu.RUnlock()
+
+	return runQuery(&q, after, matches), nil
+}
+
+func (s *MemoryStore) UpdateTask(ctx context.Context, task *models.Task) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateTask(task); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tasks[task.ID]; !exists {
+		return ErrTaskNotFound
+	}
+	s.tasks[task.ID] = task.Clone()
+	return nil
+}
+
+func (s *MemoryStore) DeleteTask(ctx context.Context, id string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tasks[id]; !exists {
+		return ErrTaskNotFound
+	}
+	delete(s.tasks, id)
+	return nil
+}
+
+func (s *MemoryStore) AddSchedule(ctx context.Context, schedule *models.Schedule) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules[schedule.ID] = schedule.Clone()
+	return nil
+}
+
+func (s *MemoryStore) GetSchedule(ctx context.Context, id string) (*models.Schedule, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	schedule, exists := s.schedules[id]
+	if !exists {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule.Clone(), nil
+}
+
+func (s *MemoryStore) ListSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	schedules := make([]*models.Schedule, 0, len(s.schedules))
+	for _, sc := range s.schedules {
+		schedules = append(schedules, sc.Clone())
+	}
+	return schedules, nil
+}
+
+func (s *MemoryStore) UpdateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.schedules[schedule.ID]; !exists {
+		return ErrScheduleNotFound
+	}
+	s.schedules[schedule.ID] = schedule.Clone()
+	return nil
+}
+
+func (s *MemoryStore) DeleteSchedule(ctx context.Context, id string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.schedules[id]; !exists {
+		return ErrScheduleNotFound
+	}
+	delete(s.schedules, id)
+	return nil
+}
+
+func (s *MemoryStore) AddDeadLetter(ctx context.Context, dl *models.DeadLetter) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateDeadLetter(dl); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dead[dl.ID] = dl.Clone()
+	return nil
+}
+
+func (s *MemoryStore) GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	dl, exists := s.dead[id]
+	if !exists {
+		return nil, ErrDeadLetterNotFound
+	}
+	return dl.Clone(), nil
+}
+
+func (s *MemoryStore) ListDeadLetters(ctx context.Context) ([]*models.DeadLetter, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	dead := make([]*models.DeadLetter, 0, len(s.dead))
+	for _, dl := range s.dead {
+		dead = append(dead, dl.Clone())
+	}
+	return dead, nil
+}
+
+func (s *MemoryStore) DeleteDeadLetter(ctx context.Context, id string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.dead[id]; !exists {
+		return ErrDeadLetterNotFound
+	}
+	delete(s.dead, id)
+	return nil
+}
+
+func (s *MemoryStore) TakeDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dl, exists := s.dead[id]
+	if !exists {
+		return nil, ErrDeadLetterNotFound
+	}
+	delete(s.dead, id)
+	return dl, nil
+}
+
+func (s *MemoryStore) ClaimIdempotencyKey(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, bool, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, false, err
+	}
+	if rec == nil || rec.Key == "" {
+		return nil, false, ErrEmptyIdempotencyKey
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.idem[rec.Key]; ok && !existing.Expired(rec.CreatedAt) {
+		return &existing, false, nil
+	}
+	claimed := *rec
+	s.idem[rec.Key] = claimed
+	return &claimed, true, nil
+}
+
+func (s *MemoryStore) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.idem, key)
+	return nil
+}
+
+func (s *MemoryStore) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	if err := checkCtx(ctx); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.purgeIdempotencyLocked(now), nil
+}
+
+func (s *MemoryStore) purgeIdempotencyLocked(now time.Time) int {
+	n := 0
+	for key, rec := range s.idem {
+		if rec.Expired(now) {
+			delete(s.idem, key)
+			n++
+		}
+	}
+	return n
+}
+
+func (s *MemoryStore) AddWorkflow(ctx context.Context, wf *models.Workflow) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if err := validateWorkflow(wf); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workflows[wf.ID] = wf.Clone()
+	return nil
+}
+
+func (s *MemoryStore) GetWorkflow(ctx context.Context, id string) (*models.Workflow, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	wf, exists := s.workflows[id]
+	if !exists {
+		return nil, ErrWorkflowNotFound
+	}
+	return wf.Clone(), nil
+}
+
+func (s *MemoryStore) ListWorkflows(ctx context.Context) ([]*models.Workflow, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	workflows := make([]*models.Workflow, 0, len(s.workflows))
+	for _, wf := range s.workflows {
+		workflows = append(workflows, wf.Clone())
+	}
+	return workflows, nil
+}
+
+func (s *MemoryStore) DeleteWorkflow(ctx context.Context, id string) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.tasks[task.ID] = task
+	if _, exists := s.workflows[id]; !exists {
+		return ErrWorkflowNotFound
+	}
+	delete(s.workflows, id)
+	return nil
+}
+
+func (s *MemoryStore) GetPauseState(ctx context.Context) (*models.PauseState, error) {
+	if err := checkCtx(ctx); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.pauses == nil {
+		return &models.PauseState{Types: []string{}}, nil
+	}
+	return s.pauses.Clone(), nil
+}
+
+func (s *MemoryStore) SetPauseState(ctx context.Context, state *models.PauseState) error {
+	if err := checkCtx(ctx); err != nil {
+		return err
+	}
+	if state == nil {
+		return ErrNilPauseState
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pauses = state.Clone()
+	return nil
 }
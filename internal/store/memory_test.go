@@ -21,7 +21,7 @@ func TestMemoryStoreBasic(t *testing.T) {
 		Duration:    1,
 	}
 
-	store.AddTask(task)
+	store.AddTask(context.Background(), task)
 
 	ctx := context.Background()
 	retrievedTask, err := store.GetTask(ctx, "basic-test-1")
@@ -57,7 +57,7 @@ func TestMemoryStoreConcurrentSubmissions(t *testing.T) {
 				Status:      models.Pending,
 				Duration:    1,
 			}
-			store.AddTask(task)
+			store.AddTask(context.Background(), task)
 		}(i)
 	}
 
@@ -95,8 +95,8 @@ func TestMemoryStoreDuplicateIDs(t *testing.T) {
 		Duration:    2,
 	}
 
-	store.AddTask(task1)
-	store.AddTask(task2) // This should overwrite the first task
+	store.AddTask(context.Background(), task1)
+	store.AddTask(context.Background(), task2) // This should overwrite the first task
 
 	ctx := context.Background()
 	retrievedTask, err := store.GetTask(ctx, "duplicate-test")
@@ -146,12 +146,12 @@ func TestMemoryStoreUpdateTask(t *testing.T) {
 		Duration:    1,
 	}
 
-	store.AddTask(task)
+	store.AddTask(context.Background(), task)
 
 	// Update the task
 	task.Title = "Updated Title"
 	task.Status = models.Running
-	store.UpdateTask(task)
+	store.UpdateTask(context.Background(), task)
 
 	ctx := context.Background()
 	updatedTask, err := store.GetTask(ctx, "update-test")
@@ -179,7 +179,7 @@ func TestMemoryStoreGetTaskWithTimeout(t *testing.T) {
 		Status:      models.Pending,
 		Duration:    1,
 	}
-	store.AddTask(task)
+	store.AddTask(context.Background(), task)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Nanosecond)
 	defer cancel()
@@ -233,7 +233,7 @@ func TestMemoryStoreConcurrentReadWrite(t *testing.T) {
 				Status:      models.Pending,
 				Duration:    1,
 			}
-			store.AddTask(task)
+			store.AddTask(context.Background(), task)
 		}(i)
 	}
 
@@ -276,7 +276,7 @@ func TestMemoryStoreLargeNumberOfTasks(t *testing.T) {
 			Status:      models.Pending,
 			Duration:    1,
 		}
-		store.AddTask(task)
+		store.AddTask(context.Background(), task)
 	}
 
 	// Verify all tasks are stored
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+var (
+	ErrInvalidQuery  = errors.New("invalid task query")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SortField is a task attribute listings can be ordered by. Ties are broken
+// by task ID so every ordering is total and cursors are stable.
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortPriority  SortField = "priority"
+	SortStatus    SortField = "status"
+	SortType      SortField = "type"
+	SortTitle     SortField = "title"
+)
+
+// TaskQuery selects, orders and pages tasks. Zero values mean no filter.
+type TaskQuery struct {
+	Statuses      []models.Status // any of these
+	Types         []string        // any of these
+	Tag           string          // tasks carrying this tag
+	CreatedAfter  time.Time       // exclusive
+	CreatedBefore time.Time       // exclusive
+	Sort          SortField       // defaults to SortCreatedAt
+	Desc          bool
+	Limit         int    // 0 returns every match
+	Cursor        string // NextCursor of the previous page
+}
+
+// TaskPage is one page of query results. NextCursor is empty on the last page.
+type TaskPage struct {
+	Tasks      []*models.Task
+	NextCursor string
+}
+
+// cursor is the position after the last task of a page. It carries the sort
+// it was issued for so it cannot be replayed against a different ordering.
+type cursor struct {
+	Sort      SortField     `json:"s"`
+	Desc      bool          `json:"d,omitempty"`
+	ID        string        `json:"i"`
+	CreatedAt time.Time     `json:"c,omitempty"`
+	Priority  int           `json:"p,omitempty"`
+	Status    models.Status `json:"st,omitempty"`
+	Type      string        `json:"t,omitempty"`
+	Title     string        `json:"ti,omitempty"`
+}
+
+// prepare validates the query, fills in defaults and decodes its cursor.
+func (q *TaskQuery) prepare() (*cursor, error) {
+	switch q.Sort {
+	case "":
+		q.Sort = SortCreatedAt
+	case SortCreatedAt, SortPriority, SortStatus, SortType, SortTitle:
+	default:
+		return nil, fmt.Errorf("%w: unknown sort field %q", ErrInvalidQuery, q.Sort)
+	}
+	if q.Limit < 0 {
+		return nil, fmt.Errorf("%w: negative limit", ErrInvalidQuery)
+	}
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != q.Sort || c.Desc != q.Desc {
+		return nil, fmt.Errorf("%w: issued for a different sort order", ErrInvalidCursor)
+	}
+	return &c, nil
+}
+
+func (q *TaskQuery) match(t *models.Task) bool {
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, t.Status) {
+		return false
+	}
+	if len(q.Types) > 0 && !slices.Contains(q.Types, t.Type) {
+		return false
+	}
+	if q.Tag != "" && !slices.Contains(t.Tags, q.Tag) {
+		return false
+	}
+	if !q.CreatedAfter.IsZero() && !t.CreatedAt.After(q.CreatedAfter) {
+		return false
+	}
+	if !q.CreatedBefore.IsZero() && !t.CreatedAt.Before(q.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// compare orders two tasks by the query's sort field and direction.
+func (q *TaskQuery) compare(a, b *cursor) int {
+	var c int
+	switch q.Sort {
+	case SortPriority:
+		c = cmp.Compare(a.Priority, b.Priority)
+	case SortStatus:
+		c = cmp.Compare(a.Status, b.Status)
+	case SortType:
+		c = cmp.Compare(a.Type, b.Type)
+	case SortTitle:
+		c = cmp.Compare(a.Title, b.Title)
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if q.Desc {
+		c = -c
+	}
+	return c
+}
+
+func (q *TaskQuery) keyOf(t *models.Task) *cursor {
+	return &cursor{
+		Sort:      q.Sort,
+		Desc:      q.Desc,
+		ID:        t.ID,
+		CreatedAt: t.CreatedAt,
+		Priority:  t.Priority,
+		Status:    t.Status,
+		Type:      t.Type,
+		Title:     t.Title,
+	}
+}
+
+func (c *cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// runQuery orders the matching tasks and cuts out one page. The matches are
+// shared, read-only snapshots taken under the store's lock; only the tasks
+// on the returned page are copied.
+func runQuery(q *TaskQuery, after *cursor, matches []*models.Task) *TaskPage {
+	keys := make([]*cursor, len(matches))
+	for i, t := range matches {
+		keys[i] = q.keyOf(t)
+	}
+	idx := make([]int, 0, len(matches))
+	for i := range matches {
+		if after == nil || q.compare(keys[i], after) > 0 {
+			idx = append(idx, i)
+		}
+	}
+	slices.SortFunc(idx, func(i, j int) int { return q.compare(keys[i], keys[j]) })
+
+	page := &TaskPage{Tasks: []*models.Task{}}
+	if q.Limit > 0 && len(idx) > q.Limit {
+		idx = idx[:q.Limit]
+		page.NextCursor = keys[idx[len(idx)-1]].encode()
+	}
+	for _, i := range idx {
+		page.Tasks = append(page.Tasks, matches[i].Clone())
+	}
+	return page
+}
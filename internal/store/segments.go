@@ -0,0 +1,367 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+const (
+	segmentExt         = ".seg"
+	cursorFileName     = "cursor"
+	cursorFormat       = "%020d %020d\n" // segment, offset; fixed width so it can be rewritten in place
+	cursorSize         = 42
+	DefaultSegmentSize = 16 << 20
+)
+
+// ErrBadRecord is returned by SegmentQueue.Pop for a record it could not
+// decode. The record has been removed from the queue.
+var ErrBadRecord = errors.New("bad segment record")
+
+// SegmentOptions tunes a SegmentQueue.
+type SegmentOptions struct {
+	SyncPolicy  SyncPolicy // SyncAlways fsyncs every push and pop; anything else leaves it to the OS
+	SegmentSize int64      // bytes written to a segment before starting the next, DefaultSegmentSize if 0
+}
+
+// SegmentQueue is a FIFO of tasks on disk. Tasks are appended as JSON lines
+// to numbered segment files and read back in order; a cursor file records
+// how far reading got, and segments are deleted once fully read. Torn
+// appends at the tail are truncated on open. Reading is at least once: a
+// crash between a pop and the cursor write returns that task again.
+type SegmentQueue struct {
+	mu       sync.Mutex
+	dir      string
+	opts     SegmentOptions
+	segments []uint64 // oldest first; the last one is written to
+	w        *os.File
+	wSize    int64
+	r        *os.File
+	rd       *bufio.Reader
+	rOff     int64
+	cursor   *os.File
+	len      int
+}
+
+// OpenSegmentQueue loads (or creates) the queue rooted at dir.
+func OpenSegmentQueue(dir string, opts SegmentOptions) (*SegmentQueue, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create segment dir: %w", err)
+	}
+	q := &SegmentQueue{dir: dir, opts: opts}
+	if err := q.load(); err != nil {
+		q.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+func (q *SegmentQueue) segmentPath(id uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", id, segmentExt))
+}
+
+// load finds the segments, drops those already read, counts the tasks left
+// and opens the read and write ends.
+func (q *SegmentQueue) load() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("read segment dir: %w", err)
+	}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok {
+			continue
+		}
+		if id, err := strconv.ParseUint(name, 10, 64); err == nil {
+			q.segments = append(q.segments, id)
+		}
+	}
+	slices.Sort(q.segments)
+
+	q.cursor, err = os.OpenFile(filepath.Join(q.dir, cursorFileName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("open cursor: %w", err)
+	}
+	var readSeg uint64
+	buf := make([]byte, cursorSize)
+	if n, _ := q.cursor.ReadAt(buf, 0); n == cursorSize {
+		// An unreadable cursor means starting over from the oldest segment.
+		if _, err := fmt.Sscanf(string(buf), cursorFormat, &readSeg, &q.rOff); err != nil {
+			readSeg, q.rOff = 0, 0
+		}
+	}
+	for len(q.segments) > 0 && q.segments[0] < readSeg {
+		if err := os.Remove(q.segmentPath(q.segments[0])); err != nil {
+			return fmt.Errorf("remove read segment: %w", err)
+		}
+		q.segments = q.segments[1:]
+	}
+	if len(q.segments) == 0 || q.segments[0] != readSeg {
+		q.rOff = 0
+	}
+	if len(q.segments) == 0 {
+		q.segments = []uint64{max(readSeg, 1)}
+	}
+
+	for i, id := range q.segments {
+		from := int64(0)
+		if i == 0 {
+			from = q.rOff
+		}
+		n, good, err := countLines(q.segmentPath(id), from)
+		if err != nil {
+			return err
+		}
+		q.len += n
+		if i == len(q.segments)-1 {
+			// A partial line at the very end is a torn append.
+			if err := os.Truncate(q.segmentPath(id), good); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("truncate segment: %w", err)
+			}
+			q.wSize = good
+		}
+	}
+
+	last := q.segments[len(q.segments)-1]
+	if q.w, err = os.OpenFile(q.segmentPath(last), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644); err != nil {
+		return fmt.Errorf("open segment: %w", err)
+	}
+	if err := q.openReader(q.rOff); err != nil {
+		return err
+	}
+	return q.saveCursorLocked()
+}
+
+// countLines counts the complete lines in the file at path from offset on,
+// and returns the offset just past the last of them.
+func countLines(path string, offset int64) (n int, end int64, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("open segment: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, 0, fmt.Errorf("seek segment: %w", err)
+	}
+	reader := bufio.NewReader(f)
+	end = offset
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return n, end, nil
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("read segment: %w", err)
+		}
+		n++
+		end += int64(len(line))
+	}
+}
+
+// openReader opens the oldest segment for reading from offset.
+func (q *SegmentQueue) openReader(offset int64) error {
+	if q.r != nil {
+		q.r.Close()
+	}
+	f, err := os.Open(q.segmentPath(q.segments[0]))
+	if err != nil {
+		return fmt.Errorf("open segment: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return fmt.Errorf("seek segment: %w", err)
+	}
+	q.r, q.rd, q.rOff = f, bufio.NewReader(f), offset
+	return nil
+}
+
+// Push appends task to the queue.
+func (q *SegmentQueue) Push(task *models.Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.w == nil {
+		return errors.New("segment queue is closed")
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("encode task: %w", err)
+	}
+	data = append(data, '\n')
+	if q.wSize > 0 && q.wSize+int64(len(data)) > q.opts.SegmentSize {
+		if err := q.rollLocked(); err != nil {
+			return err
+		}
+	}
+	if _, err := q.w.Write(data); err != nil {
+		return fmt.Errorf("write segment: %w", err)
+	}
+	if q.opts.SyncPolicy == SyncAlways {
+		if err := q.w.Sync(); err != nil {
+			return fmt.Errorf("sync segment: %w", err)
+		}
+	}
+	q.wSize += int64(len(data))
+	q.len++
+	return nil
+}
+
+// rollLocked starts a new segment for writing. Callers must hold q.mu.
+func (q *SegmentQueue) rollLocked() error {
+	next := q.segments[len(q.segments)-1] + 1
+	f, err := os.OpenFile(q.segmentPath(next), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("create segment: %w", err)
+	}
+	if err := q.w.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("close segment: %w", err)
+	}
+	q.w, q.wSize = f, 0
+	q.segments = append(q.segments, next)
+	return nil
+}
+
+// Pop removes and returns the oldest task, or nil if the queue is empty. A
+// record that cannot be decoded is still removed, so one bad record cannot
+// wedge the queue: Pop then returns an error wrapping ErrBadRecord, along
+// with a task holding just the record's ID if that much could be read.
+func (q *SegmentQueue) Pop() (*models.Task, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.len == 0 || q.r == nil {
+		return nil, nil
+	}
+	for {
+		line, err := q.rd.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 && len(q.segments) > 1 {
+			// This segment is used up; move on to the next.
+			q.r.Close()
+			q.r = nil
+			if err := os.Remove(q.segmentPath(q.segments[0])); err != nil {
+				return nil, fmt.Errorf("remove read segment: %w", err)
+			}
+			q.segments = q.segments[1:]
+			if err := q.openReader(0); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read segment: %w", err)
+		}
+		q.rOff += int64(len(line))
+		q.len--
+		if err := q.saveCursorLocked(); err != nil {
+			return nil, err
+		}
+		var task models.Task
+		if err := json.Unmarshal(line, &task); err != nil {
+			var id struct {
+				ID string `json:"id"`
+			}
+			if json.Unmarshal(line, &id) != nil || id.ID == "" {
+				return nil, fmt.Errorf("%w: %w", ErrBadRecord, err)
+			}
+			return &models.Task{ID: id.ID}, fmt.Errorf("%w for task %s: %w", ErrBadRecord, id.ID, err)
+		}
+		return &task, nil
+	}
+}
+
+func (q *SegmentQueue) saveCursorLocked() error {
+	if _, err := q.cursor.WriteAt(fmt.Appendf(nil, cursorFormat, q.segments[0], q.rOff), 0); err != nil {
+		return fmt.Errorf("write cursor: %w", err)
+	}
+	if q.opts.SyncPolicy == SyncAlways {
+		if err := q.cursor.Sync(); err != nil {
+			return fmt.Errorf("sync cursor: %w", err)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of tasks in the queue.
+func (q *SegmentQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.len
+}
+
+// Scan calls fn with every task in the queue, oldest first, without
+// removing them. Records that cannot be decoded are skipped.
+func (q *SegmentQueue) Scan(fn func(*models.Task) error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, id := range q.segments {
+		if err := q.scanSegment(id, i == 0, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *SegmentQueue) scanSegment(id uint64, first bool, fn func(*models.Task) error) error {
+	f, err := os.Open(q.segmentPath(id))
+	if err != nil {
+		return fmt.Errorf("open segment: %w", err)
+	}
+	defer f.Close()
+	if first {
+		if _, err := f.Seek(q.rOff, io.SeekStart); err != nil {
+			return fmt.Errorf("seek segment: %w", err)
+		}
+	}
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read segment: %w", err)
+		}
+		var task models.Task
+		if json.Unmarshal(line, &task) != nil {
+			continue
+		}
+		if err := fn(&task); err != nil {
+			return err
+		}
+	}
+}
+
+// Close flushes and releases the queue's files.
+func (q *SegmentQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var errs []error
+	if q.w != nil {
+		errs = append(errs, q.w.Sync(), q.w.Close())
+		q.w = nil
+	}
+	if q.r != nil {
+		errs = append(errs, q.r.Close())
+		q.r = nil
+	}
+	if q.cursor != nil {
+		errs = append(errs, q.cursor.Sync(), q.cursor.Close())
+		q.cursor = nil
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+func openTestSegmentQueue(t *testing.T, dir string) *SegmentQueue {
+	t.Helper()
+	// Small segments so a few tasks span several files
+	q, err := OpenSegmentQueue(dir, SegmentOptions{SegmentSize: 256})
+	if err != nil {
+		t.Fatalf("Failed to open segment queue: %v", err)
+	}
+	return q
+}
+
+func popID(t *testing.T, q *SegmentQueue) string {
+	t.Helper()
+	task, err := q.Pop()
+	if err != nil {
+		t.Fatalf("Pop failed: %v", err)
+	}
+	if task == nil {
+		return ""
+	}
+	return task.ID
+}
+
+// TestSegmentQueueOrderAcrossRestarts tests that tasks come out in push order, including after reopening
+func TestSegmentQueueOrderAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	q := openTestSegmentQueue(t, dir)
+	for i := range 10 {
+		if err := q.Push(&models.Task{ID: fmt.Sprintf("t%d", i), Title: "Spilled"}); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+	}
+	for i := range 4 {
+		if got := popID(t, q); got != fmt.Sprintf("t%d", i) {
+			t.Fatalf("Expected t%d, got %q", i, got)
+		}
+	}
+	q.Close()
+
+	q = openTestSegmentQueue(t, dir)
+	defer q.Close()
+	if q.Len() != 6 {
+		t.Fatalf("Expected 6 tasks after reopen, got %d", q.Len())
+	}
+	var scanned []string
+	q.Scan(func(task *models.Task) error {
+		scanned = append(scanned, task.ID)
+		return nil
+	})
+	if len(scanned) != 6 || scanned[0] != "t4" {
+		t.Errorf("Unexpected scan: %v", scanned)
+	}
+	for i := 4; i < 10; i++ {
+		if got := popID(t, q); got != fmt.Sprintf("t%d", i) {
+			t.Fatalf("Expected t%d, got %q", i, got)
+		}
+	}
+	if got := popID(t, q); got != "" {
+		t.Errorf("Expected an empty queue, got %q", got)
+	}
+
+	// Read segments are removed, leaving only the one being written
+	segs, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if len(segs) != 1 {
+		t.Errorf("Expected 1 segment left, got %d", len(segs))
+	}
+}
+
+// TestSegmentQueueTornWrite tests that a partial record at the tail is dropped on open
+func TestSegmentQueueTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	q := openTestSegmentQueue(t, dir)
+	q.Push(&models.Task{ID: "whole", Title: "Whole"})
+	q.Close()
+
+	segs, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	f, err := os.OpenFile(segs[len(segs)-1], os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":"torn","ti`)
+	f.Close()
+
+	q = openTestSegmentQueue(t, dir)
+	defer q.Close()
+	if q.Len() != 1 {
+		t.Fatalf("Expected 1 task, got %d", q.Len())
+	}
+	q.Push(&models.Task{ID: "after", Title: "After"})
+	if got := popID(t, q); got != "whole" {
+		t.Errorf("Expected whole, got %q", got)
+	}
+	if got := popID(t, q); got != "after" {
+		t.Errorf("Expected after, got %q", got)
+	}
+}
+
+// TestSegmentQueueBadRecord tests that an undecodable record is removed and reported with its ID
+func TestSegmentQueueBadRecord(t *testing.T) {
+	dir := t.TempDir()
+	q := openTestSegmentQueue(t, dir)
+	q.Close()
+	segs, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err := os.WriteFile(segs[0], []byte("{\"id\":\"bad\",\"priority\":\"high\"}\nnot json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	q = openTestSegmentQueue(t, dir)
+	defer q.Close()
+	q.Push(&models.Task{ID: "good", Title: "Good"})
+	task, err := q.Pop()
+	if !errors.Is(err, ErrBadRecord) || task == nil || task.ID != "bad" {
+		t.Fatalf("Expected ErrBadRecord for task bad, got %v, %v", task, err)
+	}
+	if task, err := q.Pop(); !errors.Is(err, ErrBadRecord) || task != nil {
+		t.Fatalf("Expected ErrBadRecord without a task, got %v, %v", task, err)
+	}
+	if got := popID(t, q); got != "good" {
+		t.Errorf("Expected good, got %q", got)
+	}
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+var (
+	ErrNilTask      = errors.New("task cannot be nil")
+	ErrEmptyTaskID  = errors.New("task ID cannot be empty")
+	ErrTaskNotFound = errors.New("task not found")
+
+	ErrNilSchedule      = errors.New("schedule cannot be nil")
+	ErrEmptyScheduleID  = errors.New("schedule ID cannot be empty")
+	ErrScheduleNotFound = errors.New("schedule not found")
+
+	ErrNilDeadLetter      = errors.New("dead letter cannot be nil")
+	ErrEmptyDeadLetterID  = errors.New("dead letter ID cannot be empty")
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	ErrEmptyIdempotencyKey = errors.New("idempotency key cannot be empty")
+
+	ErrNilWorkflow      = errors.New("workflow cannot be nil")
+	ErrEmptyWorkflowID  = errors.New("workflow ID cannot be empty")
+	ErrWorkflowNotFound = errors.New("workflow not found")
+
+	ErrNilPauseState = errors.New("pause state cannot be nil")
+)
+
+// TaskStore is the persistence contract shared by every task backend.
+// Implementations must be safe for concurrent use and must hand out copies,
+// so callers never mutate stored state without going through UpdateTask.
+type TaskStore interface {
+	AddTask(ctx context.Context, task *models.Task) error
+	GetTask(ctx context.Context, id string) (*models.Task, error)
+	ListTasks(ctx context.Context) ([]*models.Task, error)
+	QueryTasks(ctx context.Context, q TaskQuery) (*TaskPage, error)
+	UpdateTask(ctx context.Context, task *models.Task) error
+	DeleteTask(ctx context.Context, id string) error
+}
+
+// ScheduleStore persists recurring task definitions.
+type ScheduleStore interface {
+	AddSchedule(ctx context.Context, schedule *models.Schedule) error
+	GetSchedule(ctx context.Context, id string) (*models.Schedule, error)
+	ListSchedules(ctx context.Context) ([]*models.Schedule, error)
+	UpdateSchedule(ctx context.Context, schedule *models.Schedule) error
+	DeleteSchedule(ctx context.Context, id string) error
+}
+
+// DeadLetterStore holds permanently failed tasks until they are requeued.
+type DeadLetterStore interface {
+	AddDeadLetter(ctx context.Context, dl *models.DeadLetter) error
+	GetDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error)
+	ListDeadLetters(ctx context.Context) ([]*models.DeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id string) error
+	// TakeDeadLetter removes and returns a dead letter in one step, so that
+	// of two concurrent takes only one gets it.
+	TakeDeadLetter(ctx context.Context, id string) (*models.DeadLetter, error)
+}
+
+// IdempotencyStore indexes task submissions by client idempotency key.
+// Expired records are ignored by ClaimIdempotencyKey and removed by
+// PurgeIdempotencyKeys.
+type IdempotencyStore interface {
+	// ClaimIdempotencyKey stores rec unless a live record for rec.Key
+	// exists, in which case that record is returned with claimed false.
+	ClaimIdempotencyKey(ctx context.Context, rec *models.IdempotencyRecord) (existing *models.IdempotencyRecord, claimed bool, err error)
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+	PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
+}
+
+// WorkflowStore persists workflow definitions. A workflow's progress lives
+// in its tasks, so workflows are written once and never updated.
+type WorkflowStore interface {
+	AddWorkflow(ctx context.Context, wf *models.Workflow) error
+	GetWorkflow(ctx context.Context, id string) (*models.Workflow, error)
+	ListWorkflows(ctx context.Context) ([]*models.Workflow, error)
+	DeleteWorkflow(ctx context.Context, id string) error
+}
+
+// PauseStore persists which tasks dispatching is paused for, so a pause
+// survives a restart. GetPauseState returns an empty state if none was saved.
+type PauseStore interface {
+	GetPauseState(ctx context.Context) (*models.PauseState, error)
+	SetPauseState(ctx context.Context, state *models.PauseState) error
+}
+
+// Store is everything the server needs from a backend.
+type Store interface {
+	TaskStore
+	ScheduleStore
+	DeadLetterStore
+	IdempotencyStore
+	WorkflowStore
+	PauseStore
+}
+
+func checkCtx(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return nil
+	}
+}
+
+func validateTask(task *models.Task) error {
+	if task == nil {
+		return ErrNilTask
+	}
+	if task.ID == "" {
+		return ErrEmptyTaskID
+	}
+	return nil
+}
+
+func validateSchedule(schedule *models.Schedule) error {
+	if schedule == nil {
+		return ErrNilSchedule
+	}
+	if schedule.ID == "" {
+		return ErrEmptyScheduleID
+	}
+	return nil
+}
+
+func validateWorkflow(wf *models.Workflow) error {
+	if wf == nil {
+		return ErrNilWorkflow
+	}
+	if wf.ID == "" {
+		return ErrEmptyWorkflowID
+	}
+	return nil
+}
+
+func validateDeadLetter(dl *models.DeadLetter) error {
+	if dl == nil {
+		return ErrNilDeadLetter
+	}
+	if dl.ID == "" {
+		return ErrEmptyDeadLetterID
+	}
+	return nil
+}
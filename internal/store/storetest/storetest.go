@@ -0,0 +1,542 @@
+// Package storetest holds the conformance suite that every store.Store
+// backend must pass. Backends call Run from their own _test.go file.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// Factory returns a fresh, empty store for a single subtest.
+type Factory func(t *testing.T) store.Store
+
+// Run executes the full conformance suite against the backend built by newStore.
+func Run(t *testing.T, newStore Factory) {
+	t.Run("AddAndGet", func(t *testing.T) { testAddAndGet(t, newStore(t)) })
+	t.Run("AddInvalid", func(t *testing.T) { testAddInvalid(t, newStore(t)) })
+	t.Run("GetMissing", func(t *testing.T) { testGetMissing(t, newStore(t)) })
+	t.Run("List", func(t *testing.T) { testList(t, newStore(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newStore(t)) })
+	t.Run("UpdateMissing", func(t *testing.T) { testUpdateMissing(t, newStore(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStore(t)) })
+	t.Run("ReturnsCopies", func(t *testing.T) { testReturnsCopies(t, newStore(t)) })
+	t.Run("CancelledContext", func(t *testing.T) { testCancelledContext(t, newStore(t)) })
+	t.Run("Concurrent", func(t *testing.T) { testConcurrent(t, newStore(t)) })
+	t.Run("Schedules", func(t *testing.T) { testSchedules(t, newStore(t)) })
+	t.Run("DeadLetters", func(t *testing.T) { testDeadLetters(t, newStore(t)) })
+	t.Run("Query", func(t *testing.T) { testQuery(t, newStore(t)) })
+	t.Run("IdempotencyKeys", func(t *testing.T) { testIdempotencyKeys(t, newStore(t)) })
+	t.Run("Workflows", func(t *testing.T) { testWorkflows(t, newStore(t)) })
+	t.Run("PauseState", func(t *testing.T) { testPauseState(t, newStore(t)) })
+}
+
+func newTask(id string) *models.Task {
+	return &models.Task{
+		ID:          id,
+		Title:       "Task " + id,
+		Description: "conformance task",
+		Duration:    1,
+		Status:      models.Pending,
+	}
+}
+
+func testAddAndGet(t *testing.T, s store.TaskStore) {
+	ctx := context.Background()
+	task := newTask("add-get")
+	if err := s.AddTask(ctx, task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	got, err := s.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if got.ID != task.ID || got.Title != task.Title || got.Status != task.Status {
+		t.Errorf("Expected %+v, got %+v", task, got)
+	}
+}
+
+func testAddInvalid(t *testing.T, s store.TaskStore) {
+	ctx := context.Background()
+	if err := s.AddTask(ctx, nil); !errors.Is(err, store.ErrNilTask) {
+		t.Errorf("Expected ErrNilTask, got %v", err)
+	}
+	if err := s.AddTask(ctx, newTask("")); !errors.Is(err, store.ErrEmptyTaskID) {
+		t.Errorf("Expected ErrEmptyTaskID, got %v", err)
+	}
+}
+
+func testGetMissing(t *testing.T, s store.TaskStore) {
+	if _, err := s.GetTask(context.Background(), "missing"); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func testList(t *testing.T, s store.TaskStore) {
+	ctx := context.Background()
+	tasks, err := s.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("Expected empty store, got %d tasks", len(tasks))
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := s.AddTask(ctx, newTask(fmt.Sprintf("list-%d", i))); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+	tasks, err = s.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Errorf("Expected 3 tasks, got %d", len(tasks))
+	}
+}
+
+func testUpdate(t *testing.T, s store.TaskStore) {
+	ctx := context.Background()
+	task := newTask("update")
+	if err := s.AddTask(ctx, task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	task.Status = models.Completed
+	task.Title = "Updated"
+	if err := s.UpdateTask(ctx, task); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+
+	got, err := s.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if got.Status != models.Completed || got.Title != "Updated" {
+		t.Errorf("Update not persisted, got %+v", got)
+	}
+}
+
+func testUpdateMissing(t *testing.T, s store.TaskStore) {
+	if err := s.UpdateTask(context.Background(), newTask("missing")); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
+
+func testDelete(t *testing.T, s store.TaskStore) {
+	ctx := context.Background()
+	task := newTask("delete")
+	if err := s.AddTask(ctx, task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	if err := s.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if _, err := s.GetTask(ctx, task.ID); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound after delete, got %v", err)
+	}
+	if err := s.DeleteTask(ctx, task.ID); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound deleting twice, got %v", err)
+	}
+}
+
+func testReturnsCopies(t *testing.T, s store.TaskStore) {
+	ctx := context.Background()
+	task := newTask("copies")
+	if err := s.AddTask(ctx, task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	task.Title = "mutated after add"
+	got, err := s.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if got.Title == "mutated after add" {
+		t.Error("Store kept a reference to the caller's task")
+	}
+
+	got.Title = "mutated after get"
+	again, err := s.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask failed: %v", err)
+	}
+	if again.Title == "mutated after get" {
+		t.Error("Store handed out a reference to its internal task")
+	}
+}
+
+func testCancelledContext(t *testing.T, s store.TaskStore) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := s.AddTask(ctx, newTask("cancelled")); !errors.Is(err, context.Canceled) {
+		t.Errorf("AddTask: expected context.Canceled, got %v", err)
+	}
+	if _, err := s.GetTask(ctx, "cancelled"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetTask: expected context.Canceled, got %v", err)
+	}
+	if _, err := s.ListTasks(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ListTasks: expected context.Canceled, got %v", err)
+	}
+	if err := s.UpdateTask(ctx, newTask("cancelled")); !errors.Is(err, context.Canceled) {
+		t.Errorf("UpdateTask: expected context.Canceled, got %v", err)
+	}
+	if err := s.DeleteTask(ctx, "cancelled"); !errors.Is(err, context.Canceled) {
+		t.Errorf("DeleteTask: expected context.Canceled, got %v", err)
+	}
+}
+
+func testConcurrent(t *testing.T, s store.TaskStore) {
+	ctx := context.Background()
+	const numTasks = 50
+	var wg sync.WaitGroup
+	wg.Add(numTasks * 2)
+	for i := 0; i < numTasks; i++ {
+		go func(i int) {
+			defer wg.Done()
+			task := newTask(fmt.Sprintf("concurrent-%d", i))
+			if err := s.AddTask(ctx, task); err != nil {
+				t.Errorf("AddTask failed: %v", err)
+				return
+			}
+			task.Status = models.Running
+			if err := s.UpdateTask(ctx, task); err != nil {
+				t.Errorf("UpdateTask failed: %v", err)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, _ = s.ListTasks(ctx)
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := s.ListTasks(ctx)
+	if err != nil {
+		t.Fatalf("ListTasks failed: %v", err)
+	}
+	if len(tasks) != numTasks {
+		t.Errorf("Expected %d tasks, got %d", numTasks, len(tasks))
+	}
+}
+
+func testSchedules(t *testing.T, s store.ScheduleStore) {
+	ctx := context.Background()
+	if err := s.AddSchedule(ctx, nil); !errors.Is(err, store.ErrNilSchedule) {
+		t.Errorf("Expected ErrNilSchedule, got %v", err)
+	}
+	if err := s.AddSchedule(ctx, &models.Schedule{}); !errors.Is(err, store.ErrEmptyScheduleID) {
+		t.Errorf("Expected ErrEmptyScheduleID, got %v", err)
+	}
+
+	schedule := &models.Schedule{ID: "nightly", Name: "Nightly", Spec: "0 2 * * *", Overlap: models.OverlapSkip}
+	if err := s.AddSchedule(ctx, schedule); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+	schedule.FireCount = 3
+	if err := s.UpdateSchedule(ctx, schedule); err != nil {
+		t.Fatalf("UpdateSchedule failed: %v", err)
+	}
+	got, err := s.GetSchedule(ctx, "nightly")
+	if err != nil {
+		t.Fatalf("GetSchedule failed: %v", err)
+	}
+	if got.Spec != "0 2 * * *" || got.FireCount != 3 {
+		t.Errorf("Unexpected schedule: %+v", got)
+	}
+
+	list, err := s.ListSchedules(ctx)
+	if err != nil || len(list) != 1 {
+		t.Errorf("Expected 1 schedule, got %d (%v)", len(list), err)
+	}
+
+	if err := s.UpdateSchedule(ctx, &models.Schedule{ID: "missing"}); !errors.Is(err, store.ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound updating missing schedule, got %v", err)
+	}
+	if err := s.DeleteSchedule(ctx, "nightly"); err != nil {
+		t.Fatalf("DeleteSchedule failed: %v", err)
+	}
+	if _, err := s.GetSchedule(ctx, "nightly"); !errors.Is(err, store.ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound after delete, got %v", err)
+	}
+}
+
+func testDeadLetters(t *testing.T, s store.DeadLetterStore) {
+	ctx := context.Background()
+	if err := s.AddDeadLetter(ctx, nil); !errors.Is(err, store.ErrNilDeadLetter) {
+		t.Errorf("Expected ErrNilDeadLetter, got %v", err)
+	}
+	if err := s.AddDeadLetter(ctx, &models.DeadLetter{}); !errors.Is(err, store.ErrEmptyDeadLetterID) {
+		t.Errorf("Expected ErrEmptyDeadLetterID, got %v", err)
+	}
+
+	task := newTask("dead")
+	task.Status = models.Failed
+	task.History = []models.Attempt{{Number: 1, Error: "boom"}}
+	dl := &models.DeadLetter{ID: task.ID, Task: task, Error: "boom"}
+	if err := s.AddDeadLetter(ctx, dl); err != nil {
+		t.Fatalf("AddDeadLetter failed: %v", err)
+	}
+	task.History[0].Error = "mutated"
+
+	got, err := s.GetDeadLetter(ctx, "dead")
+	if err != nil {
+		t.Fatalf("GetDeadLetter failed: %v", err)
+	}
+	if got.Error != "boom" || got.Task.Status != models.Failed || got.Task.History[0].Error != "boom" {
+		t.Errorf("Unexpected dead letter: %+v", got)
+	}
+
+	list, err := s.ListDeadLetters(ctx)
+	if err != nil || len(list) != 1 {
+		t.Errorf("Expected 1 dead letter, got %d (%v)", len(list), err)
+	}
+
+	taken, err := s.TakeDeadLetter(ctx, "dead")
+	if err != nil || taken.Task.ID != "dead" {
+		t.Fatalf("TakeDeadLetter failed: %+v, %v", taken, err)
+	}
+	if _, err := s.TakeDeadLetter(ctx, "dead"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound taking twice, got %v", err)
+	}
+	if err := s.AddDeadLetter(ctx, taken); err != nil {
+		t.Fatalf("AddDeadLetter failed: %v", err)
+	}
+	if err := s.DeleteDeadLetter(ctx, "dead"); err != nil {
+		t.Fatalf("DeleteDeadLetter failed: %v", err)
+	}
+	if _, err := s.GetDeadLetter(ctx, "dead"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound after delete, got %v", err)
+	}
+	if err := s.DeleteDeadLetter(ctx, "dead"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound deleting twice, got %v", err)
+	}
+}
+
+func testWorkflows(t *testing.T, s store.WorkflowStore) {
+	ctx := context.Background()
+	if err := s.AddWorkflow(ctx, nil); !errors.Is(err, store.ErrNilWorkflow) {
+		t.Errorf("Expected ErrNilWorkflow, got %v", err)
+	}
+	if err := s.AddWorkflow(ctx, &models.Workflow{}); !errors.Is(err, store.ErrEmptyWorkflowID) {
+		t.Errorf("Expected ErrEmptyWorkflowID, got %v", err)
+	}
+
+	wf := &models.Workflow{
+		ID:   "wf",
+		Name: "etl",
+		Definition: models.WorkflowStep{Chain: []models.WorkflowStep{
+			{Task: &models.TaskTemplate{Title: "Extract", Tags: []string{"etl"}}},
+			{Chord: &models.Chord{
+				Group:    []models.WorkflowStep{{Task: &models.TaskTemplate{Title: "Load"}}},
+				Callback: models.WorkflowStep{Task: &models.TaskTemplate{Title: "Report"}},
+			}},
+		}},
+		TaskIDs: []string{"a", "b", "c"},
+		Outputs: []string{"c"},
+	}
+	if err := s.AddWorkflow(ctx, wf); err != nil {
+		t.Fatalf("AddWorkflow failed: %v", err)
+	}
+	wf.Definition.Chain[0].Task.Tags[0] = "mutated"
+	wf.TaskIDs[0] = "mutated"
+
+	got, err := s.GetWorkflow(ctx, "wf")
+	if err != nil {
+		t.Fatalf("GetWorkflow failed: %v", err)
+	}
+	if got.TaskIDs[0] != "a" || got.Definition.Chain[0].Task.Tags[0] != "etl" || got.Definition.Chain[1].Chord.Callback.Task.Title != "Report" {
+		t.Errorf("Unexpected workflow: %+v", got)
+	}
+
+	list, err := s.ListWorkflows(ctx)
+	if err != nil || len(list) != 1 {
+		t.Errorf("Expected 1 workflow, got %d (%v)", len(list), err)
+	}
+
+	if err := s.DeleteWorkflow(ctx, "wf"); err != nil {
+		t.Fatalf("DeleteWorkflow failed: %v", err)
+	}
+	if _, err := s.GetWorkflow(ctx, "wf"); !errors.Is(err, store.ErrWorkflowNotFound) {
+		t.Errorf("Expected ErrWorkflowNotFound after delete, got %v", err)
+	}
+	if err := s.DeleteWorkflow(ctx, "wf"); !errors.Is(err, store.ErrWorkflowNotFound) {
+		t.Errorf("Expected ErrWorkflowNotFound deleting twice, got %v", err)
+	}
+}
+
+func testPauseState(t *testing.T, s store.PauseStore) {
+	ctx := context.Background()
+	got, err := s.GetPauseState(ctx)
+	if err != nil || got.Global || len(got.Types) != 0 {
+		t.Fatalf("Expected an empty pause state, got %+v, %v", got, err)
+	}
+	if err := s.SetPauseState(ctx, nil); !errors.Is(err, store.ErrNilPauseState) {
+		t.Errorf("Expected ErrNilPauseState, got %v", err)
+	}
+
+	state := &models.PauseState{Global: true, Types: []string{"echo"}}
+	if err := s.SetPauseState(ctx, state); err != nil {
+		t.Fatalf("SetPauseState failed: %v", err)
+	}
+	state.Types[0] = "mutated"
+	got, err = s.GetPauseState(ctx)
+	if err != nil || !got.Global || len(got.Types) != 1 || got.Types[0] != "echo" {
+		t.Errorf("Unexpected pause state: %+v, %v", got, err)
+	}
+
+	if err := s.SetPauseState(ctx, &models.PauseState{}); err != nil {
+		t.Fatalf("SetPauseState failed: %v", err)
+	}
+	if got, _ := s.GetPauseState(ctx); got.Global || len(got.Types) != 0 {
+		t.Errorf("Expected pauses cleared, got %+v", got)
+	}
+}
+
+func ids(tasks []*models.Task) []string {
+	out := make([]string, len(tasks))
+	for i, t := range tasks {
+		out[i] = t.ID
+	}
+	return out
+}
+
+func testQuery(t *testing.T, s store.TaskStore) {
+	ctx := context.Background()
+	base := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, spec := range []struct {
+		status   models.Status
+		typ      string
+		priority int
+		tags     []string
+	}{
+		{models.Pending, "echo", 1, []string{"billing"}},
+		{models.Completed, "sleep", 5, nil},
+		{models.Pending, "sleep", 9, []string{"billing", "urgent"}},
+		{models.Failed, "echo", 5, nil},
+		{models.Pending, "echo", 0, []string{"urgent"}},
+	} {
+		task := newTask(fmt.Sprintf("q-%d", i))
+		task.Status = spec.status
+		task.Type = spec.typ
+		task.Priority = spec.priority
+		task.Tags = spec.tags
+		task.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := s.AddTask(ctx, task); err != nil {
+			t.Fatalf("AddTask failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    store.TaskQuery
+		want string
+	}{
+		{"default order", store.TaskQuery{}, "q-0 q-1 q-2 q-3 q-4"},
+		{"status", store.TaskQuery{Statuses: []models.Status{models.Pending}}, "q-0 q-2 q-4"},
+		{"type", store.TaskQuery{Types: []string{"echo"}, Desc: true}, "q-4 q-3 q-0"},
+		{"tag", store.TaskQuery{Tag: "urgent"}, "q-2 q-4"},
+		{"created window", store.TaskQuery{CreatedAfter: base, CreatedBefore: base.Add(3 * time.Minute)}, "q-1 q-2"},
+		{"priority with id tiebreak", store.TaskQuery{Sort: store.SortPriority, Desc: true}, "q-2 q-3 q-1 q-0 q-4"},
+	}
+	for _, tt := range tests {
+		page, err := s.QueryTasks(ctx, tt.q)
+		if err != nil {
+			t.Fatalf("%s: QueryTasks failed: %v", tt.name, err)
+		}
+		if got := strings.Join(ids(page.Tasks), " "); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+		if page.NextCursor != "" {
+			t.Errorf("%s: expected no next cursor without a limit", tt.name)
+		}
+	}
+
+	// Walk every page; results must be complete and in order
+	q := store.TaskQuery{Sort: store.SortPriority, Limit: 2}
+	var walked []string
+	for pages := 0; ; pages++ {
+		page, err := s.QueryTasks(ctx, q)
+		if err != nil {
+			t.Fatalf("QueryTasks failed: %v", err)
+		}
+		walked = append(walked, ids(page.Tasks)...)
+		if page.NextCursor == "" {
+			break
+		}
+		if pages > 5 {
+			t.Fatal("pagination did not terminate")
+		}
+		q.Cursor = page.NextCursor
+	}
+	if got := strings.Join(walked, " "); got != "q-4 q-0 q-1 q-3 q-2" {
+		t.Errorf("Expected paged walk q-4 q-0 q-1 q-3 q-2, got %s", got)
+	}
+
+	if _, err := s.QueryTasks(ctx, store.TaskQuery{Cursor: "not-a-cursor"}); !errors.Is(err, store.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+	first, _ := s.QueryTasks(ctx, store.TaskQuery{Limit: 1})
+	if _, err := s.QueryTasks(ctx, store.TaskQuery{Sort: store.SortTitle, Cursor: first.NextCursor}); !errors.Is(err, store.ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor reusing a cursor with another sort, got %v", err)
+	}
+	if _, err := s.QueryTasks(ctx, store.TaskQuery{Sort: "color"}); !errors.Is(err, store.ErrInvalidQuery) {
+		t.Errorf("Expected ErrInvalidQuery for unknown sort, got %v", err)
+	}
+}
+
+func testIdempotencyKeys(t *testing.T, s store.IdempotencyStore) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := func(taskID string, at time.Time) *models.IdempotencyRecord {
+		return &models.IdempotencyRecord{Key: "k1", TaskID: taskID, RequestHash: "h", CreatedAt: at, ExpiresAt: at.Add(time.Hour)}
+	}
+
+	if _, _, err := s.ClaimIdempotencyKey(ctx, &models.IdempotencyRecord{}); !errors.Is(err, store.ErrEmptyIdempotencyKey) {
+		t.Errorf("Expected ErrEmptyIdempotencyKey, got %v", err)
+	}
+	first := record("first", now)
+	got, claimed, err := s.ClaimIdempotencyKey(ctx, first)
+	if err != nil || !claimed {
+		t.Fatalf("Expected first claim to succeed, got %v, %v", claimed, err)
+	}
+	// The store keeps its own copy
+	if got == first {
+		t.Error("Expected a copy of the claimed record, got the caller's")
+	}
+	first.TaskID, got.TaskID = "changed", "changed"
+	existing, claimed, err := s.ClaimIdempotencyKey(ctx, record("second", now.Add(time.Minute)))
+	if err != nil || claimed || existing.TaskID != "first" {
+		t.Errorf("Expected live key to return the original record, got %+v, %v, %v", existing, claimed, err)
+	}
+
+	// After the window the key can be claimed again
+	if _, claimed, _ := s.ClaimIdempotencyKey(ctx, record("third", now.Add(2*time.Hour))); !claimed {
+		t.Error("Expected expired key to be claimable")
+	}
+
+	if err := s.DeleteIdempotencyKey(ctx, "k1"); err != nil {
+		t.Fatalf("DeleteIdempotencyKey failed: %v", err)
+	}
+	if _, claimed, _ := s.ClaimIdempotencyKey(ctx, record("fourth", now)); !claimed {
+		t.Error("Expected deleted key to be claimable")
+	}
+
+	n, err := s.PurgeIdempotencyKeys(ctx, now.Add(2*time.Hour))
+	if err != nil || n != 1 {
+		t.Errorf("Expected 1 purged key, got %d (%v)", n, err)
+	}
+	if _, claimed, _ := s.ClaimIdempotencyKey(ctx, record("fifth", now)); !claimed {
+		t.Error("Expected purged key to be claimable")
+	}
+}
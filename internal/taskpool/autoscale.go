@@ -0,0 +1,269 @@
+package taskpool
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/events"
+	"github.com/shayanmkpr/task-pool/internal/logger"
+)
+
+var ErrInvalidAutoscale = errors.New("invalid autoscale config")
+
+// Autoscaler defaults, used for zero fields of AutoscaleConfig.
+const (
+	DefaultAutoscaleInterval = time.Second
+	DefaultBacklogPerWorker  = 1
+	DefaultMaxQueueWait      = time.Second
+	DefaultIdleUtilization   = 0.5
+	DefaultScaleDownDelay    = 30 * time.Second
+	DefaultScaleUpCooldown   = 5 * time.Second
+	DefaultScaleDownCooldown = 30 * time.Second
+)
+
+// Reasons reported with a scaling decision.
+const (
+	ScaleBelowMin  = "below_min"
+	ScaleAboveMax  = "above_max"
+	ScaleBacklog   = "backlog"
+	ScaleQueueWait = "queue_wait"
+	ScaleIdle      = "idle"
+)
+
+// AutoscaleConfig bounds and tunes an Autoscaler.
+type AutoscaleConfig struct {
+	MinWorkers int
+	MaxWorkers int
+	Interval   time.Duration // how often load is sampled
+
+	// Scale up when more than BacklogPerWorker tasks are queued per worker
+	// or the oldest queued task has waited longer than MaxQueueWait.
+	BacklogPerWorker int
+	MaxQueueWait     time.Duration
+
+	// Scale down once nothing is queued and utilization has stayed below
+	// IdleUtilization for ScaleDownDelay.
+	IdleUtilization float64
+	ScaleDownDelay  time.Duration
+
+	// Minimum time after any resize before the next scale up or down.
+	ScaleUpCooldown   time.Duration
+	ScaleDownCooldown time.Duration
+}
+
+func (c *AutoscaleConfig) setDefaults() {
+	if c.Interval <= 0 {
+		c.Interval = DefaultAutoscaleInterval
+	}
+	if c.BacklogPerWorker <= 0 {
+		c.BacklogPerWorker = DefaultBacklogPerWorker
+	}
+	if c.MaxQueueWait <= 0 {
+		c.MaxQueueWait = DefaultMaxQueueWait
+	}
+	if c.IdleUtilization <= 0 {
+		c.IdleUtilization = DefaultIdleUtilization
+	}
+	if c.ScaleDownDelay <= 0 {
+		c.ScaleDownDelay = DefaultScaleDownDelay
+	}
+	if c.ScaleUpCooldown <= 0 {
+		c.ScaleUpCooldown = DefaultScaleUpCooldown
+	}
+	if c.ScaleDownCooldown <= 0 {
+		c.ScaleDownCooldown = DefaultScaleDownCooldown
+	}
+}
+
+func (c *AutoscaleConfig) validate() error {
+	switch {
+	case c.MinWorkers < 0:
+		return fmt.Errorf("%w: min workers must not be negative", ErrInvalidAutoscale)
+	case c.MaxWorkers < 1 || c.MaxWorkers > MaxWorkers:
+		return fmt.Errorf("%w: max workers must be between 1 and %d", ErrInvalidAutoscale, MaxWorkers)
+	case c.MinWorkers > c.MaxWorkers:
+		return fmt.Errorf("%w: min workers %d exceeds max workers %d", ErrInvalidAutoscale, c.MinWorkers, c.MaxWorkers)
+	case c.IdleUtilization >= 1:
+		return fmt.Errorf("%w: idle utilization must be below 1", ErrInvalidAutoscale)
+	}
+	return nil
+}
+
+// Autoscaler periodically resizes a WorkerManager between MinWorkers and
+// MaxWorkers based on queue depth, queue wait and worker utilization.
+//
+// The thresholds for growing and shrinking are deliberately far apart so the
+// pool does not flap: it grows only while tasks are backing up, and shrinks
+// only after the queue has stayed empty with workers mostly idle for
+// ScaleDownDelay, and then no further than leaves utilization halfway
+// between IdleUtilization and full. Each resize starts a cooldown.
+type Autoscaler struct {
+	pool    *TaskPool
+	workers *WorkerManager
+	logger  *logger.Logger
+	cfg     AutoscaleConfig
+	now     func() time.Time
+
+	mu        sync.Mutex
+	lastScale time.Time // zero until the first resize
+	lowSince  time.Time // zero while the pool is not idle
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewAutoscaler(pool *TaskPool, workers *WorkerManager, cfg AutoscaleConfig, logger *logger.Logger) (*Autoscaler, error) {
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &Autoscaler{
+		pool:    pool,
+		workers: workers,
+		logger:  logger,
+		cfg:     cfg,
+		now:     time.Now,
+	}, nil
+}
+
+// Start samples the pool every Interval until Stop. It must be called after
+// the workers are initiated.
+func (a *Autoscaler) Start() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stop != nil {
+		return
+	}
+	a.stop = make(chan struct{})
+	a.done = make(chan struct{})
+	go a.run(a.stop, a.done)
+}
+
+// Stop halts sampling and waits for an in-flight resize to finish. The
+// worker pool keeps its current size.
+func (a *Autoscaler) Stop() {
+	a.mu.Lock()
+	stop, done := a.stop, a.done
+	a.stop = nil
+	a.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (a *Autoscaler) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			a.evaluate()
+		}
+	}
+}
+
+// evaluate samples the pool once and resizes it if needed. It returns the
+// decision taken, if any.
+func (a *Autoscaler) evaluate() (events.Scaling, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	queue := a.pool.Stats()
+	workers := a.workers.Stats()
+	decision := events.Scaling{
+		From:       workers.Count,
+		Queued:     queue.Queued + queue.Overflow - queue.Paused - queue.Limited, // workers cannot help with held back tasks
+		OldestWait: queue.OldestWait,
+		Busy:       workers.Busy,
+	}
+	if workers.Count > 0 {
+		decision.Utilization = float64(workers.Busy) / float64(workers.Count)
+	}
+
+	decision.To, decision.Reason = a.decideLocked(now, decision)
+	if decision.To == decision.From {
+		return events.Scaling{}, false
+	}
+
+	if _, err := a.workers.Resize(decision.To); err != nil {
+		a.logger.Error("autoscaler failed to resize workers", "from", decision.From, "to", decision.To, "error", err)
+		return events.Scaling{}, false
+	}
+	a.lastScale = now
+	a.lowSince = time.Time{}
+	a.logger.Info("autoscaler resized workers",
+		"from", decision.From,
+		"to", decision.To,
+		"reason", decision.Reason,
+		"queued", decision.Queued,
+		"oldest_wait", decision.OldestWait.Std().String(),
+		"busy", decision.Busy,
+		"utilization", decision.Utilization,
+	)
+	a.pool.Events.Publish(events.Event{Type: events.WorkerScale, Scale: &decision})
+	return decision, true
+}
+
+// decideLocked returns the worker count the pool should have and why.
+// Returning d.From means no change. Callers must hold a.mu.
+func (a *Autoscaler) decideLocked(now time.Time, d events.Scaling) (int, string) {
+	cfg := a.cfg
+	current := d.From
+
+	// Bounds are enforced regardless of cooldowns, e.g. after a manual
+	// resize through the admin API.
+	if current < cfg.MinWorkers {
+		return cfg.MinWorkers, ScaleBelowMin
+	}
+	if current > cfg.MaxWorkers {
+		return cfg.MaxWorkers, ScaleAboveMax
+	}
+	sinceScale := now.Sub(a.lastScale)
+
+	var reason string
+	switch {
+	case d.Queued > current*cfg.BacklogPerWorker:
+		reason = ScaleBacklog
+	case d.Queued > 0 && d.OldestWait.Std() > cfg.MaxQueueWait:
+		reason = ScaleQueueWait
+	}
+	if reason != "" {
+		a.lowSince = time.Time{}
+		if current >= cfg.MaxWorkers || sinceScale < cfg.ScaleUpCooldown {
+			a.logger.Debug("autoscaler holding", "reason", reason, "workers", current, "queued", d.Queued)
+			return current, ""
+		}
+		// Enough workers for what is running plus the backlog.
+		want := d.Busy + (d.Queued+cfg.BacklogPerWorker-1)/cfg.BacklogPerWorker
+		return min(max(want, current+1), cfg.MaxWorkers), reason
+	}
+
+	if d.Queued > 0 || current <= cfg.MinWorkers || d.Utilization >= cfg.IdleUtilization {
+		a.lowSince = time.Time{}
+		return current, ""
+	}
+	if a.lowSince.IsZero() {
+		a.lowSince = now
+	}
+	if now.Sub(a.lowSince) < cfg.ScaleDownDelay || sinceScale < cfg.ScaleDownCooldown {
+		return current, ""
+	}
+	// Shrink at most by half, leaving utilization between the idle
+	// threshold and full so the next sample does not scale straight back.
+	target := (cfg.IdleUtilization + 1) / 2
+	want := int(math.Ceil(float64(d.Busy) / target))
+	return max(want, current/2, cfg.MinWorkers), ScaleIdle
+}
+
+// Config returns the autoscaler's effective configuration.
+func (a *Autoscaler) Config() AutoscaleConfig {
+	return a.cfg
+}
@@ -0,0 +1,128 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/events"
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestAutoscalerScalesWithLoad tests growing on backlog within cooldowns and bounds, then shrinking once idle
+func TestAutoscalerScalesWithLoad(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewTestLogger()
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(20, st)
+	release := make(chan struct{})
+	pool.Handlers.Register("hold", func(ctx context.Context, task *models.Task) (any, error) {
+		<-release
+		return nil, nil
+	})
+	manager := NewWorkerManager(0, st)
+	manager.InitiateWorkers(pool)
+	defer manager.ForceStopWorkers()
+
+	a, err := NewAutoscaler(pool, manager, AutoscaleConfig{MinWorkers: 1, MaxWorkers: 4, MaxQueueWait: time.Hour}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := newFakeClock()
+	a.now = clock.now
+	sub, _, _ := pool.Events.Subscribe(0)
+	defer sub.Close()
+
+	evaluate := func(wantTo int, wantReason string) {
+		t.Helper()
+		time.Sleep(20 * time.Millisecond) // let workers pick up and mark themselves busy
+		d, changed := a.evaluate()
+		if wantReason == "" {
+			if changed {
+				t.Fatalf("Expected no change, got %+v", d)
+			}
+			return
+		}
+		if !changed || d.To != wantTo || d.Reason != wantReason {
+			t.Fatalf("Expected %s to %d, got %+v (changed %v)", wantReason, wantTo, d, changed)
+		}
+		if got := manager.Stats().Count; got != wantTo {
+			t.Fatalf("Expected %d workers, got %d", wantTo, got)
+		}
+	}
+	submitted := 0
+	submit := func(n int) {
+		for range n {
+			submitted++
+			task := &models.Task{ID: fmt.Sprintf("held-%d", submitted), Title: "Held", Type: "hold"}
+			if _, err := pool.AddTask(ctx, log, task); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	evaluate(1, ScaleBelowMin)
+
+	// One running, two queued: held back by the cooldown, then scaled to fit
+	submit(3)
+	evaluate(0, "")
+	clock.advance(DefaultScaleUpCooldown)
+	evaluate(3, ScaleBacklog)
+
+	// A larger backlog is capped at the maximum
+	submit(4)
+	clock.advance(DefaultScaleUpCooldown)
+	evaluate(4, ScaleBacklog)
+
+	// Once idle the pool waits out the delay, then shrinks by at most half
+	close(release)
+	for pool.Stats().Running+pool.Stats().Queued > 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	evaluate(0, "")
+	clock.advance(DefaultScaleDownDelay)
+	evaluate(2, ScaleIdle)
+	evaluate(0, "")
+	clock.advance(DefaultScaleDownCooldown)
+	evaluate(1, ScaleIdle)
+	clock.advance(DefaultScaleDownCooldown)
+	evaluate(0, "") // at the minimum
+
+	var got []string
+	for len(got) < 5 {
+		select {
+		case e := <-sub.C:
+			if e.Type == events.WorkerScale {
+				got = append(got, fmt.Sprintf("%d>%d %s", e.Scale.From, e.Scale.To, e.Scale.Reason))
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected 5 scale events, got %v", got)
+		}
+	}
+	want := fmt.Sprint([]string{"0>1 below_min", "1>3 backlog", "3>4 backlog", "4>2 idle", "2>1 idle"})
+	if fmt.Sprint(got) != want {
+		t.Errorf("Expected events %s, got %v", want, got)
+	}
+}
+
+// TestAutoscaleConfigValidation tests that inconsistent bounds are rejected
+func TestAutoscaleConfigValidation(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st)
+	manager := NewWorkerManager(0, st)
+	for _, cfg := range []AutoscaleConfig{
+		{MinWorkers: 4, MaxWorkers: 2},
+		{MinWorkers: -1, MaxWorkers: 2},
+		{MaxWorkers: 0},
+		{MaxWorkers: MaxWorkers + 1},
+		{MaxWorkers: 2, IdleUtilization: 1},
+	} {
+		if _, err := NewAutoscaler(pool, manager, cfg, logger.NewTestLogger()); !errors.Is(err, ErrInvalidAutoscale) {
+			t.Errorf("%+v: expected ErrInvalidAutoscale, got %v", cfg, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// MaxRetryAfter caps RetryAfter, and is what it returns for a full queue
+// that has not drained at all lately.
+const MaxRetryAfter = time.Minute
+
+const (
+	drainSamples = 64          // dispatches remembered per queue and tenant
+	drainWindow  = time.Minute // older dispatches say nothing about the current rate
+)
+
+// drainRate remembers when the most recent tasks left a queue (or a
+// tenant's share of the queues) to estimate when the next one will.
+type drainRate struct {
+	times [drainSamples]time.Time // ring buffer
+	next  int
+}
+
+func (d *drainRate) record(at time.Time) {
+	d.times[d.next] = at
+	d.next = (d.next + 1) % drainSamples
+}
+
+// wait estimates how long until the next task leaves, as the average time
+// per dispatch over the window. Counting up to now rather than up to the
+// last dispatch makes the estimate grow while nothing is draining.
+func (d *drainRate) wait(now time.Time) time.Duration {
+	var (
+		n      int
+		oldest time.Time
+	)
+	for _, at := range d.times {
+		if at.IsZero() || now.Sub(at) > drainWindow {
+			continue
+		}
+		n++
+		if oldest.IsZero() || at.Before(oldest) {
+			oldest = at
+		}
+	}
+	if n == 0 {
+		return MaxRetryAfter
+	}
+	return now.Sub(oldest) / time.Duration(n)
+}
+
+// roomLocked wakes submissions waiting in AddTaskWait for queue or quota
+// room. Callers must hold p.mu.
+func (p *TaskPool) roomLocked() {
+	close(p.room)
+	p.room = make(chan struct{})
+}
+
+// reserveWait is reserve, retried whenever room may have been made until
+// it succeeds, maxWait passes or ctx ends.
+func (p *TaskPool) reserveWait(ctx context.Context, slots map[slot]int, maxWait time.Duration) error {
+	var timeout <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		p.mu.Lock()
+		err := p.reserveLocked(slots)
+		room := p.room
+		p.mu.Unlock()
+		if err == nil || maxWait <= 0 || !errors.Is(err, ErrTaskQueueFull) {
+			return err
+		}
+		select {
+		case <-room:
+		case <-timeout:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// RetryAfter estimates how long a client turned away with ErrTaskQueueFull
+// should wait before submitting task again, from how fast its queue, or its
+// tenant's tasks if the tenant is over quota, have been draining. The
+// result is between one second and MaxRetryAfter.
+func (p *TaskPool) RetryAfter(task *models.Task) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	var wait time.Duration
+	if q := p.queueFor(task); q.overflow == nil && q.Len()+q.reserved >= p.capacityOf(q) {
+		wait = q.drained.wait(now)
+	}
+	if t, ok := p.tenants.state[tenantOf(task)]; ok && t.limits.Quota > 0 && t.queued+t.reserved >= t.limits.Quota {
+		wait = max(wait, t.drained.wait(now))
+	}
+	return min(max(wait, time.Second), MaxRetryAfter)
+}
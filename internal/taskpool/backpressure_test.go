@@ -0,0 +1,94 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestAddTaskWait tests that a waiting submission gets in once a worker takes a task, and gives up at max wait or when its context ends
+func TestAddTaskWait(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewTestLogger()
+	pool := NewTaskPool(1, store.NewMemoryStore())
+	addWithPriority(t, pool, "first", 0)
+
+	added := make(chan error, 1)
+	go func() {
+		_, err := pool.AddTaskWait(ctx, log, &models.Task{ID: "waiting", Title: "Waiting"}, 5*time.Second)
+		added <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case err := <-added:
+		t.Fatalf("Expected the submission to wait for room, got %v", err)
+	default:
+	}
+	pool.mu.Lock()
+	pool.popLocked()
+	pool.mu.Unlock()
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Fatalf("Expected the submission to be accepted, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Submission was not woken when room was made")
+	}
+
+	start := time.Now()
+	if _, err := pool.AddTaskWait(ctx, log, &models.Task{ID: "late", Title: "Late"}, 50*time.Millisecond); !errors.Is(err, ErrTaskQueueFull) {
+		t.Errorf("Expected ErrTaskQueueFull after the wait, got %v", err)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("Expected to wait 50ms, waited %v", waited)
+	}
+
+	cancelled, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.AddTaskWait(cancelled, log, &models.Task{ID: "gone", Title: "Gone"}, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the request context's error, got %v", err)
+	}
+}
+
+// TestRetryAfter tests that the retry estimate follows how fast the queue has been draining
+func TestRetryAfter(t *testing.T) {
+	clock := newFakeClock()
+	pool := NewTaskPool(3, store.NewMemoryStore())
+	pool.now = clock.now
+	for i := range 3 {
+		addWithPriority(t, pool, fmt.Sprintf("t%d", i), 0)
+	}
+	task := &models.Task{Title: "Rejected", Queue: DefaultQueue}
+
+	// Nothing has drained yet: a stuck queue gets the longest estimate
+	if got := pool.RetryAfter(task); got != MaxRetryAfter {
+		t.Errorf("Expected %v for a queue that is not draining, got %v", MaxRetryAfter, got)
+	}
+
+	// Three dispatches over 12s: one every 4s
+	for range 3 {
+		pool.popLocked()
+		addWithPriority(t, pool, fmt.Sprintf("r%d", clock.now().Unix()), 0)
+		clock.advance(4 * time.Second)
+	}
+	if got := pool.RetryAfter(task); got != 4*time.Second {
+		t.Errorf("Expected 4s, got %v", got)
+	}
+
+	// The estimate grows while the queue stalls
+	clock.advance(12 * time.Second)
+	if got := pool.RetryAfter(task); got != 8*time.Second {
+		t.Errorf("Expected 8s, got %v", got)
+	}
+	clock.advance(time.Hour)
+	if got := pool.RetryAfter(task); got != MaxRetryAfter {
+		t.Errorf("Expected %v once the samples are stale, got %v", MaxRetryAfter, got)
+	}
+}
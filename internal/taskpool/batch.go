@@ -0,0 +1,100 @@
+package taskpool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// BatchError reports which task of a batch was rejected.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string { return fmt.Sprintf("task %d: %v", e.Index, e.Err) }
+func (e *BatchError) Unwrap() error { return e.Err }
+
+// AddBatch adds every task or none of them. Queue capacity and tenant quota
+// for all the tasks that are due now are claimed up front, so a batch either
+// fits entirely or fails with ErrTaskQueueFull without touching the store. A
+// failed store write rolls back the tasks already written. Tasks may depend
+// on other tasks in the same batch, in any order, as long as they form no
+// cycle.
+func (p *TaskPool) AddBatch(ctx context.Context, logger *logger.Logger, tasks []*models.Task) error {
+	byID := make(map[string]*models.Task, len(tasks))
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+
+	delayed := make([]bool, len(tasks))
+	immediate := 0
+	slots := make(map[slot]int)
+	var blocked []string
+	for i, task := range tasks {
+		d, err := p.prepare(task)
+		if err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+		if err := p.checkDependencies(ctx, task, byID); err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+		delayed[i] = d
+		switch {
+		case len(task.DependsOn) > 0:
+			blocked = append(blocked, task.ID)
+		case !d:
+			immediate++
+			slots[slot{task.Queue, task.Tenant}]++
+		}
+	}
+	if i := findCycle(tasks); i >= 0 {
+		return &BatchError{Index: i, Err: ErrDependencyCycle}
+	}
+
+	if err := p.reserve(slots); err != nil {
+		logger.Info("task queue cannot hold batch", "tasks", immediate, "error", err)
+		return err
+	}
+
+	for i, task := range tasks {
+		switch {
+		case len(task.DependsOn) > 0:
+			task.Status = models.Blocked
+		case delayed[i]:
+			task.Status = models.Scheduled
+		default:
+			task.Status = models.Pending
+		}
+		if err := p.Store.AddTask(ctx, task); err != nil {
+			for _, written := range tasks[:i] {
+				if err := p.Store.DeleteTask(context.Background(), written.ID); err != nil {
+					logger.Error("failed to roll back batch task", "task_id", written.ID, "error", err)
+				}
+			}
+			p.release(slots)
+			return &BatchError{Index: i, Err: fmt.Errorf("failed to store task: %w", err)}
+		}
+	}
+
+	p.mu.Lock()
+	p.releaseLocked(slots)
+	for i, task := range tasks {
+		switch {
+		case len(task.DependsOn) > 0:
+			p.blockLocked(task)
+		case delayed[i]:
+			p.scheduleLocked(task, *task.RunAt)
+		default:
+			p.admitLocked(task)
+		}
+		// Published under the lock, before a worker or resolve can move the task on.
+		p.publish(task, 0)
+	}
+	p.mu.Unlock()
+
+	p.resolve(blocked)
+	return nil
+}
@@ -0,0 +1,64 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+func batchOf(n int) []*models.Task {
+	tasks := make([]*models.Task, n)
+	for i := range tasks {
+		tasks[i] = &models.Task{ID: fmt.Sprintf("batch-%d", i), Title: "Batch"}
+	}
+	return tasks
+}
+
+// TestAddBatchAllOrNothing tests that a batch that does not fit leaves no trace
+func TestAddBatchAllOrNothing(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(3, st)
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	if err := pool.AddBatch(ctx, log, batchOf(4)); !errors.Is(err, ErrTaskQueueFull) {
+		t.Fatalf("Expected ErrTaskQueueFull, got %v", err)
+	}
+	if tasks, _ := st.ListTasks(ctx); len(tasks) != 0 || pool.Len() != 0 {
+		t.Fatalf("Expected nothing stored or queued, got %d stored, %d queued", len(tasks), pool.Len())
+	}
+
+	// Delayed tasks do not need queue slots
+	tasks := batchOf(4)
+	runAt := time.Now().Add(time.Hour)
+	tasks[3].RunAt = &runAt
+	if err := pool.AddBatch(ctx, log, tasks); err != nil {
+		t.Fatalf("AddBatch failed: %v", err)
+	}
+	if pool.Len() != 3 || pool.Scheduled() != 1 {
+		t.Errorf("Expected 3 queued and 1 scheduled, got %d and %d", pool.Len(), pool.Scheduled())
+	}
+}
+
+// TestAddBatchInvalidItem tests that the offending index is reported
+func TestAddBatchInvalidItem(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(5, st, WithTimeouts(0, time.Minute))
+
+	tasks := batchOf(3)
+	tasks[1].Timeout = models.Duration(time.Hour)
+	err := pool.AddBatch(context.Background(), logger.NewTestLogger(), tasks)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrInvalidTask) {
+		t.Fatalf("Expected BatchError for index 1, got %v", err)
+	}
+	if pool.Len() != 0 {
+		t.Errorf("Expected nothing queued, got %d", pool.Len())
+	}
+}
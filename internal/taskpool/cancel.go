@@ -0,0 +1,116 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+var (
+	ErrTaskCancelled = errors.New("task cancelled")
+	ErrTaskFinished  = errors.New("task already finished")
+)
+
+// Cancel stops a task wherever it currently is. Queued, scheduled, blocked
+// and retrying tasks are marked cancelled immediately. Running tasks have their context
+// cancelled and the worker records the final status once the handler
+// returns, so the returned copy may still read "running". Tasks that have
+// already reached a terminal state yield ErrTaskFinished.
+func (p *TaskPool) Cancel(ctx context.Context, id string) (*models.Task, error) {
+	task, cancelled, err := p.cancel(ctx, id)
+	if err != nil || !cancelled {
+		return task, err
+	}
+	// The task is no longer in any of the pool's structures, so it can be
+	// saved without holding p.mu.
+	if err := p.Store.UpdateTask(ctx, task); err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+	p.publish(task, 0)
+	p.notifyFinished(task)
+	return task.Clone(), nil
+}
+
+// cancel takes the task out of wherever it waits and marks it cancelled,
+// reporting true, for Cancel to save. A running task is only signalled and
+// returned as stored. The store is only read without p.mu.
+func (p *TaskPool) cancel(ctx context.Context, id string) (*models.Task, bool, error) {
+	for {
+		p.mu.Lock()
+		task, ok := p.removeWaitingLocked(id)
+		spilled := p.spilled[id]
+		cancel, running := p.running[id]
+		if running {
+			cancel(ErrTaskCancelled)
+		}
+		p.mu.Unlock()
+		if ok {
+			return markCancelled(task), true, nil
+		}
+
+		task, err := p.Store.GetTask(ctx, id)
+		if err != nil {
+			return nil, false, err
+		}
+		if spilled {
+			p.mu.Lock()
+			// It may have been moved back into memory while the lock was
+			// released, in which case the next pass finds it there.
+			if spilled = p.spilled[id]; spilled {
+				p.unspillLocked(task)
+				p.roomLocked()
+			}
+			p.mu.Unlock()
+			if spilled {
+				return markCancelled(task), true, nil
+			}
+			continue
+		}
+		switch {
+		case running:
+			return task, false, nil
+		case task.Status.Terminal():
+			return task, false, ErrTaskFinished
+		}
+		// Accepted by the store but not yet queued (AddTask in flight).
+		return task, false, fmt.Errorf("task %s is not cancellable in state %s", id, task.Status)
+	}
+}
+
+// removeWaitingLocked takes the task with the given ID out of the queues,
+// the scheduler or the blocked set. Callers must hold p.mu.
+func (p *TaskPool) removeWaitingLocked(id string) (*models.Task, bool) {
+	for _, q := range p.queues {
+		task, ok := q.remove(id)
+		if !ok {
+			task, ok = p.removeHeldLocked(q, id)
+		}
+		if ok {
+			t := p.tenantLocked(tenantOf(task))
+			t.queued--
+			p.forgetIdleLocked(t)
+			if q.spilled > 0 {
+				p.kickRefillLocked()
+			}
+			p.roomLocked()
+			return task, true
+		}
+	}
+	if task, ok := p.unscheduleLocked(id); ok {
+		task.NextAttemptAt = nil
+		return task, true
+	}
+	if task, ok := p.deps.blocked[id]; ok {
+		p.unblockLocked(task)
+		return task, true
+	}
+	return nil, false
+}
+
+func markCancelled(task *models.Task) *models.Task {
+	task.Status = models.Cancelled
+	task.Error = ErrTaskCancelled.Error()
+	return task
+}
@@ -0,0 +1,99 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestCancelQueuedTask tests that a pending task is removed from the queue
+func TestCancelQueuedTask(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	ctx := context.Background()
+
+	task := &models.Task{ID: "queued", Title: "Queued"}
+	store.AddTask(ctx, task)
+	pool.enqueue(task)
+
+	cancelled, err := pool.Cancel(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if cancelled.Status != models.Cancelled {
+		t.Errorf("Expected status 'cancelled', got '%s'", cancelled.Status)
+	}
+	if pool.Len() != 0 {
+		t.Errorf("Expected cancelled task to leave the queue, %d still queued", pool.Len())
+	}
+}
+
+// TestCancelRunningTask tests that cancelling interrupts the handler promptly
+func TestCancelRunningTask(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	task := &models.Task{ID: "running", Title: "Running", Duration: 5}
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
+
+	if waitForTaskStatus(store, task.ID, models.Running, time.Second) == nil {
+		t.Fatal("Task never started running")
+	}
+	start := time.Now()
+	if _, err := pool.Cancel(context.Background(), task.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	cancelled := waitForTaskStatus(store, task.ID, models.Cancelled, time.Second)
+	if cancelled == nil {
+		t.Fatal("Running task was not cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Cancellation took too long: %v", elapsed)
+	}
+}
+
+// TestCancelRetryingTask tests that a task waiting for a retry is not retried after cancel
+func TestCancelRetryingTask(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	ctx := context.Background()
+
+	task := &models.Task{ID: "retrying", Title: "Retrying"}
+	store.AddTask(ctx, task)
+	pool.scheduleRetry(task, 50*time.Millisecond)
+
+	if _, err := pool.Cancel(ctx, task.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if pool.Len() != 0 {
+		t.Error("Cancelled task was re-enqueued by its retry timer")
+	}
+	stored, _ := store.GetTask(ctx, task.ID)
+	if stored.Status != models.Cancelled || stored.NextAttemptAt != nil {
+		t.Errorf("Expected cancelled task without next attempt, got %+v", stored)
+	}
+}
+
+// TestCancelFinishedTask tests that finished tasks cannot be cancelled
+func TestCancelFinishedTask(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	ctx := context.Background()
+
+	store.AddTask(ctx, &models.Task{ID: "done", Title: "Done", Status: models.Completed})
+	if _, err := pool.Cancel(ctx, "done"); !errors.Is(err, ErrTaskFinished) {
+		t.Errorf("Expected ErrTaskFinished, got %v", err)
+	}
+	if _, err := pool.Cancel(ctx, "missing"); err == nil {
+		t.Error("Expected error cancelling unknown task")
+	}
+}
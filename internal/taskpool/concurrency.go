@@ -0,0 +1,195 @@
+package taskpool
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// AnyKey in ConcurrencyLimits sets the limit for every task type or
+// concurrency key not listed by name. Each still counts separately.
+const AnyKey = "*"
+
+var ErrInvalidConcurrencyConfig = errors.New("invalid concurrency config")
+
+// ConcurrencyLimits caps how many tasks may run at once across all workers,
+// per task type and per concurrency key (models.Task.ConcurrencyKey). A task
+// must be within both its limits to be dispatched; until then it waits in
+// its queue, in order, and workers move on to other tasks.
+type ConcurrencyLimits struct {
+	Types map[string]int `json:"types"`
+	Keys  map[string]int `json:"keys"`
+}
+
+func (l ConcurrencyLimits) check() error {
+	for _, limits := range []map[string]int{l.Types, l.Keys} {
+		for name, n := range limits {
+			if name == "" || n <= 0 {
+				return fmt.Errorf("%w: %q needs a name and a positive limit", ErrInvalidConcurrencyConfig, name)
+			}
+		}
+	}
+	return nil
+}
+
+// concurrency is the pool's per type and per key running counts and the
+// limits they are checked against.
+type concurrency struct {
+	limits      ConcurrencyLimits
+	typeRunning map[string]int
+	keyRunning  map[string]int
+}
+
+// limitFor returns the limit for name in limits, or 0 for none.
+func limitFor(limits map[string]int, name string) int {
+	if n, ok := limits[name]; ok {
+		return n
+	}
+	return limits[AnyKey]
+}
+
+// typeAtLimit reports whether the task type already has as many tasks
+// running as it may.
+func (c *concurrency) typeAtLimit(name string) bool {
+	n := limitFor(c.limits.Types, name)
+	return n > 0 && c.typeRunning[name] >= n
+}
+
+// keyAtLimit is typeAtLimit for a concurrency key. The empty key has no
+// limit.
+func (c *concurrency) keyAtLimit(key string) bool {
+	if key == "" {
+		return false
+	}
+	n := limitFor(c.limits.Keys, key)
+	return n > 0 && c.keyRunning[key] >= n
+}
+
+// acquire counts task as running.
+func (c *concurrency) acquire(task *models.Task) {
+	if c.typeRunning == nil {
+		c.typeRunning = make(map[string]int)
+		c.keyRunning = make(map[string]int)
+	}
+	c.typeRunning[taskTypeOf(task)]++
+	if task.ConcurrencyKey != "" {
+		c.keyRunning[task.ConcurrencyKey]++
+	}
+}
+
+// release undoes acquire.
+func (c *concurrency) release(task *models.Task) {
+	decrement(c.typeRunning, taskTypeOf(task))
+	if task.ConcurrencyKey != "" {
+		decrement(c.keyRunning, task.ConcurrencyKey)
+	}
+}
+
+func decrement(counts map[string]int, name string) {
+	if counts[name] <= 1 {
+		delete(counts, name)
+	} else {
+		counts[name]--
+	}
+}
+
+// WithConcurrencyLimits sets the initial per type and per key concurrency
+// limits. Invalid entries are ignored; use SetConcurrencyLimits to have
+// them reported.
+func WithConcurrencyLimits(limits ConcurrencyLimits) Option {
+	return func(p *TaskPool) {
+		if limits.check() == nil {
+			p.concurrency.limits = cloneConcurrencyLimits(limits)
+		}
+	}
+}
+
+// ParseConcurrencyLimits builds ConcurrencyLimits from two lists in the
+// format of ParseQueueCapacities: task types ("email=5,*=20") and
+// concurrency keys ("customer-42=1,*=2").
+func ParseConcurrencyLimits(types, keys string) (ConcurrencyLimits, error) {
+	t, err := parseNamedCounts(types, 0, ErrInvalidConcurrencyConfig)
+	if err != nil {
+		return ConcurrencyLimits{}, err
+	}
+	k, err := parseNamedCounts(keys, 0, ErrInvalidConcurrencyConfig)
+	if err != nil {
+		return ConcurrencyLimits{}, err
+	}
+	return ConcurrencyLimits{Types: t, Keys: k}, nil
+}
+
+// cloneConcurrencyLimits copies l, with empty rather than nil maps.
+func cloneConcurrencyLimits(l ConcurrencyLimits) ConcurrencyLimits {
+	clone := ConcurrencyLimits{Types: make(map[string]int), Keys: make(map[string]int)}
+	maps.Copy(clone.Types, l.Types)
+	maps.Copy(clone.Keys, l.Keys)
+	return clone
+}
+
+// ConcurrencyLimits returns the current limits.
+func (p *TaskPool) ConcurrencyLimits() ConcurrencyLimits {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return cloneConcurrencyLimits(p.concurrency.limits)
+}
+
+// SetConcurrencyLimits replaces the per type and per key limits. Tasks
+// already running are not interrupted, but no more are dispatched while a
+// type or key is at or over its new limit; raising a limit releases waiting
+// tasks at once.
+func (p *TaskPool) SetConcurrencyLimits(limits ConcurrencyLimits) error {
+	if err := limits.check(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.concurrency.limits = cloneConcurrencyLimits(limits)
+	p.releaseUnlimitedLocked()
+	return nil
+}
+
+// ConcurrencyStats describes one task type or concurrency key that has a
+// limit or tasks running.
+type ConcurrencyStats struct {
+	Kind    string `json:"kind"` // "type" or "key"
+	Name    string `json:"name"`
+	Limit   int    `json:"limit"` // 0 means none
+	Running int    `json:"running"`
+}
+
+// ConcurrencyStats returns every task type and concurrency key with a limit
+// set by name or with tasks running, types first, each sorted by name.
+func (p *TaskPool) ConcurrencyStats() []ConcurrencyStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var stats []ConcurrencyStats
+	for _, kind := range []struct {
+		name    string
+		limits  map[string]int
+		running map[string]int
+	}{
+		{"type", p.concurrency.limits.Types, p.concurrency.typeRunning},
+		{"key", p.concurrency.limits.Keys, p.concurrency.keyRunning},
+	} {
+		names := slices.Collect(maps.Keys(kind.running))
+		for name := range kind.limits {
+			if _, ok := kind.running[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			stats = append(stats, ConcurrencyStats{
+				Kind:    kind.name,
+				Name:    name,
+				Limit:   limitFor(kind.limits, name),
+				Running: kind.running[name],
+			})
+		}
+	}
+	return stats
+}
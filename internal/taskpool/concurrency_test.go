@@ -0,0 +1,143 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestConcurrencyLimitByType tests that tasks of a type at its limit wait in the queue while other tasks run
+func TestConcurrencyLimitByType(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore(), WithConcurrencyLimits(ConcurrencyLimits{Types: map[string]int{"fragile": 1}}))
+	pool.enqueue(&models.Task{ID: "f1", Type: "fragile"})
+	pool.enqueue(&models.Task{ID: "f2", Type: "fragile"})
+	pool.enqueue(&models.Task{ID: "other", Type: "echo"})
+
+	first := pool.popLocked()
+	pool.running[first.ID] = func(error) {}
+	if first.ID != "f1" {
+		t.Fatalf("Expected f1 first, got %s", first.ID)
+	}
+	if task := pool.popLocked(); task == nil || task.ID != "other" {
+		t.Fatalf("Expected other while fragile is at its limit, got %v", task)
+	}
+	if task := pool.popLocked(); task != nil {
+		t.Fatalf("Expected nothing while fragile is at its limit, got %s", task.ID)
+	}
+	if stats := pool.Stats(); stats.Queued != 1 || stats.Limited != 1 {
+		t.Errorf("Expected the waiting task to show as limited, got %+v", stats)
+	}
+
+	pool.done(first)
+	if task := pool.popLocked(); task == nil || task.ID != "f2" {
+		t.Fatalf("Expected f2 once f1 finished, got %v", task)
+	}
+}
+
+// TestConcurrencyLimitByKey tests that each concurrency key is limited on its own and tasks without one are not
+func TestConcurrencyLimitByKey(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore(), WithConcurrencyLimits(ConcurrencyLimits{Keys: map[string]int{AnyKey: 1, "vip": 2}}))
+	for _, task := range []*models.Task{
+		{ID: "a1", ConcurrencyKey: "cust-a"},
+		{ID: "a2", ConcurrencyKey: "cust-a"},
+		{ID: "b1", ConcurrencyKey: "cust-b"},
+		{ID: "v1", ConcurrencyKey: "vip"},
+		{ID: "v2", ConcurrencyKey: "vip"},
+		{ID: "none"},
+	} {
+		pool.enqueue(task)
+	}
+
+	var got []string
+	for task := pool.popLocked(); task != nil; task = pool.popLocked() {
+		got = append(got, task.ID)
+	}
+	if want := []string{"a1", "b1", "v1", "v2", "none"}; !slices.Equal(got, want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	stats := pool.ConcurrencyStats()
+	if len(stats) != 5 || stats[0] != (ConcurrencyStats{Kind: "type", Name: DefaultTaskType, Running: 5}) ||
+		stats[1] != (ConcurrencyStats{Kind: "key", Name: "*", Limit: 1}) ||
+		stats[2] != (ConcurrencyStats{Kind: "key", Name: "cust-a", Limit: 1, Running: 1}) {
+		t.Errorf("Unexpected concurrency stats: %+v", stats)
+	}
+
+	// Raising the limit lets the waiting task through without anything finishing
+	if err := pool.SetConcurrencyLimits(ConcurrencyLimits{Keys: map[string]int{AnyKey: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if task := pool.popLocked(); task == nil || task.ID != "a2" {
+		t.Errorf("Expected a2 after raising the limit, got %v", task)
+	}
+}
+
+// TestConcurrencyHeldTasks tests that tasks held back by a limit or a pause are counted, cancellable and released in order
+func TestConcurrencyHeldTasks(t *testing.T) {
+	ctx := context.Background()
+	pool := NewTaskPool(10, store.NewMemoryStore(), WithAgingInterval(0),
+		WithConcurrencyLimits(ConcurrencyLimits{Types: map[string]int{DefaultTaskType: 1}}))
+	for _, id := range []string{"t1", "t2", "t3", "t4"} {
+		addWithPriority(t, pool, id, 0)
+	}
+	first := pool.popLocked()
+	pool.running[first.ID] = func(error) {}
+	if task := pool.popLocked(); task != nil {
+		t.Fatalf("Expected nothing while at the limit, got %s", task.ID)
+	}
+	if stats := pool.Stats(); stats.Queued != 3 || stats.Limited != 3 || stats.OldestWait != 0 {
+		t.Fatalf("Expected 3 limited tasks, got %+v", stats)
+	}
+	if _, err := pool.Cancel(ctx, "t2"); err != nil {
+		t.Fatalf("Cancel of a held task failed: %v", err)
+	}
+
+	// Pausing the type counts its held tasks as paused instead
+	if _, err := pool.Pause(ctx, DefaultTaskType); err != nil {
+		t.Fatal(err)
+	}
+	if stats := pool.Stats(); stats.Queued != 2 || stats.Paused != 2 || stats.Limited != 0 {
+		t.Fatalf("Expected 2 paused tasks, got %+v", stats)
+	}
+	pool.done(first)
+	if task := pool.popLocked(); task != nil {
+		t.Fatalf("Expected nothing while paused, got %s", task.ID)
+	}
+
+	if _, err := pool.Resume(ctx, DefaultTaskType); err != nil {
+		t.Fatal(err)
+	}
+	if task := pool.popLocked(); task == nil || task.ID != "t3" {
+		t.Fatalf("Expected t3 after resuming, got %v", task)
+	}
+	if task := pool.popLocked(); task != nil {
+		t.Fatalf("Expected t4 to wait for t3, got %s", task.ID)
+	}
+	if stats := pool.Stats(); stats.Queued != 1 || stats.Limited != 1 || stats.Paused != 0 {
+		t.Errorf("Expected t4 to be held by the limit again, got %+v", stats)
+	}
+}
+
+// TestParseConcurrencyLimits tests parsing and validation of concurrency limits
+func TestParseConcurrencyLimits(t *testing.T) {
+	limits, err := ParseConcurrencyLimits("email=5, *=20", "customer-42=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.Types["email"] != 5 || limits.Types[AnyKey] != 20 || limits.Keys["customer-42"] != 1 {
+		t.Errorf("Unexpected limits: %+v", limits)
+	}
+	for _, bad := range [][2]string{{"email", ""}, {"", "k=0"}, {"a=1,a=2", ""}} {
+		if _, err := ParseConcurrencyLimits(bad[0], bad[1]); !errors.Is(err, ErrInvalidConcurrencyConfig) {
+			t.Errorf("%q: expected ErrInvalidConcurrencyConfig, got %v", bad, err)
+		}
+	}
+	pool := NewTaskPool(1, store.NewMemoryStore())
+	if err := pool.SetConcurrencyLimits(ConcurrencyLimits{Types: map[string]int{"email": -1}}); !errors.Is(err, ErrInvalidConcurrencyConfig) {
+		t.Errorf("Expected a negative limit to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shayanmkpr/task-pool/internal/cron"
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+type cronEntry struct {
+	spec  cron.Schedule
+	timer *time.Timer
+}
+
+// CronRunner fires recurring schedules, submitting a task to the pool each
+// time one comes due. Schedule definitions and their fire history live in
+// the store; the runner only keeps one timer per schedule.
+type CronRunner struct {
+	pool   *TaskPool
+	store  store.ScheduleStore
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	entries map[string]*cronEntry
+	stopped bool
+}
+
+func NewCronRunner(pool *TaskPool, store store.ScheduleStore, logger *logger.Logger) *CronRunner {
+	c := &CronRunner{
+		pool:    pool,
+		store:   store,
+		logger:  logger,
+		entries: make(map[string]*cronEntry),
+	}
+	pool.OnFinish(c.taskFinished)
+	return c
+}
+
+// Start arms every stored schedule. Firings missed while the process was
+// down are not replayed; each schedule resumes from its next activation.
+func (c *CronRunner) Start(ctx context.Context) error {
+	schedules, err := c.store.ListSchedules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sched := range schedules {
+		spec, err := cron.Parse(sched.Spec)
+		if err != nil {
+			c.logger.Error("skipping schedule with invalid spec", "schedule_id", sched.ID, "error", err)
+			continue
+		}
+		if err := c.armLocked(ctx, sched, spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop cancels all pending firings.
+func (c *CronRunner) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	for _, e := range c.entries {
+		e.timer.Stop()
+	}
+}
+
+// Create validates and stores a new schedule and arms its first firing.
+func (c *CronRunner) Create(ctx context.Context, sched *models.Schedule) (*models.Schedule, error) {
+	spec, err := validateSchedule(sched)
+	if err != nil {
+		return nil, err
+	}
+	sched.ID = uuid.New().String()
+	sched.CreatedAt = c.pool.now().UTC()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	next := spec.Next(sched.CreatedAt)
+	sched.NextFireAt = &next
+	if err := c.store.AddSchedule(ctx, sched); err != nil {
+		return nil, fmt.Errorf("failed to store schedule: %w", err)
+	}
+	c.startTimerLocked(sched.ID, spec, next)
+	return sched.Clone(), nil
+}
+
+// Update replaces the definition of a schedule, keeping its history, and
+// re-arms it from the new spec.
+func (c *CronRunner) Update(ctx context.Context, id string, def *models.Schedule) (*models.Schedule, error) {
+	spec, err := validateSchedule(def)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sched, err := c.store.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sched.Name = def.Name
+	sched.Spec = def.Spec
+	sched.Task = def.Task
+	sched.Overlap = def.Overlap
+	if sched.Overlap != models.OverlapQueue {
+		sched.QueuedFires = 0
+	}
+	if err := c.armLocked(ctx, sched, spec); err != nil {
+		return nil, err
+	}
+	return sched, nil
+}
+
+func (c *CronRunner) Delete(ctx context.Context, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.store.DeleteSchedule(ctx, id); err != nil {
+		return err
+	}
+	if e, ok := c.entries[id]; ok {
+		e.timer.Stop()
+		delete(c.entries, id)
+	}
+	return nil
+}
+
+func (c *CronRunner) Get(ctx context.Context, id string) (*models.Schedule, error) {
+	return c.store.GetSchedule(ctx, id)
+}
+
+func (c *CronRunner) List(ctx context.Context) ([]*models.Schedule, error) {
+	return c.store.ListSchedules(ctx)
+}
+
+func validateSchedule(sched *models.Schedule) (cron.Schedule, error) {
+	sched.Name = strings.TrimSpace(sched.Name)
+	if sched.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidSchedule)
+	}
+	spec, err := cron.Parse(sched.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	switch sched.Overlap {
+	case "":
+		sched.Overlap = models.OverlapSkip
+	case models.OverlapSkip, models.OverlapQueue, models.OverlapAllow:
+	default:
+		return nil, fmt.Errorf("%w: unknown overlap policy %q", ErrInvalidSchedule, sched.Overlap)
+	}
+	return spec, nil
+}
+
+// armLocked recomputes the next fire time, persists it and (re)starts the
+// timer. Callers must hold c.mu.
+func (c *CronRunner) armLocked(ctx context.Context, sched *models.Schedule, spec cron.Schedule) error {
+	next := spec.Next(c.pool.now().UTC())
+	sched.NextFireAt = &next
+	if err := c.store.UpdateSchedule(ctx, sched); err != nil {
+		return fmt.Errorf("failed to update schedule %s: %w", sched.ID, err)
+	}
+	c.startTimerLocked(sched.ID, spec, next)
+	return nil
+}
+
+func (c *CronRunner) startTimerLocked(id string, spec cron.Schedule, next time.Time) {
+	if e, ok := c.entries[id]; ok {
+		e.timer.Stop()
+	}
+	if c.stopped {
+		return
+	}
+	delay := max(next.Sub(c.pool.now()), 0)
+	c.entries[id] = &cronEntry{
+		spec:  spec,
+		timer: time.AfterFunc(delay, func() { c.fire(id) }),
+	}
+}
+
+func (c *CronRunner) fire(id string) {
+	ctx := context.Background()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[id]
+	if !ok || c.stopped {
+		return
+	}
+	sched, err := c.store.GetSchedule(ctx, id)
+	if err != nil {
+		c.logger.Error("failed to load schedule", "schedule_id", id, "error", err)
+		return
+	}
+
+	now := c.pool.now().UTC()
+	sched.LastFireAt = &now
+	switch {
+	case !c.previousActive(ctx, sched) || sched.Overlap == models.OverlapAllow:
+		c.submit(ctx, sched)
+	case sched.Overlap == models.OverlapQueue:
+		sched.QueuedFires++
+		c.logger.Info("schedule firing queued behind active run", "schedule_id", id, "active_task_id", sched.LastTaskID)
+	default:
+		sched.SkipCount++
+		c.logger.Info("schedule firing skipped, previous run still active", "schedule_id", id, "active_task_id", sched.LastTaskID)
+	}
+
+	next := entry.spec.Next(now)
+	sched.NextFireAt = &next
+	if err := c.store.UpdateSchedule(ctx, sched); err != nil {
+		c.logger.Error("failed to update schedule", "schedule_id", id, "error", err)
+	}
+	c.startTimerLocked(id, entry.spec, next)
+}
+
+// previousActive reports whether the task from the last firing has yet to
+// reach a terminal state.
+func (c *CronRunner) previousActive(ctx context.Context, sched *models.Schedule) bool {
+	if sched.LastTaskID == "" {
+		return false
+	}
+	task, err := c.pool.Store.GetTask(ctx, sched.LastTaskID)
+	if err != nil {
+		return false
+	}
+	return !task.Status.Terminal()
+}
+
+// submit turns the schedule's template into a task and adds it to the pool.
+// A rejected submission (e.g. a full queue) counts as a skipped firing.
+func (c *CronRunner) submit(ctx context.Context, sched *models.Schedule) {
+	tpl := sched.Task
+	task := &models.Task{
+		ID:             uuid.New().String(),
+		Title:          tpl.Title,
+		Description:    tpl.Description,
+		Type:           tpl.Type,
+		Queue:          tpl.Queue,
+		ConcurrencyKey: tpl.ConcurrencyKey,
+		Tenant:         sched.Tenant,
+		Payload:        tpl.Payload,
+		Tags:           tpl.Tags,
+		Priority:       tpl.Priority,
+		Retry:          tpl.Retry,
+		Timeout:        tpl.Timeout,
+		ScheduleID:     sched.ID,
+	}
+	if _, err := c.pool.AddTask(ctx, c.logger, task); err != nil {
+		sched.SkipCount++
+		sched.LastError = err.Error()
+		c.logger.Error("schedule failed to submit task", "schedule_id", sched.ID, "error", err)
+		return
+	}
+	sched.LastTaskID = task.ID
+	sched.LastError = ""
+	sched.FireCount++
+	c.logger.Info("schedule fired", "schedule_id", sched.ID, "task_id", task.ID)
+}
+
+// taskFinished releases one queued firing once the run it was waiting on
+// has finished.
+func (c *CronRunner) taskFinished(task *models.Task) {
+	if task.ScheduleID == "" {
+		return
+	}
+	ctx := context.Background()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sched, err := c.store.GetSchedule(ctx, task.ScheduleID)
+	if err != nil || sched.QueuedFires == 0 || sched.LastTaskID != task.ID {
+		return
+	}
+	sched.QueuedFires--
+	c.submit(ctx, sched)
+	if err := c.store.UpdateSchedule(ctx, sched); err != nil {
+		c.logger.Error("failed to update schedule", "schedule_id", sched.ID, "error", err)
+	}
+}
@@ -0,0 +1,183 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// yearly never comes due while a test runs, so firings are driven by hand
+const yearly = "0 0 1 1 *"
+
+func newTestCron(t *testing.T) (*CronRunner, *TaskPool, *store.MemoryStore) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st)
+	c := NewCronRunner(pool, st, logger.NewTestLogger())
+	t.Cleanup(c.Stop)
+	return c, pool, st
+}
+
+func createTestSchedule(t *testing.T, c *CronRunner, overlap models.OverlapPolicy) *models.Schedule {
+	t.Helper()
+	sched, err := c.Create(context.Background(), &models.Schedule{
+		Name:    "nightly",
+		Spec:    yearly,
+		Overlap: overlap,
+		Task:    models.TaskTemplate{Title: "Report", Type: DefaultTaskType},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	return sched
+}
+
+// TestCronCreateValidation tests that bad specs and policies are rejected
+func TestCronCreateValidation(t *testing.T) {
+	c, _, _ := newTestCron(t)
+	ctx := context.Background()
+
+	for name, sched := range map[string]*models.Schedule{
+		"missing name": {Spec: yearly},
+		"bad spec":     {Name: "x", Spec: "61 * * * *"},
+		"bad overlap":  {Name: "x", Spec: yearly, Overlap: "sometimes"},
+	} {
+		if _, err := c.Create(ctx, sched); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("%s: expected ErrInvalidSchedule, got %v", name, err)
+		}
+	}
+
+	sched := createTestSchedule(t, c, "")
+	if sched.Overlap != models.OverlapSkip {
+		t.Errorf("Expected default overlap 'skip', got '%s'", sched.Overlap)
+	}
+	if sched.NextFireAt == nil || sched.NextFireAt.Month() != time.January || sched.NextFireAt.Day() != 1 {
+		t.Errorf("Expected next fire on January 1st, got %v", sched.NextFireAt)
+	}
+}
+
+// TestCronFireSubmitsTask tests that a firing enqueues a task linked to the schedule
+func TestCronFireSubmitsTask(t *testing.T) {
+	c, pool, st := newTestCron(t)
+	ctx := context.Background()
+	sched := createTestSchedule(t, c, models.OverlapSkip)
+
+	c.fire(sched.ID)
+
+	got, _ := st.GetSchedule(ctx, sched.ID)
+	if got.FireCount != 1 || got.LastTaskID == "" || got.LastFireAt == nil {
+		t.Fatalf("Expected one recorded firing, got %+v", got)
+	}
+	task, err := st.GetTask(ctx, got.LastTaskID)
+	if err != nil {
+		t.Fatalf("Submitted task not stored: %v", err)
+	}
+	if task.ScheduleID != sched.ID || task.Title != "Report" || task.Status != models.Pending {
+		t.Errorf("Unexpected submitted task: %+v", task)
+	}
+	if pool.Len() != 1 {
+		t.Errorf("Expected 1 queued task, got %d", pool.Len())
+	}
+}
+
+// TestCronOverlapPolicies tests skip, queue and allow while the previous run is active
+func TestCronOverlapPolicies(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("skip", func(t *testing.T) {
+		c, pool, st := newTestCron(t)
+		sched := createTestSchedule(t, c, models.OverlapSkip)
+		c.fire(sched.ID)
+		c.fire(sched.ID)
+
+		got, _ := st.GetSchedule(ctx, sched.ID)
+		if got.FireCount != 1 || got.SkipCount != 1 || pool.Len() != 1 {
+			t.Errorf("Expected 1 fire and 1 skip, got fires=%d skips=%d queued=%d", got.FireCount, got.SkipCount, pool.Len())
+		}
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		c, pool, st := newTestCron(t)
+		sched := createTestSchedule(t, c, models.OverlapAllow)
+		c.fire(sched.ID)
+		c.fire(sched.ID)
+
+		got, _ := st.GetSchedule(ctx, sched.ID)
+		if got.FireCount != 2 || pool.Len() != 2 {
+			t.Errorf("Expected 2 concurrent runs, got fires=%d queued=%d", got.FireCount, pool.Len())
+		}
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		c, pool, st := newTestCron(t)
+		sched := createTestSchedule(t, c, models.OverlapQueue)
+		c.fire(sched.ID)
+		c.fire(sched.ID)
+
+		got, _ := st.GetSchedule(ctx, sched.ID)
+		if got.FireCount != 1 || got.QueuedFires != 1 {
+			t.Fatalf("Expected 1 fire and 1 deferred, got fires=%d deferred=%d", got.FireCount, got.QueuedFires)
+		}
+
+		// Finish the first run; the deferred firing should be submitted
+		first, _ := st.GetTask(ctx, got.LastTaskID)
+		first.Status = models.Completed
+		st.UpdateTask(ctx, first)
+		pool.notifyFinished(first)
+
+		got, _ = st.GetSchedule(ctx, sched.ID)
+		if got.FireCount != 2 || got.QueuedFires != 0 || got.LastTaskID == first.ID {
+			t.Errorf("Expected deferred firing to run, got fires=%d deferred=%d", got.FireCount, got.QueuedFires)
+		}
+		if pool.Len() != 2 {
+			t.Errorf("Expected 2 queued tasks, got %d", pool.Len())
+		}
+	})
+}
+
+// TestCronStartRearmsStoredSchedules tests that schedules are picked up after a restart
+func TestCronStartRearmsStoredSchedules(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	st.AddSchedule(ctx, &models.Schedule{ID: "s1", Name: "every", Spec: "@every 1s", Overlap: models.OverlapAllow,
+		Task: models.TaskTemplate{Title: "Tick", Type: DefaultTaskType}})
+
+	pool := NewTaskPool(10, st)
+	c := NewCronRunner(pool, st, logger.NewTestLogger())
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer c.Stop()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if got, _ := st.GetSchedule(ctx, "s1"); got.FireCount > 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("Expected stored schedule to fire after Start")
+}
+
+// TestCronDelete tests that deleted schedules are gone and no longer fire
+func TestCronDelete(t *testing.T) {
+	c, pool, st := newTestCron(t)
+	ctx := context.Background()
+	sched := createTestSchedule(t, c, models.OverlapAllow)
+
+	if err := c.Delete(ctx, sched.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := st.GetSchedule(ctx, sched.ID); !errors.Is(err, store.ErrScheduleNotFound) {
+		t.Errorf("Expected ErrScheduleNotFound, got %v", err)
+	}
+	c.fire(sched.ID)
+	if pool.Len() != 0 {
+		t.Errorf("Expected deleted schedule not to fire, got %d queued", pool.Len())
+	}
+}
@@ -0,0 +1,79 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+var ErrDeadLettersDisabled = errors.New("dead-letter queue is not enabled")
+
+// WithDeadLetters moves tasks that fail permanently (status failed or
+// timed_out) out of the task store and into dl. Without it they stay in
+// the task store with their final status.
+func WithDeadLetters(dl store.DeadLetterStore) Option {
+	return func(p *TaskPool) {
+		p.deadLetters = dl
+	}
+}
+
+// deadLetter moves a permanently failed task to the dead-letter store. It
+// reports false when dead-lettering is disabled or the move failed, in which
+// case the caller records the final status in the task store as usual.
+func (p *TaskPool) deadLetter(task *models.Task, reason string) bool {
+	if p.deadLetters == nil {
+		return false
+	}
+	ctx := context.Background()
+	dl := &models.DeadLetter{
+		ID:       task.ID,
+		Task:     task,
+		Error:    reason,
+		FailedAt: p.now().UTC(),
+	}
+	if err := p.deadLetters.AddDeadLetter(ctx, dl); err != nil {
+		slog.Error("failed to dead-letter task", "task_id", task.ID, "error", err)
+		return false
+	}
+	if err := p.Store.DeleteTask(ctx, task.ID); err != nil {
+		slog.Error("failed to remove dead-lettered task", "task_id", task.ID, "error", err)
+	}
+	return true
+}
+
+// Requeue gives a dead-lettered task a fresh set of attempts. The task keeps
+// its ID and attempt history, whose numbering carries on; it is submitted
+// like a new task, so a full queue or a deadline that has since passed
+// leaves it in the dead-letter store. The dead letter is taken out of the
+// store before the task is submitted, so concurrent requeues of the same ID
+// queue it only once.
+func (p *TaskPool) Requeue(ctx context.Context, logger *logger.Logger, id string) (*models.Task, error) {
+	if p.deadLetters == nil {
+		return nil, ErrDeadLettersDisabled
+	}
+	dl, err := p.deadLetters.TakeDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	task := dl.Task.Clone()
+	task.Status = ""
+	task.Attempts = 0
+	task.LastError = ""
+	task.Error = ""
+	task.Result = nil
+	task.RunAt = nil
+	task.NextAttemptAt = nil
+	if _, err := p.AddTask(ctx, logger, task); err != nil {
+		if putErr := p.deadLetters.AddDeadLetter(context.Background(), dl); putErr != nil {
+			logger.Error("failed to restore dead letter", "task_id", id, "error", putErr)
+		}
+		return nil, err
+	}
+	logger.Info("dead-lettered task requeued", "task_id", id)
+	return task.Clone(), nil
+}
@@ -0,0 +1,154 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// waitForDeadLetter polls until the task shows up in the dead-letter store
+func waitForDeadLetter(s store.DeadLetterStore, id string, timeout time.Duration) *models.DeadLetter {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if dl, err := s.GetDeadLetter(context.Background(), id); err == nil {
+			return dl
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+// TestDeadLetterOnPermanentFailure tests that a task that exhausts its retries is moved to the dead-letter store
+func TestDeadLetterOnPermanentFailure(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(5, st, WithDeadLetters(st))
+	pool.Handlers.Register("always-fails", func(ctx context.Context, task *models.Task) (any, error) {
+		return nil, errors.New("upstream down")
+	}, WithRetryPolicy(models.RetryPolicy{MaxAttempts: 2, InitialDelay: models.Duration(10 * time.Millisecond)}))
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	ctx := context.Background()
+	if _, err := pool.AddTask(ctx, logger.NewTestLogger(), &models.Task{ID: "doomed", Title: "Doomed", Type: "always-fails"}); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	dl := waitForDeadLetter(st, "doomed", 2*time.Second)
+	if dl == nil {
+		t.Fatal("Task was not dead-lettered")
+	}
+	if dl.Error != "upstream down" || dl.Task.Status != models.Failed || len(dl.Task.History) != 2 {
+		t.Errorf("Unexpected dead letter: %+v (task %+v)", dl, dl.Task)
+	}
+	if _, err := st.GetTask(ctx, "doomed"); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("Expected dead-lettered task to leave the task store, got %v", err)
+	}
+}
+
+// TestDeadLetterOnNonRetryableError tests that a permanent handler error skips the remaining attempts
+func TestDeadLetterOnNonRetryableError(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(5, st, WithDeadLetters(st))
+	pool.Handlers.Register("bad-input", func(ctx context.Context, task *models.Task) (any, error) {
+		return nil, Permanent(errors.New("malformed payload"))
+	}, WithRetryPolicy(models.RetryPolicy{MaxAttempts: 5, InitialDelay: models.Duration(10 * time.Millisecond)}))
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	if _, err := pool.AddTask(context.Background(), logger.NewTestLogger(), &models.Task{ID: "bad", Title: "Bad", Type: "bad-input"}); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	dl := waitForDeadLetter(st, "bad", 2*time.Second)
+	if dl == nil {
+		t.Fatal("Task was not dead-lettered")
+	}
+	if dl.Error != "malformed payload" || dl.Task.Status != models.Failed || dl.Task.Attempts != 1 || len(dl.Task.History) != 1 {
+		t.Errorf("Expected one attempt and no retries, got %+v (task %+v)", dl, dl.Task)
+	}
+}
+
+// TestRequeueDeadLetter tests that requeueing resets attempts and puts the task back in the queue
+func TestRequeueDeadLetter(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(5, st, WithDeadLetters(st))
+	ctx := context.Background()
+	log := logger.NewTestLogger()
+
+	st.AddDeadLetter(ctx, &models.DeadLetter{ID: "dead", Error: "boom", Task: &models.Task{
+		ID: "dead", Title: "Dead", Status: models.Failed, Attempts: 3, LastError: "boom", Error: "boom",
+		History: []models.Attempt{{Number: 1, Error: "boom"}, {Number: 2, Error: "boom"}, {Number: 3, Error: "boom"}},
+	}})
+
+	task, err := pool.Requeue(ctx, log, "dead")
+	if err != nil {
+		t.Fatalf("Requeue failed: %v", err)
+	}
+	if task.Status != models.Pending || task.Attempts != 0 || task.Error != "" || len(task.History) != 3 {
+		t.Errorf("Unexpected requeued task: %+v", task)
+	}
+	if pool.Len() != 1 {
+		t.Errorf("Expected requeued task in the queue, got %d", pool.Len())
+	}
+	if _, err := st.GetDeadLetter(ctx, "dead"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+		t.Errorf("Expected dead letter to be removed, got %v", err)
+	}
+	if _, err := pool.Requeue(ctx, log, "dead"); !errors.Is(err, store.ErrDeadLetterNotFound) {
+		t.Errorf("Expected ErrDeadLetterNotFound requeueing twice, got %v", err)
+	}
+
+	if _, err := NewTaskPool(5, st).Requeue(ctx, log, "dead"); !errors.Is(err, ErrDeadLettersDisabled) {
+		t.Errorf("Expected ErrDeadLettersDisabled, got %v", err)
+	}
+}
+
+// TestRequeueQueueFullKeepsDeadLetter tests that a rejected requeue leaves the dead letter in place
+func TestRequeueQueueFullKeepsDeadLetter(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(1, st, WithDeadLetters(st))
+	ctx := context.Background()
+	log := logger.NewTestLogger()
+
+	pool.AddTask(ctx, log, &models.Task{ID: "filler", Title: "Filler"})
+	st.AddDeadLetter(ctx, &models.DeadLetter{ID: "dead", Task: &models.Task{ID: "dead", Title: "Dead", Status: models.Failed}})
+
+	if _, err := pool.Requeue(ctx, log, "dead"); !errors.Is(err, ErrTaskQueueFull) {
+		t.Fatalf("Expected ErrTaskQueueFull, got %v", err)
+	}
+	if dl, err := st.GetDeadLetter(ctx, "dead"); err != nil || dl.Task.Status != models.Failed {
+		t.Errorf("Expected dead letter to remain unchanged after a rejected requeue, got %+v, %v", dl, err)
+	}
+}
+
+// TestRequeueConcurrent tests that racing requeues of one dead letter queue it once
+func TestRequeueConcurrent(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st, WithDeadLetters(st))
+	ctx := context.Background()
+	st.AddDeadLetter(ctx, &models.DeadLetter{ID: "dead", Task: &models.Task{ID: "dead", Title: "Dead", Status: models.Failed}})
+
+	var wg sync.WaitGroup
+	var requeued atomic.Int32
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pool.Requeue(ctx, logger.NewTestLogger(), "dead"); err == nil {
+				requeued.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if requeued.Load() != 1 || pool.Len() != 1 {
+		t.Errorf("Expected one requeue and one queued task, got %d and %d", requeued.Load(), pool.Len())
+	}
+}
@@ -0,0 +1,345 @@
+package taskpool
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+var ErrDependencyCycle = fmt.Errorf("%w: dependency cycle", ErrInvalidTask)
+
+// dependencies tracks tasks that are blocked on other tasks. A blocked task
+// is stored but holds no queue slot until it is released. All fields
+// require the pool's mutex.
+type dependencies struct {
+	blocked    map[string]*models.Task // blocked tasks by ID
+	dependents map[string][]string     // parent ID -> blocked tasks waiting on it
+}
+
+func (d *dependencies) len() int { return len(d.blocked) }
+
+// Blocked returns the number of tasks waiting for their dependencies.
+func (p *TaskPool) Blocked() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.deps.len()
+}
+
+// checkDependencies validates and de-duplicates task.DependsOn. Every parent
+// must already exist, either in the store (finished or not) or in batch,
+// the set of tasks submitted together with this one.
+func (p *TaskPool) checkDependencies(ctx context.Context, task *models.Task, batch map[string]*models.Task) error {
+	switch task.OnDepFailure {
+	case "", models.DependencyCancel, models.DependencyRun:
+	default:
+		return fmt.Errorf("%w: unknown on_dependency_failure %q", ErrInvalidTask, task.OnDepFailure)
+	}
+	if len(task.DependsOn) == 0 {
+		task.DependsOn = nil
+		return nil
+	}
+
+	parents := make([]string, 0, len(task.DependsOn))
+	for _, id := range task.DependsOn {
+		switch {
+		case id == "":
+			return fmt.Errorf("%w: empty dependency id", ErrInvalidTask)
+		case id == task.ID:
+			return fmt.Errorf("%w: task %s depends on itself", ErrDependencyCycle, id)
+		case slices.Contains(parents, id):
+			continue
+		}
+		if _, ok := batch[id]; !ok {
+			if _, err := p.lookup(ctx, id); err != nil {
+				if errors.Is(err, store.ErrTaskNotFound) {
+					return fmt.Errorf("%w: unknown dependency %s", ErrInvalidTask, id)
+				}
+				return fmt.Errorf("failed to look up dependency %s: %w", id, err)
+			}
+		}
+		parents = append(parents, id)
+	}
+	task.DependsOn = parents
+	return nil
+}
+
+// findCycle returns the index of a task in batch that can reach itself
+// through depends_on, or -1. Tasks already in the store can only depend on
+// tasks that existed before them, so a cycle has to run through the batch.
+func findCycle(batch []*models.Task) int {
+	index := make(map[string]int, len(batch))
+	for i, task := range batch {
+		index[task.ID] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(batch))
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = visiting
+		for _, parent := range batch[i].DependsOn {
+			j, ok := index[parent]
+			if !ok {
+				continue
+			}
+			if state[j] == visiting || (state[j] == unvisited && visit(j)) {
+				return true
+			}
+		}
+		state[i] = visited
+		return false
+	}
+	for i := range batch {
+		if state[i] == unvisited && visit(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// addBlocked stores a task whose parents have not all finished yet and
+// resolves it straight away in case they finished while it was being stored.
+func (p *TaskPool) addBlocked(ctx context.Context, task *models.Task) (string, error) {
+	task.Status = models.Blocked
+	if err := p.Store.AddTask(ctx, task); err != nil {
+		return "", fmt.Errorf("failed to store task: %w", err)
+	}
+	p.mu.Lock()
+	p.blockLocked(task)
+	p.publish(task, 0)
+	p.mu.Unlock()
+	p.resolve([]string{task.ID})
+	return task.ID, nil
+}
+
+func (p *TaskPool) blockLocked(task *models.Task) {
+	d := &p.deps
+	if d.blocked == nil {
+		d.blocked = make(map[string]*models.Task)
+		d.dependents = make(map[string][]string)
+	}
+	d.blocked[task.ID] = task
+	for _, parent := range task.DependsOn {
+		d.dependents[parent] = append(d.dependents[parent], task.ID)
+	}
+}
+
+func (p *TaskPool) unblockLocked(task *models.Task) {
+	d := &p.deps
+	delete(d.blocked, task.ID)
+	for _, parent := range task.DependsOn {
+		waiting := slices.DeleteFunc(d.dependents[parent], func(id string) bool { return id == task.ID })
+		if len(waiting) == 0 {
+			delete(d.dependents, parent)
+		} else {
+			d.dependents[parent] = waiting
+		}
+	}
+}
+
+// resolve re-examines blocked tasks after one of their parents finished.
+// A task whose parents all completed is released into the pool, bypassing
+// the capacity check since the pool already accepted it. A task with a
+// parent that failed, was cancelled or timed out is cancelled in turn,
+// unless it asked to run anyway, in which case it is released once every
+// parent has finished. Callers must not hold p.mu.
+//
+// The store is read and written without p.mu. A task stays blocked while
+// its parents are looked up; once its outcome is decided it is unblocked,
+// saved, and only then queued or scheduled.
+func (p *TaskPool) resolve(ids []string) {
+	p.mu.Lock()
+	var waiting []*models.Task
+	for _, id := range ids {
+		if task, ok := p.deps.blocked[id]; ok {
+			waiting = append(waiting, task)
+		}
+	}
+	p.mu.Unlock()
+
+	type outcome struct {
+		task    *models.Task
+		failure string // set when the task is cancelled
+	}
+	var decided []outcome
+	for _, task := range waiting {
+		finished, failure := p.dependencyState(task)
+		switch {
+		case failure != "" && task.OnDepFailure != models.DependencyRun:
+			decided = append(decided, outcome{task, failure})
+		case finished:
+			decided = append(decided, outcome{task, ""})
+		}
+	}
+	if len(decided) == 0 {
+		return
+	}
+
+	// A task cancelled or resolved elsewhere meanwhile is no longer blocked.
+	p.mu.Lock()
+	claimed := decided[:0]
+	for _, o := range decided {
+		if p.deps.blocked[o.task.ID] == o.task {
+			p.unblockLocked(o.task)
+			claimed = append(claimed, o)
+		}
+	}
+	p.mu.Unlock()
+
+	var cancelled []*models.Task
+	for _, o := range claimed {
+		task := o.task
+		if o.failure != "" {
+			task.Status = models.Cancelled
+			task.Error = o.failure
+			task.RunAt = nil
+			cancelled = append(cancelled, task)
+		} else {
+			p.prepareRelease(task)
+		}
+		p.updateTask(task)
+	}
+
+	p.mu.Lock()
+	for _, o := range claimed {
+		task := o.task
+		p.publish(task, 0)
+		switch task.Status {
+		case models.Scheduled:
+			p.scheduleLocked(task, *task.RunAt)
+		case models.Pending:
+			p.enqueueLocked(task)
+		}
+	}
+	p.mu.Unlock()
+
+	// Cancelling a task finishes it, which resolves its own dependents.
+	for _, task := range cancelled {
+		p.notifyFinished(task.Clone())
+	}
+}
+
+// dependencyState reports whether every parent of task has finished and,
+// if one of them did not complete, why.
+func (p *TaskPool) dependencyState(task *models.Task) (finished bool, failure string) {
+	finished = true
+	for _, id := range task.DependsOn {
+		parent, err := p.lookup(context.Background(), id)
+		switch {
+		case errors.Is(err, store.ErrTaskNotFound):
+			if failure == "" {
+				failure = fmt.Sprintf("dependency %s no longer exists", id)
+			}
+		case err != nil:
+			slog.Error("failed to look up dependency", "task_id", task.ID, "dependency", id, "error", err)
+			finished = false
+		case !parent.Status.Terminal():
+			finished = false
+		case parent.Status != models.Completed && failure == "":
+			failure = fmt.Sprintf("dependency %s %s", id, parent.Status)
+		}
+	}
+	return finished, failure
+}
+
+// prepareRelease readies a task whose dependencies are satisfied to be
+// queued, or scheduled if its run_at is still ahead. A task with
+// PassResults gets its parents' results as input first.
+func (p *TaskPool) prepareRelease(task *models.Task) {
+	if task.PassResults {
+		results := make([]json.RawMessage, len(task.DependsOn))
+		for i, id := range task.DependsOn {
+			if parent, err := p.lookup(context.Background(), id); err == nil {
+				results[i] = parent.Result
+			}
+		}
+		task.Input = joinResults(results)
+	}
+	if task.RunAt != nil && task.RunAt.After(p.now()) {
+		task.Status = models.Scheduled
+		return
+	}
+	task.Status = models.Pending
+	task.RunAt = nil
+}
+
+// Graph returns the dependency graph the task belongs to: its ancestors,
+// its descendants and every other task connected to them through
+// depends_on. Finished and dead-lettered tasks are included.
+func (p *TaskPool) Graph(ctx context.Context, id string) (*models.TaskGraph, error) {
+	if _, err := p.lookup(ctx, id); err != nil {
+		return nil, err
+	}
+	tasks, err := p.Store.ListTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	if p.deadLetters != nil {
+		dead, err := p.deadLetters.ListDeadLetters(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list dead letters: %w", err)
+		}
+		for _, dl := range dead {
+			tasks = append(tasks, dl.Task)
+		}
+	}
+
+	byID := make(map[string]*models.Task, len(tasks))
+	children := make(map[string][]string)
+	for _, task := range tasks {
+		byID[task.ID] = task
+		for _, parent := range task.DependsOn {
+			children[parent] = append(children[parent], task.ID)
+		}
+	}
+
+	member := map[string]bool{id: true}
+	for queue := []string{id}; len(queue) > 0; queue = queue[1:] {
+		task, ok := byID[queue[0]]
+		if !ok {
+			continue
+		}
+		for _, next := range slices.Concat(task.DependsOn, children[task.ID]) {
+			if _, known := byID[next]; known && !member[next] {
+				member[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	graph := &models.TaskGraph{Root: id, Nodes: []models.GraphNode{}, Edges: []models.GraphEdge{}}
+	var nodes []*models.Task
+	for nodeID := range member {
+		if task, ok := byID[nodeID]; ok {
+			nodes = append(nodes, task)
+		}
+	}
+	slices.SortFunc(nodes, func(a, b *models.Task) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	for _, task := range nodes {
+		graph.Nodes = append(graph.Nodes, models.GraphNode{
+			ID:        task.ID,
+			Title:     task.Title,
+			Status:    task.Status,
+			DependsOn: task.DependsOn,
+		})
+		for _, parent := range task.DependsOn {
+			if member[parent] {
+				graph.Edges = append(graph.Edges, models.GraphEdge{From: parent, To: task.ID})
+			}
+		}
+	}
+	return graph, nil
+}
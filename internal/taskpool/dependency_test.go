@@ -0,0 +1,198 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// newDependencyPool returns a pool with one worker and a "gate" handler
+// that blocks until the test sends the task's outcome on gate.
+func newDependencyPool(t *testing.T) (*TaskPool, *store.MemoryStore, chan error) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st)
+	gate := make(chan error)
+	pool.Handlers.Register("gate", func(ctx context.Context, task *models.Task) (any, error) {
+		return nil, <-gate
+	})
+	pool.Handlers.Register("quick", func(ctx context.Context, task *models.Task) (any, error) {
+		return nil, nil
+	})
+	worker := NewWorker(1, pool)
+	worker.Start()
+	t.Cleanup(worker.Stop)
+	return pool, st, gate
+}
+
+// TestDependentWaitsForAllParents tests that a task stays blocked until every parent completes
+func TestDependentWaitsForAllParents(t *testing.T) {
+	pool, st, gate := newDependencyPool(t)
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	pool.AddTask(ctx, log, &models.Task{ID: "a", Title: "A", Type: "gate"})
+	pool.AddTask(ctx, log, &models.Task{ID: "b", Title: "B", Type: "gate"})
+	if _, err := pool.AddTask(ctx, log, &models.Task{ID: "c", Title: "C", Type: "quick", DependsOn: []string{"a", "b", "a"}}); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	c, _ := st.GetTask(ctx, "c")
+	if c.Status != models.Blocked || len(c.DependsOn) != 2 {
+		t.Fatalf("Expected blocked task with 2 parents, got %s %v", c.Status, c.DependsOn)
+	}
+
+	gate <- nil
+	waitForTaskStatus(st, "a", models.Completed, 2*time.Second)
+	if c, _ := st.GetTask(ctx, "c"); c.Status != models.Blocked {
+		t.Fatalf("Expected task to stay blocked with one parent left, got %s", c.Status)
+	}
+
+	gate <- nil
+	if waitForTaskStatus(st, "c", models.Completed, 2*time.Second) == nil {
+		t.Fatal("Dependent task did not run after its parents completed")
+	}
+	if pool.Blocked() != 0 {
+		t.Errorf("Expected no blocked tasks, got %d", pool.Blocked())
+	}
+}
+
+// TestDependencyFailurePropagation tests the cancel and run policies when a parent fails
+func TestDependencyFailurePropagation(t *testing.T) {
+	pool, st, gate := newDependencyPool(t)
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	pool.AddTask(ctx, log, &models.Task{ID: "parent", Title: "Parent", Type: "gate"})
+	pool.AddTask(ctx, log, &models.Task{ID: "child", Title: "Child", Type: "quick", DependsOn: []string{"parent"}})
+	pool.AddTask(ctx, log, &models.Task{ID: "grandchild", Title: "Grandchild", Type: "quick", DependsOn: []string{"child"}})
+	pool.AddTask(ctx, log, &models.Task{ID: "cleanup", Title: "Cleanup", Type: "quick", DependsOn: []string{"parent"}, OnDepFailure: models.DependencyRun})
+
+	gate <- errors.New("boom")
+
+	child := waitForTaskStatus(st, "child", models.Cancelled, 2*time.Second)
+	if child == nil || child.Error != "dependency parent failed" {
+		t.Fatalf("Expected child cancelled by its failed parent, got %+v", child)
+	}
+	if waitForTaskStatus(st, "grandchild", models.Cancelled, 2*time.Second) == nil {
+		t.Error("Expected cancellation to cascade to the grandchild")
+	}
+	if waitForTaskStatus(st, "cleanup", models.Completed, 2*time.Second) == nil {
+		t.Error("Expected the run-anyway task to run after its parent failed")
+	}
+}
+
+// TestDependencyValidation tests unknown parents, self references and cycles within a batch
+func TestDependencyValidation(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore())
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	if _, err := pool.AddTask(ctx, log, &models.Task{ID: "x", Title: "X", DependsOn: []string{"missing"}}); !errors.Is(err, ErrInvalidTask) {
+		t.Errorf("Expected ErrInvalidTask for an unknown parent, got %v", err)
+	}
+	if _, err := pool.AddTask(ctx, log, &models.Task{ID: "x", Title: "X", DependsOn: []string{"x"}}); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Expected ErrDependencyCycle for a self reference, got %v", err)
+	}
+	if _, err := pool.AddTask(ctx, log, &models.Task{ID: "x", Title: "X", OnDepFailure: "ignore"}); !errors.Is(err, ErrInvalidTask) {
+		t.Errorf("Expected ErrInvalidTask for an unknown policy, got %v", err)
+	}
+
+	tasks := batchOf(3)
+	tasks[0].DependsOn = []string{"batch-2"}
+	tasks[1].DependsOn = []string{"batch-0"}
+	tasks[2].DependsOn = []string{"batch-1"}
+	err := pool.AddBatch(ctx, log, tasks)
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) || !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("Expected a cycle BatchError, got %v", err)
+	}
+
+	// The same batch without the back edge is a valid chain in any order
+	tasks = batchOf(3)
+	tasks[0].DependsOn = []string{"batch-2"}
+	tasks[2].DependsOn = []string{"batch-1"}
+	if err := pool.AddBatch(ctx, log, tasks); err != nil {
+		t.Fatalf("AddBatch failed: %v", err)
+	}
+	if pool.Len() != 1 || pool.Blocked() != 2 {
+		t.Errorf("Expected 1 queued and 2 blocked, got %d and %d", pool.Len(), pool.Blocked())
+	}
+}
+
+// TestCancelBlockedTask tests that cancelling a blocked task cascades to its own dependents
+func TestCancelBlockedTask(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st)
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	pool.AddTask(ctx, log, &models.Task{ID: "a", Title: "A"})
+	pool.AddTask(ctx, log, &models.Task{ID: "b", Title: "B", DependsOn: []string{"a"}})
+	pool.AddTask(ctx, log, &models.Task{ID: "c", Title: "C", DependsOn: []string{"b"}})
+
+	task, err := pool.Cancel(ctx, "b")
+	if err != nil || task.Status != models.Cancelled {
+		t.Fatalf("Expected blocked task cancelled, got %+v, %v", task, err)
+	}
+	c, _ := st.GetTask(ctx, "c")
+	if c.Status != models.Cancelled || c.Error != "dependency b cancelled" {
+		t.Errorf("Expected dependent cancelled, got %s %q", c.Status, c.Error)
+	}
+	if pool.Blocked() != 0 {
+		t.Errorf("Expected no blocked tasks, got %d", pool.Blocked())
+	}
+}
+
+// TestRecoverBlockedTasks tests that blocked tasks survive a restart and are released if their parents finished meanwhile
+func TestRecoverBlockedTasks(t *testing.T) {
+	st := store.NewMemoryStore()
+	ctx := context.Background()
+	st.AddTask(ctx, &models.Task{ID: "done", Title: "Done", Status: models.Completed})
+	st.AddTask(ctx, &models.Task{ID: "open", Title: "Open", Status: models.Pending})
+	st.AddTask(ctx, &models.Task{ID: "ready", Title: "Ready", Status: models.Blocked, DependsOn: []string{"done"}})
+	st.AddTask(ctx, &models.Task{ID: "waiting", Title: "Waiting", Status: models.Blocked, DependsOn: []string{"done", "open"}})
+
+	pool := NewTaskPool(10, st)
+	if n, err := pool.Recover(ctx, logger.NewTestLogger()); err != nil || n != 3 {
+		t.Fatalf("Expected 3 recovered tasks, got %d, %v", n, err)
+	}
+	if ready, _ := st.GetTask(ctx, "ready"); ready.Status != models.Pending {
+		t.Errorf("Expected task with finished parents released, got %s", ready.Status)
+	}
+	if pool.Len() != 2 || pool.Blocked() != 1 {
+		t.Errorf("Expected 2 queued and 1 blocked, got %d and %d", pool.Len(), pool.Blocked())
+	}
+}
+
+// TestGraph tests that the graph covers the whole connected DAG
+func TestGraph(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore())
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	pool.AddTask(ctx, log, &models.Task{ID: "a", Title: "A"})
+	pool.AddTask(ctx, log, &models.Task{ID: "b", Title: "B"})
+	pool.AddTask(ctx, log, &models.Task{ID: "c", Title: "C", DependsOn: []string{"a", "b"}})
+	pool.AddTask(ctx, log, &models.Task{ID: "d", Title: "D", DependsOn: []string{"c"}})
+	pool.AddTask(ctx, log, &models.Task{ID: "unrelated", Title: "Unrelated"})
+
+	graph, err := pool.Graph(ctx, "a")
+	if err != nil {
+		t.Fatalf("Graph failed: %v", err)
+	}
+	if len(graph.Nodes) != 4 || len(graph.Edges) != 3 {
+		t.Fatalf("Expected 4 nodes and 3 edges, got %+v", graph)
+	}
+	if graph.Nodes[3].ID != "d" || graph.Nodes[3].Status != models.Blocked {
+		t.Errorf("Expected blocked leaf d last, got %+v", graph.Nodes[3])
+	}
+	if _, err := pool.Graph(ctx, "missing"); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,181 @@
+package taskpool
+
+import (
+	"container/heap"
+	"slices"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// heldKey names the task type or concurrency key a held task waits on.
+type heldKey struct {
+	key  bool // a concurrency key rather than a task type
+	name string
+}
+
+// holdFor reports what, if anything, task must wait on before it may run:
+// a pause of its type, or its type or concurrency key being at its limit.
+// Callers must hold p.mu.
+func (p *TaskPool) holdFor(task *models.Task) (k heldKey, paused, ok bool) {
+	name := taskTypeOf(task)
+	if p.pauses.types[name] {
+		return heldKey{name: name}, true, true
+	}
+	if p.concurrency.typeAtLimit(name) {
+		return heldKey{name: name}, false, true
+	}
+	if key := task.ConcurrencyKey; p.concurrency.keyAtLimit(key) {
+		return heldKey{key: true, name: key}, false, true
+	}
+	return heldKey{}, false, false
+}
+
+// nextRunnableLocked pops tenant's next task in q that may run now. Tasks
+// ahead of it that may not are held, so each is passed over only once
+// however long it waits. Callers must hold p.mu.
+func (p *TaskPool) nextRunnableLocked(q *taskQueue, tenant string) *models.Task {
+	for q.tenants[tenant] != nil {
+		item := q.take(tenant)
+		k, paused, ok := p.holdFor(item.task)
+		if !ok {
+			return item.task
+		}
+		p.holdLocked(q, item, k, paused)
+	}
+	return nil
+}
+
+// holdLocked sets item aside under k until releaseHeldLocked puts it back
+// in its queue's heaps, keeping its place in line. It still counts as
+// queued in q. Callers must hold p.mu.
+func (p *TaskPool) holdLocked(q *taskQueue, item *queueItem, k heldKey, paused bool) {
+	item.heldBy = k
+	item.heldPaused = paused
+	if p.held == nil {
+		p.held = make(map[heldKey]map[string]*queueItem)
+	}
+	if p.held[k] == nil {
+		p.held[k] = make(map[string]*queueItem)
+	}
+	p.held[k][item.task.ID] = item
+	if q.held == nil {
+		q.held = make(map[string]*queueItem)
+	}
+	q.held[item.task.ID] = item
+	if paused {
+		q.paused++
+	} else {
+		q.limited++
+	}
+}
+
+// unholdLocked forgets that item is held. Callers must hold p.mu.
+func (p *TaskPool) unholdLocked(q *taskQueue, item *queueItem) {
+	list := p.held[item.heldBy]
+	delete(list, item.task.ID)
+	if len(list) == 0 {
+		delete(p.held, item.heldBy)
+	}
+	delete(q.held, item.task.ID)
+	if item.heldPaused {
+		q.paused--
+	} else {
+		q.limited--
+	}
+}
+
+// removeHeldLocked takes the held task with the given ID out of q, if
+// there. Callers must hold p.mu.
+func (p *TaskPool) removeHeldLocked(q *taskQueue, id string) (*models.Task, bool) {
+	item, ok := q.held[id]
+	if !ok {
+		return nil, false
+	}
+	p.unholdLocked(q, item)
+	q.size--
+	return item.task, true
+}
+
+// releaseHeldLocked puts every task held under k back in its queue and
+// wakes the workers. Any that still may not run are held again, possibly
+// under another key, when a worker comes to them. Callers must hold p.mu.
+func (p *TaskPool) releaseHeldLocked(k heldKey) {
+	list := p.held[k]
+	if len(list) == 0 {
+		return
+	}
+	for _, item := range list {
+		q := p.queueFor(item.task)
+		p.unholdLocked(q, item)
+		q.insert(tenantOf(item.task), item)
+	}
+	p.signalLocked()
+}
+
+// releaseConcurrencyLocked counts task as no longer running and releases
+// the tasks held on its type or concurrency key if that brought them under
+// their limit. Callers must hold p.mu.
+func (p *TaskPool) releaseConcurrencyLocked(task *models.Task) {
+	p.concurrency.release(task)
+	if name := taskTypeOf(task); !p.pauses.types[name] && !p.concurrency.typeAtLimit(name) {
+		p.releaseHeldLocked(heldKey{name: name})
+	}
+	if key := task.ConcurrencyKey; key != "" && !p.concurrency.keyAtLimit(key) {
+		p.releaseHeldLocked(heldKey{key: true, name: key})
+	}
+}
+
+// releaseUnlimitedLocked releases every held task whose type or key is no
+// longer paused or at its limit, e.g. after the limits changed. Callers
+// must hold p.mu.
+func (p *TaskPool) releaseUnlimitedLocked() {
+	for k := range p.held {
+		if k.key && !p.concurrency.keyAtLimit(k.name) ||
+			!k.key && !p.pauses.types[k.name] && !p.concurrency.typeAtLimit(k.name) {
+			p.releaseHeldLocked(k)
+		}
+	}
+}
+
+// holdPausedTypeLocked holds every queued task of a newly paused type, so
+// that the queues' paused counts include them straight away, including
+// those already held on their concurrency key or their type's limit.
+// Callers must hold p.mu.
+func (p *TaskPool) holdPausedTypeLocked(name string) {
+	k := heldKey{name: name}
+	for _, q := range p.queues {
+		for _, item := range q.held {
+			if taskTypeOf(item.task) == name && !item.heldPaused {
+				p.unholdLocked(q, item)
+				p.holdLocked(q, item, k, true)
+			}
+		}
+		moved := false
+		for tenant, h := range q.tenants {
+			kept := h.items[:0]
+			for _, item := range h.items {
+				if taskTypeOf(item.task) == name {
+					delete(h.byID, item.task.ID)
+					p.holdLocked(q, item, k, true)
+					moved = true
+				} else {
+					item.index = len(kept)
+					kept = append(kept, item)
+				}
+			}
+			clear(h.items[len(kept):])
+			h.items = kept
+			heap.Init(h)
+			if h.Len() == 0 {
+				delete(q.tenants, tenant)
+			}
+		}
+		if moved {
+			q.waiting = slices.DeleteFunc(q.waiting, func(item *queueItem) bool { return q.held[item.task.ID] != nil })
+			for i, item := range q.waiting {
+				item.arrival = i
+			}
+			heap.Init(&q.waiting)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// DefaultIdempotencyWindow is how long an idempotency key deduplicates.
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+// idempotencyPurgeInterval caps how often expired keys are swept.
+const idempotencyPurgeInterval = time.Minute
+
+var (
+	ErrIdempotencyDisabled = errors.New("idempotency keys are not enabled")
+	ErrIdempotencyMismatch = errors.New("idempotency key was used for a different request")
+)
+
+type idempotency struct {
+	store     store.IdempotencyStore
+	window    time.Duration
+	lastPurge time.Time // guarded by TaskPool.mu
+}
+
+// WithIdempotency enables AddTaskIdempotent. Keys deduplicate submissions
+// for window after their first use and are then purged.
+func WithIdempotency(s store.IdempotencyStore, window time.Duration) Option {
+	return func(p *TaskPool) {
+		if window <= 0 {
+			window = DefaultIdempotencyWindow
+		}
+		p.idempotency = &idempotency{store: s, window: window}
+	}
+}
+
+// AddTaskIdempotent adds task unless key was already used within the
+// window, in which case the original task's ID is returned with replayed
+// set. requestHash identifies the request body; reusing a key for a
+// different request fails with ErrIdempotencyMismatch. If the task cannot be
+// added, the key is released so the client can retry. Keys are scoped to the
+// task's tenant, so tenants cannot collide or see each other's task IDs.
+func (p *TaskPool) AddTaskIdempotent(ctx context.Context, logger *logger.Logger, key, requestHash string, task *models.Task) (id string, replayed bool, err error) {
+	return p.AddTaskIdempotentWait(ctx, logger, key, requestHash, task, 0)
+}
+
+// AddTaskIdempotentWait is AddTaskIdempotent that waits for room like
+// AddTaskWait. The key stays claimed while it waits.
+func (p *TaskPool) AddTaskIdempotentWait(ctx context.Context, logger *logger.Logger, key, requestHash string, task *models.Task, maxWait time.Duration) (id string, replayed bool, err error) {
+	idem := p.idempotency
+	if idem == nil {
+		return "", false, ErrIdempotencyDisabled
+	}
+	key = tenantOf(task) + ":" + key
+	now := p.now().UTC()
+	p.purgeIdempotencyKeys(ctx, logger, now)
+
+	rec := &models.IdempotencyRecord{
+		Key:         key,
+		TaskID:      task.ID,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(idem.window),
+	}
+	existing, claimed, err := idem.store.ClaimIdempotencyKey(ctx, rec)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if !claimed {
+		if existing.RequestHash != requestHash {
+			return existing.TaskID, false, ErrIdempotencyMismatch
+		}
+		logger.Info("idempotent replay", "idempotency_key", key, "task_id", existing.TaskID)
+		return existing.TaskID, true, nil
+	}
+
+	id, err = p.AddTaskWait(ctx, logger, task, maxWait)
+	if err != nil {
+		if delErr := idem.store.DeleteIdempotencyKey(context.Background(), key); delErr != nil {
+			logger.Error("failed to release idempotency key", "idempotency_key", key, "error", delErr)
+		}
+		return "", false, err
+	}
+	return id, false, nil
+}
+
+// purgeIdempotencyKeys drops expired keys, at most once per purge interval.
+func (p *TaskPool) purgeIdempotencyKeys(ctx context.Context, logger *logger.Logger, now time.Time) {
+	idem := p.idempotency
+	p.mu.Lock()
+	due := now.Sub(idem.lastPurge) >= min(idem.window, idempotencyPurgeInterval)
+	if due {
+		idem.lastPurge = now
+	}
+	p.mu.Unlock()
+	if !due {
+		return
+	}
+	if n, err := idem.store.PurgeIdempotencyKeys(ctx, now); err != nil {
+		logger.Error("failed to purge idempotency keys", "error", err)
+	} else if n > 0 {
+		logger.Info("purged expired idempotency keys", "count", n)
+	}
+}
@@ -0,0 +1,70 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestAddTaskIdempotentReplay tests that a repeated key returns the original task within the window
+func TestAddTaskIdempotentReplay(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(5, st, WithIdempotency(st, time.Hour))
+	clock := newFakeClock()
+	pool.now = clock.now
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	id, replayed, err := pool.AddTaskIdempotent(ctx, log, "key-1", "hash", &models.Task{ID: "first", Title: "First"})
+	if err != nil || replayed || id != "first" {
+		t.Fatalf("Expected first submission to add the task, got %q, %v, %v", id, replayed, err)
+	}
+	id, replayed, err = pool.AddTaskIdempotent(ctx, log, "key-1", "hash", &models.Task{ID: "second", Title: "First"})
+	if err != nil || !replayed || id != "first" {
+		t.Fatalf("Expected replay of the first task, got %q, %v, %v", id, replayed, err)
+	}
+	if pool.Len() != 1 {
+		t.Errorf("Expected 1 queued task, got %d", pool.Len())
+	}
+
+	if _, _, err := pool.AddTaskIdempotent(ctx, log, "key-1", "other", &models.Task{ID: "third", Title: "Other"}); !errors.Is(err, ErrIdempotencyMismatch) {
+		t.Errorf("Expected ErrIdempotencyMismatch, got %v", err)
+	}
+
+	// Once the window has passed the key starts over
+	clock.advance(2 * time.Hour)
+	id, replayed, err = pool.AddTaskIdempotent(ctx, log, "key-1", "hash", &models.Task{ID: "fourth", Title: "First"})
+	if err != nil || replayed || id != "fourth" {
+		t.Errorf("Expected expired key to add a new task, got %q, %v, %v", id, replayed, err)
+	}
+}
+
+// TestAddTaskIdempotentReleasesKeyOnFailure tests that a rejected submission can be retried with the same key
+func TestAddTaskIdempotentReleasesKeyOnFailure(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(1, st, WithIdempotency(st, time.Hour))
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	pool.AddTask(ctx, log, &models.Task{ID: "filler", Title: "Filler"})
+	if _, _, err := pool.AddTaskIdempotent(ctx, log, "key-1", "hash", &models.Task{ID: "rejected", Title: "R"}); !errors.Is(err, ErrTaskQueueFull) {
+		t.Fatalf("Expected ErrTaskQueueFull, got %v", err)
+	}
+
+	pool.mu.Lock()
+	pool.popLocked()
+	pool.mu.Unlock()
+	id, replayed, err := pool.AddTaskIdempotent(ctx, log, "key-1", "hash", &models.Task{ID: "retried", Title: "R"})
+	if err != nil || replayed || id != "retried" {
+		t.Errorf("Expected retry to add the task, got %q, %v, %v", id, replayed, err)
+	}
+
+	if _, _, err := NewTaskPool(1, st).AddTaskIdempotent(ctx, log, "k", "h", &models.Task{ID: "x"}); !errors.Is(err, ErrIdempotencyDisabled) {
+		t.Errorf("Expected ErrIdempotencyDisabled, got %v", err)
+	}
+}
@@ -2,81 +2,245 @@ package taskpool
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/shayanmkpr/task-pool/internal/events"
 	"github.com/shayanmkpr/task-pool/internal/logger"
 	"github.com/shayanmkpr/task-pool/internal/models"
 	"github.com/shayanmkpr/task-pool/internal/store"
 )
 
-type workerManager struct {
-	workers []*Worker
-	store   *store.MemoryStore
+// MaxWorkers bounds the number of workers Resize will run.
+const MaxWorkers = 1024
+
+var (
+	ErrInvalidWorkerCount = fmt.Errorf("worker count must be between 0 and %d", MaxWorkers)
+	ErrWorkerNotFound     = errors.New("worker not found")
+)
+
+// WorkerManager runs the pool's workers and resizes the set at runtime.
+type WorkerManager struct {
+	mu       sync.Mutex
+	workers  []*Worker
+	retiring []*Worker // stopped, but possibly still finishing a task
+	nextID   int
+	queues   QueueWeights // assignment for workers started from now on
+	store    store.TaskStore
+	pool     *TaskPool
 }
 
-func NewWorkerManager(workerCount int, store *store.MemoryStore) *workerManager {
-	return &workerManager{
+// WorkerStats describes the worker pool.
+type WorkerStats struct {
+	Count    int `json:"count"` // workers taking tasks
+	Busy     int `json:"busy"`
+	Idle     int `json:"idle"`
+	Retiring int `json:"retiring"` // retired workers still finishing their task
+}
+
+func NewWorkerManager(workerCount int, store store.TaskStore) *WorkerManager {
+	return &WorkerManager{
 		workers: make([]*Worker, workerCount),
 		store:   store,
 	}
 }
 
-func (wm *workerManager) InitiateWorkers(pool *TaskPool) {
+func (wm *WorkerManager) InitiateWorkers(pool *TaskPool) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	wm.pool = pool
 	for i := range wm.workers {
 		w := NewWorker(i+1, pool)
 		w.Start()
 		wm.workers[i] = w
 	}
+	wm.nextID = len(wm.workers)
 }
 
-func (wm *workerManager) MonitorWorkers(log *logger.Logger) {
-	for _, worker := range wm.workers {
-		w := worker
-		go func() {
-			for assigned := range w.Assigned {
-				if assigned != nil {
-					fmt.Printf("worker %d assigned to %v \n", w.ID, assigned.ID)
-					log.Info(fmt.Sprintf("worker %d assigned to %v \n", w.ID, assigned.ID))
-				} else {
-					fmt.Printf("worker %d is free \n", w.ID)
-					log.Info(fmt.Sprintf("worker %d is free\n", w.ID))
+// Resize grows or shrinks the pool to n workers. New workers start right
+// away. When shrinking, idle workers are retired first; a busy worker that
+// has to go finishes its current task before it exits. It must be called
+// after InitiateWorkers.
+func (wm *WorkerManager) Resize(n int) (WorkerStats, error) {
+	if n < 0 || n > MaxWorkers {
+		return WorkerStats{}, ErrInvalidWorkerCount
+	}
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	for len(wm.workers) < n {
+		wm.nextID++
+		w := NewWorker(wm.nextID, wm.pool)
+		w.SetQueues(wm.queues)
+		w.Start()
+		wm.workers = append(wm.workers, w)
+	}
+	if excess := len(wm.workers) - n; excess > 0 {
+		busy := make([]bool, len(wm.workers))
+		for i, w := range wm.workers {
+			busy[i] = w.Busy()
+		}
+		// Retire idle workers first, then busy ones, newest first in each.
+		retire := make(map[*Worker]bool, excess)
+		for _, wantBusy := range []bool{false, true} {
+			for i := len(wm.workers) - 1; i >= 0 && len(retire) < excess; i-- {
+				if busy[i] == wantBusy {
+					retire[wm.workers[i]] = true
 				}
 			}
-		}()
+		}
+		wm.workers = slices.DeleteFunc(wm.workers, func(w *Worker) bool {
+			if !retire[w] {
+				return false
+			}
+			w.Stop()
+			wm.retiring = append(wm.retiring, w)
+			return true
+		})
 	}
+	return wm.statsLocked(), nil
 }
 
-func (wm *workerManager) WaitForCompletion(ctx context.Context, log *logger.Logger, waitingTime time.Duration) {
-	for {
-		select {
-		case <-ctx.Done():
-			log.Info("Context cancelled during WaitForCompletion", "error", ctx.Err())
-			return
-		default:
-		}
+// WorkerInfo describes one worker.
+type WorkerInfo struct {
+	ID     int          `json:"id"`
+	Busy   bool         `json:"busy"`
+	Queues QueueWeights `json:"queues,omitempty"` // empty means every queue
+}
 
-		allDone := true
-		tasks, err := wm.store.ListTasks(ctx)
-		if err != nil {
-			log.Error("failed to retrieve tasks", "error", err)
-			return
+// Workers lists the workers taking tasks, oldest first.
+func (wm *WorkerManager) Workers() []WorkerInfo {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	infos := make([]WorkerInfo, 0, len(wm.workers))
+	for _, w := range wm.workers {
+		infos = append(infos, WorkerInfo{ID: w.ID, Busy: w.Busy(), Queues: w.Queues()})
+	}
+	return infos
+}
+
+// AssignQueues assigns every worker, including those started later, to the
+// given queues. Nil weights put them back on every queue. It must be called
+// after InitiateWorkers.
+func (wm *WorkerManager) AssignQueues(weights QueueWeights) error {
+	if err := wm.pool.CheckQueueWeights(weights); err != nil {
+		return err
+	}
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	wm.queues = maps.Clone(weights)
+	for _, w := range wm.workers {
+		w.SetQueues(weights)
+	}
+	wm.pool.wakeWorkers()
+	return nil
+}
+
+// AssignWorkerQueues assigns one worker to the given queues.
+func (wm *WorkerManager) AssignWorkerQueues(id int, weights QueueWeights) (WorkerInfo, error) {
+	if err := wm.pool.CheckQueueWeights(weights); err != nil {
+		return WorkerInfo{}, err
+	}
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	i := slices.IndexFunc(wm.workers, func(w *Worker) bool { return w.ID == id })
+	if i < 0 {
+		return WorkerInfo{}, ErrWorkerNotFound
+	}
+	w := wm.workers[i]
+	w.SetQueues(weights)
+	wm.pool.wakeWorkers()
+	return WorkerInfo{ID: w.ID, Busy: w.Busy(), Queues: w.Queues()}, nil
+}
+
+// Stats reports how many workers there are and what they are doing.
+func (wm *WorkerManager) Stats() WorkerStats {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	return wm.statsLocked()
+}
+
+func (wm *WorkerManager) statsLocked() WorkerStats {
+	// Forget retired workers that have exited.
+	wm.retiring = slices.DeleteFunc(wm.retiring, (*Worker).Exited)
+
+	stats := WorkerStats{Count: len(wm.workers), Retiring: len(wm.retiring)}
+	for _, w := range wm.workers {
+		if w != nil && w.Busy() {
+			stats.Busy++
 		}
-		for _, t := range tasks {
-			if t.Status != models.Completed {
-				allDone = false
-				break
+	}
+	stats.Idle = stats.Count - stats.Busy
+	return stats
+}
+
+// MonitorWorkers logs worker hand-offs from the pool's event bus. It must be
+// called after InitiateWorkers.
+func (wm *WorkerManager) MonitorWorkers(log *logger.Logger) {
+	bus := wm.pool.Events
+	go func() {
+		var last uint64
+		for {
+			// A monitor that falls behind is cut off by the bus; pick up
+			// where it left off.
+			sub, backlog, _ := bus.Subscribe(last)
+			for _, e := range backlog {
+				last = e.ID
+				logWorkerEvent(log, e)
+			}
+			for e := range sub.C {
+				last = e.ID
+				logWorkerEvent(log, e)
 			}
 		}
-		if allDone {
+	}()
+}
+
+func logWorkerEvent(log *logger.Logger, e events.Event) {
+	switch {
+	case e.Type == events.WorkerIdle:
+		fmt.Printf("worker %d is free \n", e.WorkerID)
+		log.Info(fmt.Sprintf("worker %d is free\n", e.WorkerID))
+	case e.Type == events.TaskStatus && e.Status == models.Running:
+		fmt.Printf("worker %d assigned to %v \n", e.WorkerID, e.TaskID)
+		log.Info(fmt.Sprintf("worker %d assigned to %v \n", e.WorkerID, e.TaskID))
+	}
+}
+
+// WaitForCompletion waits until no task is running, polling every
+// waitingTime, or until ctx is done. Queued, scheduled and blocked tasks are
+// not waited for: they are in the store and Recover picks them up on the
+// next start. Stop the workers first so that they take no more tasks.
+func (wm *WorkerManager) WaitForCompletion(ctx context.Context, log *logger.Logger, waitingTime time.Duration) {
+	wm.mu.Lock()
+	pool := wm.pool
+	wm.mu.Unlock()
+	if pool == nil {
+		return
+	}
+	for pool.inFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			log.Info("Context cancelled during WaitForCompletion", "error", ctx.Err())
 			return
+		case <-time.After(waitingTime):
 		}
-		time.Sleep(waitingTime)
 	}
 }
 
-func (wm *workerManager) ForceStopWorkers() {
+// ForceStopWorkers stops every worker; each finishes the task it is running
+// first. The manager is left with no workers, so it is safe to call again
+// or to Resize afterwards.
+func (wm *WorkerManager) ForceStopWorkers() {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
 	for _, w := range wm.workers {
 		w.Stop()
 	}
+	wm.retiring = append(wm.retiring, wm.workers...)
+	wm.workers = nil
 }
@@ -1,9 +1,13 @@
 package taskpool
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
 	"github.com/shayanmkpr/task-pool/internal/store"
 )
 
@@ -57,3 +61,110 @@ func TestForceStopWorkers(t *testing.T) {
 	// Give workers time to stop
 	time.Sleep(100 * time.Millisecond)
 }
+
+// TestForceStopThenResize tests that stopping twice is harmless and that workers can be started again afterwards
+func TestForceStopThenResize(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st)
+	pool.Handlers.Register("echo", func(ctx context.Context, task *models.Task) (any, error) { return nil, nil })
+	manager := NewWorkerManager(2, st)
+	manager.InitiateWorkers(pool)
+
+	manager.ForceStopWorkers()
+	manager.ForceStopWorkers()
+	if stats := manager.Stats(); stats.Count != 0 {
+		t.Fatalf("Expected no workers after stopping, got %+v", stats)
+	}
+
+	stats, err := manager.Resize(1)
+	if err != nil || stats.Count != 1 {
+		t.Fatalf("Expected 1 worker after resizing, got %+v, %v", stats, err)
+	}
+	defer manager.ForceStopWorkers()
+	pool.AddTask(context.Background(), logger.NewTestLogger(), &models.Task{ID: "after", Title: "After", Type: "echo"})
+	if waitForTaskStatus(st, "after", models.Completed, 2*time.Second) == nil {
+		t.Error("Task did not run on the resized workers")
+	}
+}
+
+// TestWaitForCompletion tests that shutdown waits for running tasks but not for scheduled ones
+func TestWaitForCompletion(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st)
+	manager := NewWorkerManager(1, st)
+	manager.InitiateWorkers(pool)
+	manager.ForceStopWorkers()
+
+	runAt := time.Now().Add(time.Hour)
+	if _, err := pool.AddTask(context.Background(), logger.NewTestLogger(), &models.Task{ID: "later", Title: "Later", RunAt: &runAt}); err != nil {
+		t.Fatal(err)
+	}
+	pool.mu.Lock()
+	pool.running["busy"] = func(error) {}
+	pool.mu.Unlock()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pool.mu.Lock()
+		delete(pool.running, "busy")
+		pool.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	manager.WaitForCompletion(ctx, logger.NewTestLogger(), 10*time.Millisecond)
+	if ctx.Err() != nil {
+		t.Fatal("Expected WaitForCompletion to return once nothing was running")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected WaitForCompletion to wait for the running task, returned after %s", elapsed)
+	}
+}
+
+// TestResizeWorkers tests growing the pool and shrinking it, idle workers first
+func TestResizeWorkers(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st)
+	release := make(chan struct{})
+	pool.Handlers.Register("hold", func(ctx context.Context, task *models.Task) (any, error) {
+		<-release
+		return nil, nil
+	})
+	manager := NewWorkerManager(1, st)
+	manager.InitiateWorkers(pool)
+	defer manager.ForceStopWorkers()
+
+	if stats, err := manager.Resize(3); err != nil || stats.Count != 3 || stats.Idle != 3 {
+		t.Fatalf("Expected 3 idle workers, got %+v, %v", stats, err)
+	}
+	if _, err := manager.Resize(MaxWorkers + 1); !errors.Is(err, ErrInvalidWorkerCount) {
+		t.Errorf("Expected ErrInvalidWorkerCount, got %v", err)
+	}
+
+	pool.AddTask(context.Background(), logger.NewTestLogger(), &models.Task{ID: "held", Title: "Held", Type: "hold"})
+	waitForTaskStatus(st, "held", models.Running, 2*time.Second)
+	time.Sleep(20 * time.Millisecond) // let the worker mark itself busy
+
+	// Shrinking to one keeps the busy worker
+	stats, _ := manager.Resize(1)
+	if stats.Count != 1 || stats.Busy != 1 {
+		t.Fatalf("Expected the busy worker to stay, got %+v", stats)
+	}
+
+	// Shrinking to zero retires it only once its task is done
+	stats, _ = manager.Resize(0)
+	if stats.Count != 0 || stats.Retiring == 0 {
+		t.Fatalf("Expected a retiring worker, got %+v", stats)
+	}
+	close(release)
+	if waitForTaskStatus(st, "held", models.Completed, 2*time.Second) == nil {
+		t.Fatal("Busy worker did not finish its task after being retired")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for manager.Stats().Retiring != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stats := manager.Stats(); stats.Retiring != 0 {
+		t.Errorf("Expected every retired worker to exit, got %+v", stats)
+	}
+}
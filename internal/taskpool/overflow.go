@@ -0,0 +1,250 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// EnableOverflow gives every queue an on-disk overflow under dir. A queue
+// with an overflow never turns submissions away for lack of room: once its
+// in-memory buffer is full new tasks are appended to disk, and they are
+// moved back into memory, oldest first, as workers make room. Tenant quotas
+// still apply and count spilled tasks.
+//
+// Tasks spilled before a restart are picked up again: those the store no
+// longer has as pending (cancelled, or already run) are dropped, and those
+// it lost are written back. They move into memory once workers ask for
+// work, so call EnableOverflow and then Recover, which skips spilled tasks,
+// before starting workers to keep recovered tasks ahead of them. Call
+// CloseOverflow on shutdown.
+//
+// Refilling runs on its own goroutine, woken whenever a worker takes a task
+// from a queue with spilled tasks.
+func (p *TaskPool) EnableOverflow(ctx context.Context, logger *logger.Logger, dir string, opts store.SegmentOptions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.spilled == nil {
+		p.spilled = make(map[string]bool)
+	}
+	for _, name := range p.queueNames {
+		q := p.queues[name]
+		spill, err := store.OpenSegmentQueue(filepath.Join(dir, url.PathEscape(name)), opts)
+		if err != nil {
+			return fmt.Errorf("failed to open overflow for queue %q: %w", name, err)
+		}
+		q.overflow = spill
+		err = spill.Scan(func(task *models.Task) error {
+			stored, err := p.Store.GetTask(ctx, task.ID)
+			if errors.Is(err, store.ErrTaskNotFound) {
+				stored = task
+				err = p.Store.AddTask(ctx, task)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to restore spilled task %s: %w", task.ID, err)
+			}
+			if stored.Status != models.Pending || p.spilled[task.ID] {
+				return nil
+			}
+			p.spilled[task.ID] = true
+			q.spilled++
+			p.tenantLocked(tenantOf(task)).spilled++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if q.spilled > 0 {
+			logger.Info("restored overflow", "queue", name, "tasks", q.spilled)
+		}
+	}
+	if p.refiller == nil {
+		p.refiller = &refiller{
+			logger: logger,
+			kick:   make(chan struct{}, 1),
+			stop:   make(chan struct{}),
+			done:   make(chan struct{}),
+		}
+		go p.runRefiller(p.refiller)
+	}
+	p.signalLocked()
+	return nil
+}
+
+// CloseOverflow closes the queues' overflow files. Spilled tasks stay on
+// disk for the next EnableOverflow.
+func (p *TaskPool) CloseOverflow() error {
+	p.mu.Lock()
+	r := p.refiller
+	p.refiller = nil
+	p.mu.Unlock()
+	if r != nil {
+		close(r.stop)
+		<-r.done
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for _, q := range p.queues {
+		if q.overflow != nil {
+			errs = append(errs, q.overflow.Close())
+			q.overflow = nil
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// admitLocked queues a newly accepted task, spilling it to disk instead if
+// its queue's buffer is full, or if earlier tasks are already waiting on
+// disk so that it cannot overtake them. Callers must hold p.mu.
+func (p *TaskPool) admitLocked(task *models.Task) {
+	q := p.queueFor(task)
+	if q.overflow != nil && (q.spilled > 0 || q.Len()+q.reserved >= p.capacityOf(q)) {
+		// If the write fails the task is still safe in the store, so keep
+		// it in memory over capacity rather than lose it.
+		if err := q.overflow.Push(task); err == nil {
+			p.spilled[task.ID] = true
+			q.spilled++
+			p.tenantLocked(tenantOf(task)).spilled++
+			return
+		}
+	}
+	p.enqueueLocked(task)
+}
+
+// refiller moves spilled tasks back into memory on a goroutine of its own,
+// so neither dispatch nor anything else holding p.mu waits on the overflow
+// files or the store.
+type refiller struct {
+	logger *logger.Logger
+	kick   chan struct{} // buffered, so a kick during a pass asks for another
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// kickRefillLocked asks the refiller for a pass. Callers must hold p.mu.
+func (p *TaskPool) kickRefillLocked() {
+	if p.refiller == nil {
+		return
+	}
+	select {
+	case p.refiller.kick <- struct{}{}:
+	default:
+	}
+}
+
+func (p *TaskPool) runRefiller(r *refiller) {
+	defer close(r.done)
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-r.kick:
+		}
+		for _, name := range p.queueNames {
+			p.refill(r.logger, p.queues[name])
+		}
+	}
+}
+
+// refill moves spilled tasks back into q's buffer while it has room. Tasks
+// cancelled while on disk are skipped. Records are read, and bad ones
+// looked up in the store, without p.mu.
+func (p *TaskPool) refill(logger *logger.Logger, q *taskQueue) {
+	for {
+		p.mu.Lock()
+		room := q.spilled > 0 && q.Len() < p.capacityOf(q)
+		p.mu.Unlock()
+		if !room {
+			return
+		}
+
+		task, err := q.overflow.Pop()
+		if errors.Is(err, store.ErrBadRecord) {
+			// The record is gone from disk but the store still has the
+			// task, so queue it from there. Without an ID the counts are
+			// put right by reconcileSpilled once the overflow is empty.
+			logger.Error("failed to read spilled task", "queue", q.name, "error", err)
+			if task == nil {
+				continue
+			}
+			if task, err = p.Store.GetTask(context.Background(), task.ID); err != nil {
+				continue
+			}
+		} else if err != nil {
+			return // try again on the next pass
+		}
+		if task == nil {
+			p.reconcileSpilled(logger, q)
+			return
+		}
+
+		p.mu.Lock()
+		if p.spilled[task.ID] {
+			delete(p.spilled, task.ID)
+			q.spilled--
+			p.tenantLocked(tenantOf(task)).spilled--
+			p.enqueueLocked(task)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// reconcileSpilled is called when q's overflow is empty but tasks are still
+// counted as spilled to it, which happens when a record could not be read
+// back. It queues those tasks from the store instead, oldest first. Tasks
+// spilled since the overflow was found empty are picked up too, and their
+// records skipped later.
+func (p *TaskPool) reconcileSpilled(logger *logger.Logger, q *taskQueue) {
+	p.mu.Lock()
+	var ids []string
+	if q.spilled > 0 {
+		for id := range p.spilled {
+			ids = append(ids, id)
+		}
+	}
+	p.mu.Unlock()
+
+	var tasks []*models.Task
+	for _, id := range ids {
+		task, err := p.Store.GetTask(context.Background(), id)
+		if err != nil {
+			logger.Error("failed to look up spilled task", "queue", q.name, "task_id", id, "error", err)
+			continue
+		}
+		if p.queueFor(task) == q {
+			tasks = append(tasks, task)
+		}
+	}
+	slices.SortFunc(tasks, func(a, b *models.Task) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, task := range tasks {
+		if !p.spilled[task.ID] {
+			continue // cancelled or refilled meanwhile
+		}
+		p.unspillLocked(task)
+		if task.Status == models.Pending {
+			p.enqueueLocked(task)
+		}
+	}
+}
+
+// unspillLocked forgets a spilled task, which stays on disk to be skipped
+// by refill. Callers must hold p.mu.
+func (p *TaskPool) unspillLocked(task *models.Task) {
+	delete(p.spilled, task.ID)
+	p.queueFor(task).spilled--
+	t := p.tenantLocked(tenantOf(task))
+	t.spilled--
+	p.forgetIdleLocked(t)
+}
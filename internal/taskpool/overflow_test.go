@@ -0,0 +1,166 @@
+package taskpool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+func newOverflowPool(t *testing.T, st store.TaskStore, dir string) *TaskPool {
+	t.Helper()
+	pool := NewTaskPool(2, st, WithAgingInterval(0))
+	if err := pool.EnableOverflow(context.Background(), logger.NewTestLogger(), dir, store.SegmentOptions{}); err != nil {
+		t.Fatalf("EnableOverflow failed: %v", err)
+	}
+	t.Cleanup(func() { pool.CloseOverflow() })
+	return pool
+}
+
+// drainRefilled is drainOrder for a pool with overflow: before each pop it
+// waits for the refiller to fill every queue it can.
+func drainRefilled(t *testing.T, p *TaskPool) []string {
+	t.Helper()
+	var ids []string
+	for {
+		deadline := time.Now().Add(2 * time.Second)
+		for !p.refilled() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for a refill after %v", ids)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		p.mu.Lock()
+		task := p.popLocked()
+		p.mu.Unlock()
+		if task == nil {
+			return ids
+		}
+		ids = append(ids, task.ID)
+	}
+}
+
+// refilled reports whether every queue is either full or has nothing spilled.
+func (p *TaskPool) refilled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, q := range p.queues {
+		if q.spilled > 0 && q.Len() < p.capacityOf(q) {
+			return false
+		}
+	}
+	return true
+}
+
+// TestOverflowSpillAndRefill tests that tasks beyond capacity spill to disk and are dispatched in submission order
+func TestOverflowSpillAndRefill(t *testing.T) {
+	pool := newOverflowPool(t, store.NewMemoryStore(), t.TempDir())
+	for i := range 6 {
+		addWithPriority(t, pool, fmt.Sprintf("t%d", i), 0)
+	}
+	// Priority only applies once a task is back in memory, so this waits
+	// behind t0-t4 and then overtakes t5
+	addWithPriority(t, pool, "urgent", 9)
+
+	stats := pool.Stats()
+	if stats.Queued != 2 || stats.Overflow != 5 {
+		t.Fatalf("Expected 2 queued and 5 spilled, got %+v", stats)
+	}
+	if _, err := pool.Cancel(context.Background(), "t3"); err != nil {
+		t.Fatalf("Cancel of a spilled task failed: %v", err)
+	}
+	if task, _ := pool.Store.GetTask(context.Background(), "t3"); task.Status != models.Cancelled {
+		t.Errorf("Expected t3 to be cancelled, got %s", task.Status)
+	}
+
+	want := []string{"t0", "t1", "t2", "t4", "urgent", "t5"}
+	if got := drainRefilled(t, pool); !slices.Equal(got, want) {
+		t.Errorf("Expected order %v, got %v", want, got)
+	}
+	if stats := pool.Stats(); stats.Overflow != 0 {
+		t.Errorf("Expected an empty overflow, got %d", stats.Overflow)
+	}
+}
+
+// TestOverflowSurvivesRestart tests that spilled tasks come back after a restart, behind the recovered ones
+func TestOverflowSurvivesRestart(t *testing.T) {
+	st := store.NewMemoryStore()
+	dir := t.TempDir()
+	pool := newOverflowPool(t, st, dir)
+	for i := range 5 {
+		addWithPriority(t, pool, fmt.Sprintf("t%d", i), 0)
+	}
+	pool.CloseOverflow()
+
+	// Cancelled while the process was down
+	task, _ := st.GetTask(context.Background(), "t4")
+	task.Status = models.Cancelled
+	st.UpdateTask(context.Background(), task)
+
+	restarted := newOverflowPool(t, st, dir)
+	if n, err := restarted.Recover(context.Background(), logger.NewTestLogger()); err != nil || n != 2 {
+		t.Fatalf("Expected 2 recovered tasks, got %d (%v)", n, err)
+	}
+	if stats := restarted.QueueStats()[0]; stats.Queued != 2 || stats.Overflow != 2 {
+		t.Fatalf("Expected 2 queued and 2 spilled, got %+v", stats)
+	}
+	want := []string{"t0", "t1", "t2", "t3"}
+	if got := drainRefilled(t, restarted); !slices.Equal(got, want) {
+		t.Errorf("Expected order %v, got %v", want, got)
+	}
+}
+
+// TestOverflowBadRecords tests that tasks whose overflow records cannot be read are queued from the store instead
+func TestOverflowBadRecords(t *testing.T) {
+	st := store.NewMemoryStore()
+	dir := t.TempDir()
+	pool := newOverflowPool(t, st, dir)
+	for i := range 5 {
+		addWithPriority(t, pool, fmt.Sprintf("t%d", i), 0)
+	}
+
+	// Nothing has been read back yet, so the records can be replaced: t2's
+	// keeps its ID and t3's does not
+	t4, _ := st.GetTask(context.Background(), "t4")
+	good, _ := json.Marshal(t4)
+	segs, _ := filepath.Glob(filepath.Join(dir, DefaultQueue, "*.seg"))
+	records := "{\"id\":\"t2\",\"priority\":\"high\"}\ngarbage\n" + string(good) + "\n"
+	if err := os.WriteFile(segs[0], []byte(records), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"t0", "t1", "t2", "t4", "t3"}
+	if got := drainRefilled(t, pool); !slices.Equal(got, want) {
+		t.Errorf("Expected order %v, got %v", want, got)
+	}
+	if stats := pool.TenantStats(); stats[0].Overflow != 0 || pool.Stats().Overflow != 0 {
+		t.Errorf("Expected nothing counted as spilled, got %+v", stats)
+	}
+}
+
+// TestOverflowTenantQuota tests that spilled tasks count against their tenant's quota
+func TestOverflowTenantQuota(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(1, st, WithTenants(map[string]TenantLimits{DefaultTenant: {Quota: 3}}))
+	if err := pool.EnableOverflow(context.Background(), logger.NewTestLogger(), t.TempDir(), store.SegmentOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	defer pool.CloseOverflow()
+	for i := range 3 {
+		addWithPriority(t, pool, fmt.Sprintf("t%d", i), 0)
+	}
+	if _, err := pool.AddTask(context.Background(), logger.NewTestLogger(), &models.Task{Title: "Over"}); err == nil {
+		t.Error("Expected the tenant quota to be enforced over spilled tasks")
+	}
+	if stats := pool.TenantStats(); stats[0].Queued != 1 || stats[0].Overflow != 2 {
+		t.Errorf("Unexpected tenant stats: %+v", stats)
+	}
+}
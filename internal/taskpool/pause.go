@@ -0,0 +1,170 @@
+package taskpool
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// pauses is what dispatching is paused for. Paused tasks stay in their
+// queue, in order: queues paused as a whole are skipped by popLocked, and
+// tasks of a paused type are held until resumed.
+type pauses struct {
+	global    bool
+	types     map[string]bool
+	queues    map[string]bool
+	updatedAt time.Time
+	store     store.PauseStore // nil keeps pauses in memory only
+}
+
+// WithPauses persists pause state in s so it survives a restart. Call
+// LoadPauses before starting workers to restore it.
+func WithPauses(s store.PauseStore) Option {
+	return func(p *TaskPool) {
+		p.pauses.store = s
+	}
+}
+
+// Pause stops workers from picking up tasks of taskType, or every task if
+// taskType is empty. Tasks are still accepted and queued; running tasks
+// are not interrupted.
+func (p *TaskPool) Pause(ctx context.Context, taskType string) (*models.PauseState, error) {
+	return p.setPaused(ctx, func(s *models.PauseState) {
+		if taskType == "" {
+			s.Global = true
+		} else {
+			s.Types = append(s.Types, taskType)
+		}
+	}, false)
+}
+
+// Resume undoes Pause for taskType, or the global pause if taskType is
+// empty. Resuming the global pause leaves per-type and per-queue pauses in
+// place.
+func (p *TaskPool) Resume(ctx context.Context, taskType string) (*models.PauseState, error) {
+	return p.setPaused(ctx, func(s *models.PauseState) {
+		if taskType == "" {
+			s.Global = false
+		} else {
+			s.Types = slices.DeleteFunc(s.Types, func(t string) bool { return t == taskType })
+		}
+	}, true)
+}
+
+// PauseQueue stops workers from picking up tasks from the named queue.
+func (p *TaskPool) PauseQueue(ctx context.Context, name string) (*models.PauseState, error) {
+	if _, ok := p.queues[name]; !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownQueue, name)
+	}
+	return p.setPaused(ctx, func(s *models.PauseState) {
+		s.Queues = append(s.Queues, name)
+	}, false)
+}
+
+// ResumeQueue undoes PauseQueue.
+func (p *TaskPool) ResumeQueue(ctx context.Context, name string) (*models.PauseState, error) {
+	if _, ok := p.queues[name]; !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownQueue, name)
+	}
+	return p.setPaused(ctx, func(s *models.PauseState) {
+		s.Queues = slices.DeleteFunc(s.Queues, func(q string) bool { return q == name })
+	}, true)
+}
+
+// setPaused applies change to the current state, saves the result and
+// then puts it into effect. Resuming wakes the workers.
+func (p *TaskPool) setPaused(ctx context.Context, change func(*models.PauseState), resumed bool) (*models.PauseState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	current := p.pauseStateLocked()
+	state := current.Clone()
+	change(state)
+	state.Types = sortedSet(state.Types)
+	state.Queues = sortedSet(state.Queues)
+	if state.Global == current.Global && slices.Equal(state.Types, current.Types) && slices.Equal(state.Queues, current.Queues) {
+		return current, nil
+	}
+
+	state.UpdatedAt = p.now().UTC()
+	if p.pauses.store != nil {
+		if err := p.pauses.store.SetPauseState(ctx, state); err != nil {
+			return nil, fmt.Errorf("failed to save pause state: %w", err)
+		}
+	}
+	p.applyPausesLocked(state)
+	if resumed {
+		p.signalLocked()
+	}
+	return p.pauseStateLocked(), nil
+}
+
+// PauseState returns what dispatching is currently paused for.
+func (p *TaskPool) PauseState() *models.PauseState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pauseStateLocked()
+}
+
+func (p *TaskPool) pauseStateLocked() *models.PauseState {
+	return &models.PauseState{
+		Global:    p.pauses.global,
+		Types:     sortedSet(slices.Collect(maps.Keys(p.pauses.types))),
+		Queues:    sortedSet(slices.Collect(maps.Keys(p.pauses.queues))),
+		UpdatedAt: p.pauses.updatedAt,
+	}
+}
+
+func (p *TaskPool) applyPausesLocked(state *models.PauseState) {
+	p.pauses.global = state.Global
+	was := p.pauses.types
+	p.pauses.types = make(map[string]bool, len(state.Types))
+	for _, t := range state.Types {
+		p.pauses.types[t] = true
+		if !was[t] {
+			p.holdPausedTypeLocked(t)
+		}
+	}
+	for t := range was {
+		if !p.pauses.types[t] {
+			p.releaseHeldLocked(heldKey{name: t})
+		}
+	}
+	p.pauses.queues = make(map[string]bool, len(state.Queues))
+	for _, q := range state.Queues {
+		p.pauses.queues[q] = true
+	}
+	p.pauses.updatedAt = state.UpdatedAt
+}
+
+// sortedSet sorts names and drops duplicates. It never returns nil, so the
+// lists encode as [] rather than null.
+func sortedSet(names []string) []string {
+	names = slices.Compact(slices.Sorted(slices.Values(names)))
+	if names == nil {
+		names = []string{}
+	}
+	return names
+}
+
+// LoadPauses restores the pause state saved by WithPauses. It does nothing
+// if pauses are not persisted.
+func (p *TaskPool) LoadPauses(ctx context.Context) error {
+	if p.pauses.store == nil {
+		return nil
+	}
+	state, err := p.pauses.store.GetPauseState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pause state: %w", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.applyPausesLocked(state)
+	p.signalLocked()
+	return nil
+}
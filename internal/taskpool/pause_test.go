@@ -0,0 +1,85 @@
+package taskpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestPauseTaskType tests that a paused type stays queued while other tasks run, and runs once resumed
+func TestPauseTaskType(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewTestLogger()
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st, WithPauses(st))
+	noop := func(ctx context.Context, task *models.Task) (any, error) { return nil, nil }
+	pool.Handlers.Register("report", noop)
+	pool.Handlers.Register("email", noop)
+	manager := NewWorkerManager(2, st)
+	manager.InitiateWorkers(pool)
+	defer manager.ForceStopWorkers()
+
+	if _, err := pool.Pause(ctx, "report"); err != nil {
+		t.Fatal(err)
+	}
+	pool.AddTask(ctx, log, &models.Task{ID: "report", Title: "Report", Type: "report", Priority: MaxPriority})
+	pool.AddTask(ctx, log, &models.Task{ID: "email", Title: "Email", Type: "email"})
+
+	if waitForTaskStatus(st, "email", models.Completed, 2*time.Second) == nil {
+		t.Fatal("Task of an unpaused type did not run")
+	}
+	if task, _ := st.GetTask(ctx, "report"); task.Status != models.Pending {
+		t.Fatalf("Expected the paused task to stay pending, got %s", task.Status)
+	}
+	if stats := pool.Stats(); stats.Queued != 1 || stats.Paused != 1 {
+		t.Errorf("Expected 1 paused task in the queue, got %+v", stats)
+	}
+
+	state, err := pool.Resume(ctx, "report")
+	if err != nil || len(state.Types) != 0 {
+		t.Fatalf("Expected no paused types, got %+v, %v", state, err)
+	}
+	if waitForTaskStatus(st, "report", models.Completed, 2*time.Second) == nil {
+		t.Error("Resumed task did not run")
+	}
+}
+
+// TestPauseGlobalPersists tests that a global pause holds every task and is restored by a new pool
+func TestPauseGlobalPersists(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st, WithPauses(st))
+	pool.Pause(ctx, "")
+	pool.Pause(ctx, "echo")
+
+	restarted := NewTaskPool(10, st, WithPauses(st))
+	if err := restarted.LoadPauses(ctx); err != nil {
+		t.Fatal(err)
+	}
+	state := restarted.PauseState()
+	if !state.Global || len(state.Types) != 1 || state.Types[0] != "echo" {
+		t.Fatalf("Expected the pause to survive a restart, got %+v", state)
+	}
+	manager := NewWorkerManager(1, st)
+	manager.InitiateWorkers(restarted)
+	defer manager.ForceStopWorkers()
+
+	restarted.AddTask(ctx, logger.NewTestLogger(), &models.Task{ID: "held", Title: "Held"})
+	time.Sleep(100 * time.Millisecond)
+	if task, _ := st.GetTask(ctx, "held"); task.Status != models.Pending {
+		t.Fatalf("Expected the task to wait while paused, got %s", task.Status)
+	}
+
+	// Resuming globally leaves the per-type pause in place
+	state, _ = restarted.Resume(ctx, "")
+	if state.Global || len(state.Types) != 1 {
+		t.Errorf("Unexpected state after resume: %+v", state)
+	}
+	if waitForTaskStatus(st, "held", models.Completed, 2*time.Second) == nil {
+		t.Error("Task did not run after the pool was resumed")
+	}
+}
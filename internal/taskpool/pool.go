@@ -4,7 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
 
+	"github.com/shayanmkpr/task-pool/internal/events"
 	"github.com/shayanmkpr/task-pool/internal/logger"
 	"github.com/shayanmkpr/task-pool/internal/models"
 	"github.com/shayanmkpr/task-pool/internal/store"
@@ -12,41 +17,340 @@ import (
 
 var ErrTaskQueueFull = errors.New("task queue is full") //fix
 
+// DefaultAgingInterval is how long a task must wait to gain one priority level.
+const DefaultAgingInterval = 30 * time.Second
+
 type TaskPool struct {
 	PoolSize int
-	Tasks    chan *models.Task
-	Store    *store.MemoryStore
+	Store    store.TaskStore
+	Handlers *Registry
+	Events   *events.Bus // every task status change and worker hand-off
+
+	mu             sync.Mutex
+	queues         map[string]*taskQueue
+	queueNames     []string // sorted
+	aging          time.Duration
+	seq            uint64
+	wake           chan struct{} // closed and replaced whenever a queue changes
+	room           chan struct{} // closed and replaced whenever a queue slot frees up
+	defaultTimeout time.Duration
+	maxTimeout     time.Duration
+
+	deadLetters store.DeadLetterStore
+	idempotency *idempotency
+	workflows   store.WorkflowStore
+	scheduler   scheduler
+	deps        dependencies
+	pauses      pauses
+	tenants     tenants
+	concurrency concurrency
+	held        map[heldKey]map[string]*queueItem // tasks set aside by holdLocked, by ID
+	spilled     map[string]bool                   // IDs of tasks waiting in a queue's overflow
+	refiller    *refiller                         // nil unless overflow is enabled
+	running     map[string]context.CancelCauseFunc
+	finishHooks []func(*models.Task)
+	now         func() time.Time
+	random      func() float64 // source of retry jitter
+}
+
+type Option func(*TaskPool)
+
+// WithAgingInterval sets how long a queued task waits to gain one priority
+// level. Zero disables aging and dispatches in strict priority order.
+func WithAgingInterval(d time.Duration) Option {
+	return func(p *TaskPool) {
+		p.aging = d
+	}
 }
 
-func NewTaskPool(poolSize int, store *store.MemoryStore) *TaskPool {
-	return &TaskPool{
+// WithEventHistory sets how many events are kept for resuming event streams.
+func WithEventHistory(n int) Option {
+	return func(p *TaskPool) {
+		p.Events = events.NewBus(n)
+	}
+}
+
+func NewTaskPool(poolSize int, store store.TaskStore, opts ...Option) *TaskPool {
+	p := &TaskPool{
 		PoolSize: poolSize,
-		Tasks:    make(chan *models.Task, poolSize),
 		Store:    store,
+		Handlers: NewRegistry(),
+		Events:   events.NewBus(events.DefaultHistory),
+		queues:   map[string]*taskQueue{DefaultQueue: {name: DefaultQueue}},
+		aging:    DefaultAgingInterval,
+		wake:     make(chan struct{}),
+		room:     make(chan struct{}),
+		running:  make(map[string]context.CancelCauseFunc),
+		now:      time.Now,
+		random:   rand.Float64,
+	}
+	p.queueNames = []string{DefaultQueue}
+	for _, opt := range opts {
+		opt(p)
+	}
+	for _, q := range p.queues {
+		q.aging = p.aging
+	}
+	return p
+}
+
+// OnFinish registers fn to run after any task reaches a terminal state. It
+// is called with a copy of the task on the goroutine that finished it
+// (usually a worker), so it should return quickly.
+func (p *TaskPool) OnFinish(fn func(task *models.Task)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finishHooks = append(p.finishHooks, fn)
+}
+
+// publish announces the task's current status on the event bus. workerID is
+// zero for transitions that do not happen on a worker.
+func (p *TaskPool) publish(task *models.Task, workerID int) {
+	e := events.ForTask(task)
+	e.WorkerID = workerID
+	p.Events.Publish(e)
+}
+
+// notifyFinished runs the finish hooks and resolves the tasks that were
+// waiting on this one. Callers must not hold p.mu.
+func (p *TaskPool) notifyFinished(task *models.Task) {
+	p.mu.Lock()
+	hooks := p.finishHooks
+	waiting := p.deps.dependents[task.ID]
+	delete(p.deps.dependents, task.ID)
+	p.mu.Unlock()
+	for _, fn := range hooks {
+		fn(task.Clone())
 	}
+	p.resolve(waiting)
+}
+
+// Len returns the number of tasks waiting to be dispatched, in every queue.
+func (p *TaskPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.queuedLocked()
 }
 
 func (p *TaskPool) AddTask(ctx context.Context, logger *logger.Logger, task *models.Task) (string, error) {
+	return p.AddTaskWait(ctx, logger, task, 0)
+}
 
-	if len(p.Tasks) >= p.PoolSize {
-		logger.Info("task queue is full")
-		return "", ErrTaskQueueFull //fix
+// AddTaskWait is AddTask for callers that would rather wait than be turned
+// away: if task's queue is full or its tenant is over quota, it waits up to
+// maxWait for room before failing with ErrTaskQueueFull. It gives up early
+// with ctx's error if ctx ends first. A maxWait of zero behaves like AddTask.
+func (p *TaskPool) AddTaskWait(ctx context.Context, logger *logger.Logger, task *models.Task, maxWait time.Duration) (string, error) {
+	delayed, err := p.prepare(task)
+	if err != nil {
+		return "", err
+	}
+	if err := p.checkDependencies(ctx, task, nil); err != nil {
+		return "", err
+	}
+	if len(task.DependsOn) > 0 {
+		return p.addBlocked(ctx, task)
+	}
+	if delayed {
+		return p.addScheduled(ctx, task)
+	}
+
+	slots := map[slot]int{{task.Queue, task.Tenant}: 1}
+	if err := p.reserveWait(ctx, slots, maxWait); err != nil {
+		logger.Info("task queue is full", "queue", task.Queue, "tenant", task.Tenant, "max_wait", maxWait, "error", err)
+		return "", err //fix
 	}
 
 	task.Status = models.Pending
-	if err := p.Store.AddTask(task); err != nil { //fix
+	if err := p.Store.AddTask(ctx, task); err != nil { //fix
+		p.release(slots)
 		return "", fmt.Errorf("failed to store task: %w", err) //fix
 	}
 
-	select {
-	case <-ctx.Done():
-		return "", ctx.Err()
+	p.mu.Lock()
+	p.releaseLocked(slots)
+	p.admitLocked(task)
+	p.publish(task, 0) // before a worker can pick the task up
+	p.mu.Unlock()
+	return task.ID, nil
+}
+
+// prepare applies timeout defaults and checks a new task before it is
+// stored. It reports whether the task waits for a future run_at.
+func (p *TaskPool) prepare(task *models.Task) (delayed bool, err error) {
+	if err := p.checkQueue(task); err != nil {
+		return false, err
+	}
+	task.Tenant = tenantOf(task)
+	if err := p.applyTimeouts(task); err != nil {
+		return false, err
+	}
+	if task.RunAt != nil && task.Deadline != nil && task.RunAt.After(*task.Deadline) {
+		return false, fmt.Errorf("%w: run_at is after the deadline", ErrInvalidTask)
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = p.now().UTC()
+	}
+	if task.RunAt != nil && task.RunAt.After(p.now()) {
+		return true, nil
+	}
+	task.RunAt = nil
+	return false, nil
+}
+
+// addScheduled stores a task that is not due yet and parks it in the
+// scheduler. It does not occupy a queue slot until it is released.
+func (p *TaskPool) addScheduled(ctx context.Context, task *models.Task) (string, error) {
+	task.Status = models.Scheduled
+	if err := p.Store.AddTask(ctx, task); err != nil {
+		return "", fmt.Errorf("failed to store task: %w", err)
+	}
+	p.mu.Lock()
+	p.scheduleLocked(task, *task.RunAt)
+	p.publish(task, 0)
+	p.mu.Unlock()
+	return task.ID, nil
+}
+
+// Scheduled returns the number of tasks waiting for their run time or retry.
+func (p *TaskPool) Scheduled() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.scheduler.len()
+}
+
+// slot is a place in a queue, charged to a tenant's quota.
+type slot struct {
+	queue  string
+	tenant string
+}
+
+// reserve claims slots in each named queue so the capacity and quota checks
+// and the store write cannot race with concurrent submissions. Either every
+// queue and tenant has room and all slots are claimed, or none are and the
+// error is ErrTaskQueueFull, wrapping ErrTenantQuotaExceeded if a tenant's
+// quota was the limit.
+func (p *TaskPool) reserve(slots map[slot]int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.reserveLocked(slots)
+}
+
+// reserveLocked is reserve for callers that hold p.mu.
+func (p *TaskPool) reserveLocked(slots map[slot]int) error {
+	byQueue := make(map[string]int)
+	byTenant := make(map[string]int)
+	for s, n := range slots {
+		byQueue[s.queue] += n
+		byTenant[s.tenant] += n
+	}
+	for name, n := range byQueue {
+		q := p.queues[name]
+		if q.overflow == nil && q.Len()+q.reserved+n > p.capacityOf(q) {
+			return ErrTaskQueueFull
+		}
+	}
+	if err := p.checkQuotasLocked(byTenant); err != nil {
+		return err
+	}
+	for s, n := range slots {
+		p.queues[s.queue].reserved += n
+		p.tenantLocked(s.tenant).reserved += n
+	}
+	return nil
+}
+
+// release gives back slots after a failed submission.
+func (p *TaskPool) release(slots map[slot]int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.releaseLocked(slots)
+	p.roomLocked()
+}
+
+// releaseLocked gives back slots taken by reserve. Callers must hold p.mu.
+func (p *TaskPool) releaseLocked(slots map[slot]int) {
+	for s, n := range slots {
+		p.queues[s.queue].reserved -= n
+		t := p.tenantLocked(s.tenant)
+		t.reserved -= n
+		p.forgetIdleLocked(t)
+	}
+}
+
+// enqueue queues a task that is already persisted, bypassing the capacity
+// check. It is used for work the pool has already accepted.
+func (p *TaskPool) enqueue(task *models.Task) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enqueueLocked(task)
+}
+
+// Recover re-enqueues tasks that were pending or running when the process
+// last stopped, so a durable store does not silently drop in-flight work.
+// Running tasks are reset to pending since their worker is gone, while
+// scheduled and retrying tasks go back to waiting for their run time and
+// blocked tasks for their dependencies. Recovered tasks are queued oldest
+// first and do not count against PoolSize. Tasks waiting in an overflow
+// are left there.
+func (p *TaskPool) Recover(ctx context.Context, logger *logger.Logger) (int, error) {
+	tasks, err := p.Store.ListTasks(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
 
-	case p.Tasks <- task:
-		return task.ID, nil
+	recovered := 0
+	var blocked []string
+	for _, task := range tasks {
+		switch task.Status {
+		case models.Pending:
+			p.mu.Lock()
+			spilled := p.spilled[task.ID]
+			p.mu.Unlock()
+			if spilled {
+				continue
+			}
+		case models.Running:
+		case models.Blocked:
+			p.mu.Lock()
+			p.blockLocked(task)
+			p.mu.Unlock()
+			blocked = append(blocked, task.ID)
+			recovered++
+			logger.Info("recovered blocked task", "task_id", task.ID, "depends_on", task.DependsOn)
+			continue
+		case models.Scheduled, models.Retrying:
+			at := p.now()
+			if task.Status == models.Scheduled && task.RunAt != nil {
+				at = *task.RunAt
+			} else if task.Status == models.Retrying && task.NextAttemptAt != nil {
+				at = *task.NextAttemptAt
+			}
+			p.mu.Lock()
+			p.scheduleLocked(task, at)
+			p.mu.Unlock()
+			recovered++
+			logger.Info("recovered waiting task", "task_id", task.ID, "status", task.Status, "run_at", at)
+			continue
+		default:
+			continue
+		}
+		if task.Status == models.Running {
+			task.Status = models.Pending
+			if err := p.Store.UpdateTask(ctx, task); err != nil {
+				return recovered, fmt.Errorf("failed to reset task %s: %w", task.ID, err)
+			}
+			p.publish(task, 0)
+		}
 
-	default:
-		logger.Info("task queue is full")
-		return "", ErrTaskQueueFull //fix
+		p.enqueue(task)
+		recovered++
+		logger.Info("recovered task", "task_id", task.ID)
 	}
+	// Parents may have finished while the process was down.
+	p.resolve(blocked)
+	return recovered, nil
 }
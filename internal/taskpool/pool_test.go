@@ -18,8 +18,8 @@ func TestNewTaskPool(t *testing.T) {
 		t.Errorf("Expected pool size 5, got %d", pool.PoolSize)
 	}
 
-	if cap(pool.Tasks) != 5 {
-		t.Errorf("Expected task channel capacity 5, got %d", cap(pool.Tasks))
+	if pool.Len() != 0 {
+		t.Errorf("Expected empty queue, got %d tasks", pool.Len())
 	}
 }
 
@@ -36,8 +36,9 @@ func TestAddTaskPoolFull(t *testing.T) {
 		Description: "A task that takes a long time",
 		Duration:    5,
 	}
-	store.AddTask(longTask)
-	pool.Tasks <- longTask
+	if _, err := pool.AddTask(context.Background(), log, longTask); err != nil {
+		t.Fatalf("Failed to fill the pool: %v", err)
+	}
 
 	// Try to add another task - should fail (by design)
 	newTask := &models.Task{
@@ -56,7 +57,31 @@ func TestAddTaskPoolFull(t *testing.T) {
 	if err.Error() != "task queue is full" {
 		t.Errorf("Expected 'task queue is full' error, got '%v'", err)
 	}
+}
+
+// TestRecoverRequeuesUnfinished tests that pending and running tasks are re-enqueued after a restart
+func TestRecoverRequeuesUnfinished(t *testing.T) {
+	store := store.NewMemoryStore()
+	ctx := context.Background()
+	for _, task := range []*models.Task{
+		{ID: "was-pending", Title: "Pending", Status: models.Pending},
+		{ID: "was-running", Title: "Running", Status: models.Running},
+		{ID: "was-completed", Title: "Completed", Status: models.Completed},
+	} {
+		store.AddTask(ctx, task)
+	}
 
-	// Clean up
-	<-pool.Tasks
+	pool := NewTaskPool(5, store)
+	n, err := pool.Recover(ctx, logger.NewTestLogger())
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if n != 2 || pool.Len() != 2 {
+		t.Fatalf("Expected 2 recovered tasks, got %d (queued %d)", n, pool.Len())
+	}
+
+	running, _ := store.GetTask(ctx, "was-running")
+	if running.Status != models.Pending {
+		t.Errorf("Expected interrupted task to be reset to 'pending', got '%s'", running.Status)
+	}
 }
@@ -0,0 +1,228 @@
+package taskpool
+
+import (
+	"container/heap"
+	"context"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+const (
+	MinPriority = 0
+	MaxPriority = 9
+)
+
+type queueItem struct {
+	task       *models.Task
+	seq        uint64 // submission order, breaks ties between equal scores
+	enqueuedAt time.Time
+	index      int // in its tenant's heap
+	arrival    int // in its queue's arrivals
+
+	heldBy     heldKey // set while held, see holdLocked
+	heldPaused bool    // held by a pause rather than a concurrency limit
+}
+
+// taskHeap orders tasks by priority with aging. A task of priority p is
+// treated as if it had been enqueued p*aging earlier than it really was, so
+// urgent work jumps ahead while anything that has waited long enough
+// eventually outranks fresh high-priority submissions. Because every task
+// ages at the same rate the relative order never changes after insertion,
+// which keeps this a plain heap with no periodic re-scoring.
+type taskHeap struct {
+	items []*queueItem
+	byID  map[string]*queueItem
+	aging time.Duration
+}
+
+func (h *taskHeap) Len() int { return len(h.items) }
+
+func (h *taskHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.aging > 0 {
+		ea := a.enqueuedAt.Add(-time.Duration(a.task.Priority) * h.aging)
+		eb := b.enqueuedAt.Add(-time.Duration(b.task.Priority) * h.aging)
+		if !ea.Equal(eb) {
+			return ea.Before(eb)
+		}
+	} else if a.task.Priority != b.task.Priority {
+		return a.task.Priority > b.task.Priority
+	}
+	return a.seq < b.seq
+}
+
+func (h *taskHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *taskHeap) Push(x any) {
+	item := x.(*queueItem)
+	item.index = len(h.items)
+	h.items = append(h.items, item)
+	if h.byID == nil {
+		h.byID = make(map[string]*queueItem)
+	}
+	h.byID[item.task.ID] = item
+}
+
+func (h *taskHeap) Pop() any {
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	h.items = old[:n-1]
+	delete(h.byID, item.task.ID)
+	return item
+}
+
+// remove takes the task with the given ID out of the heap, if queued.
+func (h *taskHeap) remove(id string) (*queueItem, bool) {
+	item, ok := h.byID[id]
+	if !ok {
+		return nil, false
+	}
+	heap.Remove(h, item.index)
+	return item, true
+}
+
+// arrivals orders the tasks in a queue's heaps by submission, so the one
+// that has waited longest is on top.
+type arrivals []*queueItem
+
+func (a arrivals) Len() int           { return len(a) }
+func (a arrivals) Less(i, j int) bool { return a[i].seq < a[j].seq }
+
+func (a arrivals) Swap(i, j int) {
+	a[i], a[j] = a[j], a[i]
+	a[i].arrival = i
+	a[j].arrival = j
+}
+
+func (a *arrivals) Push(x any) {
+	item := x.(*queueItem)
+	item.arrival = len(*a)
+	*a = append(*a, item)
+}
+
+func (a *arrivals) Pop() any {
+	old := *a
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.arrival = -1
+	*a = old[:n-1]
+	return item
+}
+
+// enqueueLocked pushes task onto its queue and wakes waiting workers.
+// Callers must hold p.mu.
+func (p *TaskPool) enqueueLocked(task *models.Task) {
+	t := p.tenantLocked(tenantOf(task))
+	if t.queued == 0 {
+		// A tenant that was not waiting starts level with the others
+		// rather than cashing in the turns it did not need.
+		t.pass = max(t.pass, p.tenants.clock)
+	}
+	t.queued++
+	p.seq++
+	p.queueFor(task).push(t.name, &queueItem{
+		task:       task,
+		seq:        p.seq,
+		enqueuedAt: p.now(),
+	})
+	p.signalLocked()
+}
+
+// wakeWorkers wakes every goroutine blocked in next, e.g. after workers
+// were reassigned to other queues.
+func (p *TaskPool) wakeWorkers() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.signalLocked()
+}
+
+// inFlight returns the number of tasks currently on a worker.
+func (p *TaskPool) inFlight() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.running)
+}
+
+// signalLocked wakes every goroutine blocked in next. Callers must hold p.mu.
+func (p *TaskPool) signalLocked() {
+	close(p.wake)
+	p.wake = make(chan struct{})
+}
+
+// popLocked removes the next task to dispatch from any queue, or returns nil
+// if the queues are empty or everything in them is paused. Callers must hold
+// p.mu.
+func (p *TaskPool) popLocked() *models.Task {
+	return p.popFromLocked(nil)
+}
+
+// next blocks until a task is available or quit is closed, in which case it
+// returns nil. The task is registered as running under the same lock that
+// dequeues it, so Cancel always finds it in one place or the other. The
+// returned context is cancelled when the task is cancelled.
+func (p *TaskPool) next(quit <-chan struct{}) (*models.Task, context.Context) {
+	return p.nextFrom(quit, nil)
+}
+
+// nextFrom is next for a worker assigned to queues. weights is called on
+// every attempt so a reassigned worker picks up its new queues as soon as
+// it is woken.
+func (p *TaskPool) nextFrom(quit <-chan struct{}, weights func() QueueWeights) (*models.Task, context.Context) {
+	for {
+		select {
+		case <-quit:
+			return nil, nil
+		default:
+		}
+
+		var w QueueWeights
+		if weights != nil {
+			w = weights()
+		}
+		p.mu.Lock()
+		if task := p.popFromLocked(w); task != nil {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			p.running[task.ID] = cancel
+			p.mu.Unlock()
+			return task, ctx
+		}
+		wake := p.wake
+		p.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-quit:
+			return nil, nil
+		}
+	}
+}
+
+// done releases the running registration taken by next.
+func (p *TaskPool) done(task *models.Task) {
+	p.mu.Lock()
+	cancel, ok := p.running[task.ID]
+	delete(p.running, task.ID)
+	if ok {
+		p.queueFor(task).running--
+		t := p.tenantLocked(tenantOf(task))
+		if t.atConcurrencyLimit() && t.queued > 0 {
+			p.signalLocked() // its queued tasks may run again
+		}
+		t.running--
+		p.forgetIdleLocked(t)
+		p.releaseConcurrencyLocked(task)
+	}
+	p.mu.Unlock()
+	if ok {
+		cancel(nil)
+	}
+}
@@ -0,0 +1,119 @@
+package taskpool
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// fakeClock is a manually advanced time source for queue ordering tests
+type fakeClock struct{ t time.Time }
+
+func newFakeClock() *fakeClock { return &fakeClock{t: time.Unix(1_700_000_000, 0)} }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// drainOrder pops every queued task and returns their IDs in dispatch order
+func drainOrder(t *testing.T, p *TaskPool) []string {
+	t.Helper()
+	var ids []string
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for task := p.popLocked(); task != nil; task = p.popLocked() {
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
+
+func addWithPriority(t *testing.T, p *TaskPool, id string, priority int) {
+	t.Helper()
+	task := &models.Task{ID: id, Title: id, Priority: priority}
+	if _, err := p.AddTask(context.Background(), logger.NewTestLogger(), task); err != nil {
+		t.Fatalf("AddTask(%s) failed: %v", id, err)
+	}
+}
+
+// TestQueuePriorityOrder tests that higher priority tasks are dispatched first
+func TestQueuePriorityOrder(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore(), WithAgingInterval(0))
+
+	addWithPriority(t, pool, "low", 1)
+	addWithPriority(t, pool, "urgent", 9)
+	addWithPriority(t, pool, "normal", 5)
+
+	got := fmt.Sprint(drainOrder(t, pool))
+	if got != "[urgent normal low]" {
+		t.Errorf("Unexpected dispatch order: %s", got)
+	}
+}
+
+// TestQueueFIFOWithinPriority tests that equal priorities keep submission order
+func TestQueueFIFOWithinPriority(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore())
+	clock := newFakeClock()
+	pool.now = clock.now
+
+	for i := 0; i < 5; i++ {
+		addWithPriority(t, pool, fmt.Sprintf("t%d", i), 3)
+	}
+
+	got := fmt.Sprint(drainOrder(t, pool))
+	if got != "[t0 t1 t2 t3 t4]" {
+		t.Errorf("Unexpected dispatch order: %s", got)
+	}
+}
+
+// TestQueueAgingPreventsStarvation tests that an old low-priority task eventually outranks new urgent ones
+func TestQueueAgingPreventsStarvation(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore(), WithAgingInterval(time.Second))
+	clock := newFakeClock()
+	pool.now = clock.now
+
+	addWithPriority(t, pool, "old-low", 0)
+	clock.advance(5 * time.Second)
+	addWithPriority(t, pool, "fresh-mid", 4) // effectively 1s older than old-low
+	addWithPriority(t, pool, "fresh-high", 6)
+
+	got := fmt.Sprint(drainOrder(t, pool))
+	if got != "[fresh-high old-low fresh-mid]" {
+		t.Errorf("Unexpected dispatch order: %s", got)
+	}
+}
+
+// TestQueueNextBlocksUntilTask tests that next wakes up when a task is enqueued
+func TestQueueNextBlocksUntilTask(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore())
+	quit := make(chan struct{})
+
+	got := make(chan *models.Task, 1)
+	go func() { task, _ := pool.next(quit); got <- task }()
+
+	time.Sleep(20 * time.Millisecond)
+	pool.enqueue(&models.Task{ID: "late"})
+
+	select {
+	case task := <-got:
+		if task == nil || task.ID != "late" {
+			t.Errorf("Expected task 'late', got %+v", task)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("next did not wake up after enqueue")
+	}
+
+	go func() { task, _ := pool.next(quit); got <- task }()
+	close(quit)
+	select {
+	case task := <-got:
+		if task != nil {
+			t.Errorf("Expected nil after quit, got %+v", task)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("next did not return after quit")
+	}
+}
@@ -0,0 +1,329 @@
+package taskpool
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// DefaultQueue receives tasks submitted without a queue. Unless configured
+// with WithQueue its capacity is PoolSize.
+const DefaultQueue = "default"
+
+var (
+	ErrUnknownQueue       = errors.New("unknown queue")
+	ErrInvalidQueueConfig = errors.New("invalid queue config")
+)
+
+// taskQueue is a named priority queue with its own capacity. Each tenant
+// has its own heap within the queue so tenants can be served fairly. Tasks
+// that may not run yet are held outside the heaps (see holdLocked) but
+// still count as queued.
+type taskQueue struct {
+	name       string
+	capacity   int // 0 means PoolSize
+	aging      time.Duration
+	tenants    map[string]*taskHeap  // only tenants with tasks in a heap
+	waiting    arrivals              // the tasks in tenants, oldest first
+	held       map[string]*queueItem // by task ID
+	paused     int                   // held tasks whose type is paused
+	limited    int                   // held tasks over a concurrency limit
+	size       int
+	reserved   int // slots claimed by submissions still writing to the store
+	running    int
+	dispatched uint64
+	drained    drainRate
+
+	overflow *store.SegmentQueue // nil unless overflow is enabled
+	spilled  int                 // tasks waiting in overflow
+}
+
+func (q *taskQueue) Len() int { return q.size }
+
+func (q *taskQueue) push(tenant string, item *queueItem) {
+	q.insert(tenant, item)
+	q.size++
+}
+
+// insert puts item into its tenant's heap without counting it, for tasks
+// already counted in size.
+func (q *taskQueue) insert(tenant string, item *queueItem) {
+	h, ok := q.tenants[tenant]
+	if !ok {
+		if q.tenants == nil {
+			q.tenants = make(map[string]*taskHeap)
+		}
+		h = &taskHeap{aging: q.aging}
+		q.tenants[tenant] = h
+	}
+	heap.Push(h, item)
+	heap.Push(&q.waiting, item)
+}
+
+// take pops the next item from tenant's heap, leaving it counted in size.
+func (q *taskQueue) take(tenant string) *queueItem {
+	h := q.tenants[tenant]
+	item := heap.Pop(h).(*queueItem)
+	heap.Remove(&q.waiting, item.arrival)
+	if h.Len() == 0 {
+		delete(q.tenants, tenant)
+	}
+	return item
+}
+
+// remove takes the task with the given ID out of the queue's heaps, if
+// there.
+func (q *taskQueue) remove(id string) (*models.Task, bool) {
+	for tenant, h := range q.tenants {
+		if item, ok := h.remove(id); ok {
+			heap.Remove(&q.waiting, item.arrival)
+			q.size--
+			if h.Len() == 0 {
+				delete(q.tenants, tenant)
+			}
+			return item.task, true
+		}
+	}
+	return nil, false
+}
+
+// WithQueue adds a named queue holding up to capacity tasks, or sets the
+// capacity of DefaultQueue.
+func WithQueue(name string, capacity int) Option {
+	return func(p *TaskPool) {
+		if q, ok := p.queues[name]; ok {
+			q.capacity = capacity
+			return
+		}
+		p.queues[name] = &taskQueue{name: name, capacity: capacity}
+		p.queueNames = append(p.queueNames, name)
+		slices.Sort(p.queueNames)
+	}
+}
+
+// QueueWeights assigns a worker to queues. A worker with weights for several
+// queues picks among those with work in proportion to the weights, so a
+// worker weighted 70/30 between "critical" and "bulk" gives bulk a share
+// while both are busy and all its time to either when the other is empty.
+// Nil or empty weights mean every queue, equally.
+type QueueWeights map[string]int
+
+func (w QueueWeights) String() string {
+	parts := make([]string, 0, len(w))
+	for _, name := range slices.Sorted(maps.Keys(w)) {
+		parts = append(parts, fmt.Sprintf("%s=%d", name, w[name]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseQueueWeights parses "critical=70,bulk=30". A queue without a weight,
+// as in "critical", has weight 1.
+func ParseQueueWeights(s string) (QueueWeights, error) {
+	counts, err := parseNamedCounts(s, 1, ErrInvalidQueueConfig)
+	return QueueWeights(counts), err
+}
+
+// ParseQueueCapacities parses "critical=100,bulk=5000" into queue
+// capacities for WithQueue.
+func ParseQueueCapacities(s string) (map[string]int, error) {
+	return parseNamedCounts(s, 0, ErrInvalidQueueConfig)
+}
+
+// parseNamedCounts parses a comma-separated list of name=count pairs. A
+// missing count takes def; a def of 0 makes the count required. Errors wrap
+// errInvalid.
+func parseNamedCounts(s string, def int, errInvalid error) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, hasValue := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		n := def
+		if hasValue {
+			var err error
+			if n, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("%w: %q is not a number", errInvalid, value)
+			}
+		}
+		if name == "" || n <= 0 {
+			return nil, fmt.Errorf("%w: %q needs a name and a positive number", errInvalid, part)
+		}
+		if _, dup := counts[name]; dup {
+			return nil, fmt.Errorf("%w: %q listed twice", errInvalid, name)
+		}
+		counts[name] = n
+	}
+	return counts, nil
+}
+
+// CheckQueueWeights reports an error if weights name a queue the pool does
+// not have or give a queue a weight below 1.
+func (p *TaskPool) CheckQueueWeights(weights QueueWeights) error {
+	for name, w := range weights {
+		if _, ok := p.queues[name]; !ok {
+			return fmt.Errorf("%w %q", ErrUnknownQueue, name)
+		}
+		if w <= 0 {
+			return fmt.Errorf("%w: weight for %q must be positive", ErrInvalidQueueConfig, name)
+		}
+	}
+	return nil
+}
+
+// checkQueue normalises task.Queue and rejects unknown queues.
+func (p *TaskPool) checkQueue(task *models.Task) error {
+	if task.Queue == "" {
+		task.Queue = DefaultQueue
+	}
+	if _, ok := p.queues[task.Queue]; !ok {
+		return fmt.Errorf("%w: %w %q", ErrInvalidTask, ErrUnknownQueue, task.Queue)
+	}
+	return nil
+}
+
+// queueFor returns the queue task belongs to. Tasks recovered from a queue
+// that is no longer configured fall back to DefaultQueue.
+func (p *TaskPool) queueFor(task *models.Task) *taskQueue {
+	if q, ok := p.queues[task.Queue]; ok {
+		return q
+	}
+	return p.queues[DefaultQueue]
+}
+
+func (p *TaskPool) capacityOf(q *taskQueue) int {
+	if q.capacity > 0 {
+		return q.capacity
+	}
+	return p.PoolSize
+}
+
+// queuedLocked returns the number of tasks in every queue. Callers must
+// hold p.mu.
+func (p *TaskPool) queuedLocked() int {
+	n := 0
+	for _, q := range p.queues {
+		n += q.Len()
+	}
+	return n
+}
+
+// popFromLocked removes the next task for a worker with the given weights,
+// or returns nil if none of its queues has a task it may run. The queue is
+// drawn at random in proportion to the weights among those with work, then
+// a tenant by fair share, then the tenant's own priority order applies.
+// Callers must hold p.mu.
+func (p *TaskPool) popFromLocked(weights QueueWeights) *models.Task {
+	if p.pauses.global {
+		return nil
+	}
+	type candidate struct {
+		q      *taskQueue
+		weight int
+	}
+	var candidates []candidate
+	total := 0
+	for _, name := range p.queueNames {
+		q := p.queues[name]
+		weight := 1
+		if len(weights) > 0 {
+			weight = weights[name]
+		}
+		if weight <= 0 || p.pauses.queues[name] {
+			continue
+		}
+		if q.spilled > 0 && q.Len() < p.capacityOf(q) {
+			p.kickRefillLocked()
+		}
+		if q.Len() == 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{q, weight})
+		total += weight
+	}
+
+	for len(candidates) > 0 {
+		i := 0
+		if len(candidates) > 1 {
+			r := p.random() * float64(total)
+			for ; i < len(candidates)-1; i++ {
+				if r -= float64(candidates[i].weight); r < 0 {
+					break
+				}
+			}
+		}
+		q := candidates[i].q
+		if task := p.popTenantLocked(q); task != nil {
+			q.running++
+			q.dispatched++
+			q.drained.record(p.now())
+			if q.spilled > 0 {
+				p.kickRefillLocked()
+			}
+			p.roomLocked()
+			return task
+		}
+		// Everything in this queue is paused or held back by tenant or concurrency limits.
+		total -= candidates[i].weight
+		candidates = slices.Delete(candidates, i, i+1)
+	}
+	return nil
+}
+
+// NamedQueueStats describes one queue.
+type NamedQueueStats struct {
+	Name       string          `json:"name"`
+	Capacity   int             `json:"capacity"`
+	Queued     int             `json:"queued"`
+	Overflow   int             `json:"overflow"` // tasks spilled to disk behind the queued ones
+	Paused     int             `json:"paused"`   // queued tasks held back by a pause
+	Limited    int             `json:"limited"`  // queued tasks held back by a concurrency limit
+	Running    int             `json:"running"`
+	Dispatched uint64          `json:"dispatched"` // tasks handed to workers since start
+	OldestWait models.Duration `json:"oldest_wait"`
+}
+
+// QueueStats returns a snapshot of every queue, sorted by name.
+func (p *TaskPool) QueueStats() []NamedQueueStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	stats := make([]NamedQueueStats, 0, len(p.queueNames))
+	for _, name := range p.queueNames {
+		q := p.queues[name]
+		s := NamedQueueStats{
+			Name:       name,
+			Capacity:   p.capacityOf(q),
+			Queued:     q.Len(),
+			Overflow:   q.spilled,
+			Running:    q.running,
+			Dispatched: q.dispatched,
+		}
+		s.Paused, s.Limited, s.OldestWait = p.waitingLocked(q, now)
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// waitingLocked returns how many of q's tasks are held back by a pause or a
+// concurrency limit and the longest wait among the others. Callers must
+// hold p.mu.
+func (p *TaskPool) waitingLocked(q *taskQueue, now time.Time) (paused, limited int, oldestWait models.Duration) {
+	if p.pauses.global || p.pauses.queues[q.name] {
+		return q.Len(), 0, 0
+	}
+	if len(q.waiting) > 0 {
+		oldestWait = models.Duration(now.Sub(q.waiting[0].enqueuedAt))
+	}
+	return q.paused, q.limited, oldestWait
+}
@@ -0,0 +1,146 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestNamedQueueCapacity tests that each queue is bounded by its own capacity
+func TestNamedQueueCapacity(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewTestLogger()
+	pool := NewTaskPool(5, store.NewMemoryStore(), WithQueue("bulk", 2))
+
+	for i := range 2 {
+		if _, err := pool.AddTask(ctx, log, &models.Task{ID: fmt.Sprintf("bulk-%d", i), Title: "Bulk", Queue: "bulk"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := pool.AddTask(ctx, log, &models.Task{ID: "bulk-2", Title: "Bulk", Queue: "bulk"}); !errors.Is(err, ErrTaskQueueFull) {
+		t.Errorf("Expected ErrTaskQueueFull from the full queue, got %v", err)
+	}
+	task := &models.Task{ID: "default", Title: "Default"}
+	if _, err := pool.AddTask(ctx, log, task); err != nil || task.Queue != DefaultQueue {
+		t.Errorf("Expected the default queue to accept the task, got %q, %v", task.Queue, err)
+	}
+	_, err := pool.AddTask(ctx, log, &models.Task{ID: "lost", Title: "Lost", Queue: "missing"})
+	if !errors.Is(err, ErrInvalidTask) || !errors.Is(err, ErrUnknownQueue) {
+		t.Errorf("Expected ErrUnknownQueue, got %v", err)
+	}
+
+	// A batch must fit in every queue it touches
+	batch := []*models.Task{{ID: "a", Title: "A"}, {ID: "b", Title: "B", Queue: "bulk"}}
+	if err := pool.AddBatch(ctx, log, batch); !errors.Is(err, ErrTaskQueueFull) || pool.Len() != 3 {
+		t.Errorf("Expected the batch to be rejected whole, got %v with %d queued", err, pool.Len())
+	}
+
+	stats := pool.QueueStats()
+	if len(stats) != 2 || stats[0].Name != "bulk" || stats[0].Queued != 2 || stats[0].Capacity != 2 || stats[1].Capacity != 5 {
+		t.Errorf("Unexpected queue stats: %+v", stats)
+	}
+	if total := pool.Stats(); total.Capacity != 7 || total.Queued != 3 {
+		t.Errorf("Unexpected totals: %+v", total)
+	}
+}
+
+// TestQueueWeights tests that workers share out dispatches by weight and only take from their queues
+func TestQueueWeights(t *testing.T) {
+	pool := NewTaskPool(200, store.NewMemoryStore(), WithQueue("critical", 200), WithQueue("bulk", 200))
+	pool.random = rand.New(rand.NewSource(1)).Float64
+	for i := range 200 {
+		pool.enqueue(&models.Task{ID: fmt.Sprintf("c%d", i), Queue: "critical"})
+		pool.enqueue(&models.Task{ID: fmt.Sprintf("b%d", i), Queue: "bulk"})
+	}
+
+	weights := QueueWeights{"critical": 70, "bulk": 30}
+	critical := 0
+	for range 100 {
+		if pool.popFromLocked(weights).Queue == "critical" {
+			critical++
+		}
+	}
+	if critical < 60 || critical > 80 {
+		t.Errorf("Expected about 70 of 100 dispatches from critical, got %d", critical)
+	}
+
+	// A worker on one queue never takes from another
+	for task := pool.popFromLocked(QueueWeights{"bulk": 1}); task != nil; task = pool.popFromLocked(QueueWeights{"bulk": 1}) {
+		if task.Queue != "bulk" {
+			t.Fatalf("Bulk-only worker got a task from %s", task.Queue)
+		}
+	}
+	// Once bulk is empty a weighted worker takes everything from critical
+	if task := pool.popFromLocked(weights); task == nil || task.Queue != "critical" {
+		t.Errorf("Expected a critical task, got %+v", task)
+	}
+
+	// A paused queue is skipped
+	pool.PauseQueue(context.Background(), "critical")
+	if task := pool.popLocked(); task != nil {
+		t.Errorf("Expected nothing from a paused queue, got %s", task.ID)
+	}
+	if _, err := pool.PauseQueue(context.Background(), "missing"); !errors.Is(err, ErrUnknownQueue) {
+		t.Errorf("Expected ErrUnknownQueue, got %v", err)
+	}
+}
+
+// TestAssignWorkerQueues tests that a reassigned worker picks up tasks from its new queues
+func TestAssignWorkerQueues(t *testing.T) {
+	ctx := context.Background()
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st, WithQueue("bulk", 10))
+	manager := NewWorkerManager(1, st)
+	manager.InitiateWorkers(pool)
+	defer manager.ForceStopWorkers()
+
+	if err := manager.AssignQueues(QueueWeights{"bulk": 1}); err != nil {
+		t.Fatal(err)
+	}
+	pool.AddTask(ctx, logger.NewTestLogger(), &models.Task{ID: "urgent", Title: "Urgent"})
+	time.Sleep(100 * time.Millisecond)
+	if task, _ := st.GetTask(ctx, "urgent"); task.Status != models.Pending {
+		t.Fatalf("Expected the default queue to be unserved, got %s", task.Status)
+	}
+
+	info, err := manager.AssignWorkerQueues(1, nil)
+	if err != nil || info.Queues != nil {
+		t.Fatalf("Expected worker 1 on every queue, got %+v, %v", info, err)
+	}
+	if waitForTaskStatus(st, "urgent", models.Completed, 2*time.Second) == nil {
+		t.Error("Reassigned worker did not pick up the task")
+	}
+
+	if _, err := manager.AssignWorkerQueues(99, nil); !errors.Is(err, ErrWorkerNotFound) {
+		t.Errorf("Expected ErrWorkerNotFound, got %v", err)
+	}
+	if err := manager.AssignQueues(QueueWeights{"missing": 1}); !errors.Is(err, ErrUnknownQueue) {
+		t.Errorf("Expected ErrUnknownQueue, got %v", err)
+	}
+}
+
+// TestParseQueueWeights tests the name=weight list format used by the flags
+func TestParseQueueWeights(t *testing.T) {
+	w, err := ParseQueueWeights(" critical=70, bulk ")
+	if err != nil || w["critical"] != 70 || w["bulk"] != 1 || w.String() != "bulk=1,critical=70" {
+		t.Errorf("Unexpected weights %v, %v", w, err)
+	}
+	if w, err := ParseQueueWeights(""); err != nil || len(w) != 0 {
+		t.Errorf("Expected no weights, got %v, %v", w, err)
+	}
+	for _, s := range []string{"a=0", "a=x", "=3", "a=1,a=2"} {
+		if _, err := ParseQueueWeights(s); !errors.Is(err, ErrInvalidQueueConfig) {
+			t.Errorf("%q: expected ErrInvalidQueueConfig, got %v", s, err)
+		}
+	}
+	if _, err := ParseQueueCapacities("bulk"); !errors.Is(err, ErrInvalidQueueConfig) {
+		t.Errorf("Expected a capacity to be required, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package taskpool
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// DefaultTaskType is used for tasks submitted without a type. Its handler
+// simulates work by sleeping for the task's Duration.
+const DefaultTaskType = "sleep"
+
+// HandlerFunc executes a task. The returned result is JSON encoded and
+// stored on the task; a non-nil error marks the task as failed.
+type HandlerFunc func(ctx context.Context, task *models.Task) (any, error)
+
+type handlerEntry struct {
+	fn    HandlerFunc
+	retry *models.RetryPolicy
+}
+
+// HandlerOption configures per-type behaviour at registration time.
+type HandlerOption func(*handlerEntry)
+
+// WithRetryPolicy sets the retry policy for tasks of this type that were
+// submitted without one of their own.
+func WithRetryPolicy(policy models.RetryPolicy) HandlerOption {
+	return func(e *handlerEntry) {
+		e.retry = &policy
+	}
+}
+
+// Registry maps task types to the Go code that executes them.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]handlerEntry
+}
+
+// NewRegistry returns a registry with the built-in sleep handler registered.
+func NewRegistry() *Registry {
+	r := &Registry{handlers: make(map[string]handlerEntry)}
+	r.Register(DefaultTaskType, sleepHandler)
+	return r
+}
+
+// Register installs h for taskType, replacing any previous handler.
+func (r *Registry) Register(taskType string, h HandlerFunc, opts ...HandlerOption) {
+	entry := handlerEntry{fn: h}
+	for _, opt := range opts {
+		opt(&entry)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[taskType] = entry
+}
+
+func (r *Registry) Lookup(taskType string) (HandlerFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.handlers[taskType]
+	return e.fn, ok
+}
+
+// RetryPolicy returns the default retry policy registered for taskType, if any.
+func (r *Registry) RetryPolicy(taskType string) (models.RetryPolicy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.handlers[taskType]
+	if !ok || e.retry == nil {
+		return models.RetryPolicy{}, false
+	}
+	return *e.retry, true
+}
+
+// Types returns the registered task types in sorted order.
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.handlers))
+	for t := range r.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func sleepHandler(ctx context.Context, task *models.Task) (any, error) {
+	timer := time.NewTimer(time.Duration(task.Duration) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,39 @@
+package taskpool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// TestRegistryDefaultHandler tests that the sleep handler is registered by default
+func TestRegistryDefaultHandler(t *testing.T) {
+	r := NewRegistry()
+	if _, ok := r.Lookup(DefaultTaskType); !ok {
+		t.Fatalf("Expected %q handler to be registered", DefaultTaskType)
+	}
+	if _, ok := r.Lookup("unknown"); ok {
+		t.Error("Expected no handler for unknown type")
+	}
+}
+
+// TestRegistryRegister tests registering and replacing handlers
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+	r.Register("first", func(ctx context.Context, task *models.Task) (any, error) { return 1, nil })
+	r.Register("first", func(ctx context.Context, task *models.Task) (any, error) { return 2, nil })
+
+	h, ok := r.Lookup("first")
+	if !ok {
+		t.Fatal("Expected handler to be registered")
+	}
+	if got, _ := h(context.Background(), &models.Task{}); got != 2 {
+		t.Errorf("Expected replaced handler to return 2, got %v", got)
+	}
+
+	types := r.Types()
+	if len(types) != 2 || types[0] != "first" || types[1] != DefaultTaskType {
+		t.Errorf("Unexpected registered types: %v", types)
+	}
+}
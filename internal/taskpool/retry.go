@@ -0,0 +1,140 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+const (
+	MaxRetryAttempts  = 25
+	DefaultRetryDelay = time.Second
+)
+
+var (
+	ErrInvalidRetryPolicy = errors.New("invalid retry policy")
+
+	// ErrPermanent marks a handler error as not worth retrying. A task whose
+	// handler returns an error matching it fails at once, whatever attempts
+	// its retry policy has left, and is dead-lettered if that is enabled.
+	ErrPermanent = errors.New("permanent failure")
+)
+
+// Permanent wraps a handler error so the task is not retried. The error
+// keeps its message and still matches err with errors.Is.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string        { return e.err.Error() }
+func (e permanentError) Unwrap() error        { return e.err }
+func (e permanentError) Is(target error) bool { return target == ErrPermanent }
+
+// ValidateRetryPolicy rejects policies the pool cannot honour.
+func ValidateRetryPolicy(rp *models.RetryPolicy) error {
+	if rp == nil {
+		return nil
+	}
+	if rp.MaxAttempts < 1 || rp.MaxAttempts > MaxRetryAttempts {
+		return fmt.Errorf("%w: max_attempts must be between 1 and %d", ErrInvalidRetryPolicy, MaxRetryAttempts)
+	}
+	switch rp.Backoff {
+	case "", models.BackoffConstant, models.BackoffLinear, models.BackoffExponential:
+	default:
+		return fmt.Errorf("%w: unknown backoff %q", ErrInvalidRetryPolicy, rp.Backoff)
+	}
+	if rp.InitialDelay < 0 || rp.MaxDelay < 0 {
+		return fmt.Errorf("%w: delays cannot be negative", ErrInvalidRetryPolicy)
+	}
+	if rp.Jitter < 0 || rp.Jitter > 1 {
+		return fmt.Errorf("%w: jitter must be between 0 and 1", ErrInvalidRetryPolicy)
+	}
+	return nil
+}
+
+// retryPolicy resolves the policy for a task: its own, then its type's
+// registered default, then a single attempt.
+func (p *TaskPool) retryPolicy(task *models.Task) models.RetryPolicy {
+	if task.Retry != nil {
+		return *task.Retry
+	}
+	if rp, ok := p.Handlers.RetryPolicy(taskTypeOf(task)); ok {
+		return rp
+	}
+	return models.RetryPolicy{MaxAttempts: 1}
+}
+
+// backoffDelay returns how long to wait before the attempt following
+// attempt number n (1-based).
+func (p *TaskPool) backoffDelay(rp models.RetryPolicy, n int) time.Duration {
+	base := rp.InitialDelay.Std()
+	if base <= 0 {
+		base = DefaultRetryDelay
+	}
+
+	var d time.Duration
+	switch rp.Backoff {
+	case models.BackoffConstant:
+		d = base
+	case models.BackoffLinear:
+		d = base * time.Duration(n)
+	default:
+		if f := float64(base) * math.Pow(2, float64(n-1)); f >= math.MaxInt64 {
+			d = math.MaxInt64
+		} else {
+			d = time.Duration(f)
+		}
+	}
+
+	if rp.Jitter > 0 {
+		spread := float64(d) * rp.Jitter
+		if f := float64(d) - spread + p.random()*2*spread; f < math.MaxInt64 {
+			d = time.Duration(f)
+		}
+	}
+	if maxDelay := rp.MaxDelay.Std(); maxDelay > 0 && d > maxDelay {
+		d = maxDelay
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// scheduleRetry parks a failed task in the retrying state until its backoff
+// delay has passed. The task is still its worker's until it is scheduled,
+// so it is saved before taking p.mu.
+func (p *TaskPool) scheduleRetry(task *models.Task, delay time.Duration) {
+	next := p.now().Add(delay).UTC()
+	task.Status = models.Retrying
+	task.NextAttemptAt = &next
+	p.updateTask(task)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.publish(task, 0)
+	p.scheduleLocked(task, next)
+}
+
+func (p *TaskPool) updateTask(task *models.Task) {
+	if err := p.Store.UpdateTask(context.Background(), task); err != nil {
+		slog.Error("failed to update task", "task_id", task.ID, "error", err)
+	}
+}
+
+func taskTypeOf(task *models.Task) string {
+	if task.Type == "" {
+		return DefaultTaskType
+	}
+	return task.Type
+}
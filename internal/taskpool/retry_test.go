@@ -0,0 +1,144 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestBackoffDelay tests the delay computed by each backoff strategy
+func TestBackoffDelay(t *testing.T) {
+	pool := NewTaskPool(1, store.NewMemoryStore())
+	second := models.Duration(time.Second)
+
+	tests := []struct {
+		name    string
+		policy  models.RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"constant", models.RetryPolicy{Backoff: models.BackoffConstant, InitialDelay: second}, 4, time.Second},
+		{"linear", models.RetryPolicy{Backoff: models.BackoffLinear, InitialDelay: second}, 3, 3 * time.Second},
+		{"exponential", models.RetryPolicy{Backoff: models.BackoffExponential, InitialDelay: second}, 4, 8 * time.Second},
+		{"default is exponential", models.RetryPolicy{InitialDelay: second}, 3, 4 * time.Second},
+		{"capped", models.RetryPolicy{InitialDelay: second, MaxDelay: models.Duration(5 * time.Second)}, 10, 5 * time.Second},
+		{"huge attempt is capped", models.RetryPolicy{InitialDelay: second, MaxDelay: models.Duration(time.Minute)}, 200, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pool.backoffDelay(tt.policy, tt.attempt); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestBackoffJitter tests that jitter stays within the configured fraction
+func TestBackoffJitter(t *testing.T) {
+	pool := NewTaskPool(1, store.NewMemoryStore())
+	policy := models.RetryPolicy{Backoff: models.BackoffConstant, InitialDelay: models.Duration(10 * time.Second), Jitter: 0.5}
+
+	pool.random = func() float64 { return 0 }
+	if got := pool.backoffDelay(policy, 1); got != 5*time.Second {
+		t.Errorf("Expected lower bound 5s, got %v", got)
+	}
+	pool.random = func() float64 { return 0.999999 }
+	if got := pool.backoffDelay(policy, 1); got < 14*time.Second || got > 15*time.Second {
+		t.Errorf("Expected upper bound close to 15s, got %v", got)
+	}
+}
+
+// TestValidateRetryPolicy tests rejection of invalid retry settings
+func TestValidateRetryPolicy(t *testing.T) {
+	if err := ValidateRetryPolicy(nil); err != nil {
+		t.Errorf("nil policy should be valid, got %v", err)
+	}
+	if err := ValidateRetryPolicy(&models.RetryPolicy{MaxAttempts: 3, Backoff: models.BackoffLinear}); err != nil {
+		t.Errorf("Expected valid policy, got %v", err)
+	}
+	for _, rp := range []models.RetryPolicy{
+		{MaxAttempts: 0},
+		{MaxAttempts: MaxRetryAttempts + 1},
+		{MaxAttempts: 2, Backoff: "fibonacci"},
+		{MaxAttempts: 2, InitialDelay: -1},
+		{MaxAttempts: 2, Jitter: 1.5},
+	} {
+		if err := ValidateRetryPolicy(&rp); !errors.Is(err, ErrInvalidRetryPolicy) {
+			t.Errorf("Expected ErrInvalidRetryPolicy for %+v, got %v", rp, err)
+		}
+	}
+}
+
+// TestWorkerRetriesUntilSuccess tests that a flaky task is retried and records its attempt history
+func TestWorkerRetriesUntilSuccess(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	var calls atomic.Int32
+	pool.Handlers.Register("flaky", func(ctx context.Context, task *models.Task) (any, error) {
+		if calls.Add(1) < 3 {
+			return nil, errors.New("temporary failure")
+		}
+		return "ok", nil
+	})
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	task := &models.Task{
+		ID:    "flaky-task",
+		Title: "Flaky",
+		Type:  "flaky",
+		Retry: &models.RetryPolicy{MaxAttempts: 5, Backoff: models.BackoffConstant, InitialDelay: models.Duration(10 * time.Millisecond)},
+	}
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
+
+	done := waitForTaskStatus(store, task.ID, models.Completed, 2*time.Second)
+	if done == nil {
+		t.Fatal("Flaky task did not complete")
+	}
+	if done.Attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", done.Attempts)
+	}
+	if len(done.History) != 3 || done.History[0].Error != "temporary failure" || done.History[2].Error != "" {
+		t.Errorf("Unexpected attempt history: %+v", done.History)
+	}
+	if done.LastError != "temporary failure" {
+		t.Errorf("Expected last_error to keep the previous failure, got %q", done.LastError)
+	}
+}
+
+// TestWorkerRetriesExhausted tests that a task fails after its type's max attempts
+func TestWorkerRetriesExhausted(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	pool.Handlers.Register("always-fails", func(ctx context.Context, task *models.Task) (any, error) {
+		panic("still broken")
+	}, WithRetryPolicy(models.RetryPolicy{MaxAttempts: 2, InitialDelay: models.Duration(200 * time.Millisecond)}))
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	task := &models.Task{ID: "doomed", Title: "Doomed", Type: "always-fails"}
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
+
+	if retrying := waitForTaskStatus(store, task.ID, models.Retrying, time.Second); retrying == nil || retrying.NextAttemptAt == nil {
+		t.Errorf("Expected task to pass through 'retrying' with a next attempt time, got %+v", retrying)
+	}
+	failed := waitForTaskStatus(store, task.ID, models.Failed, 2*time.Second)
+	if failed == nil {
+		t.Fatal("Task was not marked failed")
+	}
+	if failed.Attempts != 2 || len(failed.History) != 2 {
+		t.Errorf("Expected 2 recorded attempts, got %d (history %d)", failed.Attempts, len(failed.History))
+	}
+	if failed.Error != "handler panicked: still broken" {
+		t.Errorf("Unexpected final error: %q", failed.Error)
+	}
+}
@@ -0,0 +1,131 @@
+package taskpool
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+type scheduledItem struct {
+	task  *models.Task
+	at    time.Time
+	seq   uint64
+	index int
+}
+
+type scheduleHeap []*scheduledItem
+
+func (h scheduleHeap) Len() int { return len(h) }
+func (h scheduleHeap) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h scheduleHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *scheduleHeap) Push(x any) {
+	item := x.(*scheduledItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+func (h *scheduleHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// scheduler parks tasks that are not due yet (delayed submissions and
+// retries waiting out their backoff) outside the dispatch queue, so they
+// hold neither a worker nor a queue slot. A single timer is armed for the
+// earliest entry. All methods require the pool's mutex.
+type scheduler struct {
+	items scheduleHeap
+	byID  map[string]*scheduledItem
+	seq   uint64
+	timer *time.Timer
+}
+
+func (s *scheduler) len() int { return len(s.items) }
+
+// scheduleLocked parks task until at and re-arms the timer if it is now the
+// earliest entry.
+func (p *TaskPool) scheduleLocked(task *models.Task, at time.Time) {
+	s := &p.scheduler
+	if s.byID == nil {
+		s.byID = make(map[string]*scheduledItem)
+	}
+	s.seq++
+	item := &scheduledItem{task: task, at: at, seq: s.seq}
+	heap.Push(&s.items, item)
+	s.byID[task.ID] = item
+	if item.index == 0 {
+		p.armLocked()
+	}
+}
+
+// unscheduleLocked removes a parked task, reporting whether it was found.
+func (p *TaskPool) unscheduleLocked(id string) (*models.Task, bool) {
+	s := &p.scheduler
+	item, ok := s.byID[id]
+	if !ok {
+		return nil, false
+	}
+	heap.Remove(&s.items, item.index)
+	delete(s.byID, id)
+	p.armLocked()
+	return item.task, true
+}
+
+func (p *TaskPool) armLocked() {
+	s := &p.scheduler
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.items) == 0 {
+		return
+	}
+	delay := max(s.items[0].at.Sub(p.now()), 0)
+	s.timer = time.AfterFunc(delay, p.releaseDue)
+}
+
+// releaseDue moves every task whose time has come into the dispatch queue.
+// Released tasks bypass the capacity check since the pool already accepted
+// them. They are taken off the scheduler and saved as pending before being
+// queued, so the store is written without p.mu and no worker can have
+// picked them up yet.
+func (p *TaskPool) releaseDue() {
+	p.mu.Lock()
+	s := &p.scheduler
+	now := p.now()
+	var due []*models.Task
+	for len(s.items) > 0 && !s.items[0].at.After(now) {
+		item := heap.Pop(&s.items).(*scheduledItem)
+		delete(s.byID, item.task.ID)
+		due = append(due, item.task)
+	}
+	p.armLocked()
+	p.mu.Unlock()
+
+	for _, task := range due {
+		task.Status = models.Pending
+		task.NextAttemptAt = nil
+		p.updateTask(task)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, task := range due {
+		p.publish(task, 0)
+		p.enqueueLocked(task)
+	}
+}
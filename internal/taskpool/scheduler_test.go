@@ -0,0 +1,90 @@
+package taskpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestScheduledTaskReleasedWhenDue tests that a delayed task waits outside the queue and is released on time
+func TestScheduledTaskReleasedWhenDue(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(1, store)
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	runAt := time.Now().Add(100 * time.Millisecond)
+	task := &models.Task{ID: "later", Title: "Later", RunAt: &runAt}
+	if _, err := pool.AddTask(ctx, log, task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	stored, _ := store.GetTask(ctx, task.ID)
+	if stored.Status != models.Scheduled {
+		t.Errorf("Expected status 'scheduled', got '%s'", stored.Status)
+	}
+	if pool.Len() != 0 || pool.Scheduled() != 1 {
+		t.Errorf("Scheduled task should not occupy the queue (queued %d, scheduled %d)", pool.Len(), pool.Scheduled())
+	}
+
+	// The queue slot is still free for immediate work
+	if _, err := pool.AddTask(ctx, log, &models.Task{ID: "now", Title: "Now"}); err != nil {
+		t.Fatalf("Expected free queue slot while task is scheduled: %v", err)
+	}
+
+	if waitForTaskStatus(store, task.ID, models.Pending, time.Second) == nil {
+		t.Fatal("Scheduled task was not released")
+	}
+	if pool.Len() != 2 || pool.Scheduled() != 0 {
+		t.Errorf("Expected released task in queue (queued %d, scheduled %d)", pool.Len(), pool.Scheduled())
+	}
+}
+
+// TestSchedulerOrdersByRunTime tests that the earliest task is released first regardless of insertion order
+func TestSchedulerOrdersByRunTime(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store, WithAgingInterval(0))
+	log := logger.NewTestLogger()
+
+	second := time.Now().Add(120 * time.Millisecond)
+	first := time.Now().Add(60 * time.Millisecond)
+	pool.AddTask(context.Background(), log, &models.Task{ID: "second", Title: "Second", RunAt: &second})
+	pool.AddTask(context.Background(), log, &models.Task{ID: "first", Title: "First", RunAt: &first})
+
+	if waitForTaskStatus(store, "first", models.Pending, time.Second) == nil {
+		t.Fatal("First task was not released")
+	}
+	if s, _ := store.GetTask(context.Background(), "second"); s.Status != models.Scheduled {
+		t.Errorf("Second task released too early: %s", s.Status)
+	}
+	if waitForTaskStatus(store, "second", models.Pending, time.Second) == nil {
+		t.Fatal("Second task was not released")
+	}
+}
+
+// TestRecoverScheduledTasks tests that scheduled tasks are parked again after a restart
+func TestRecoverScheduledTasks(t *testing.T) {
+	store := store.NewMemoryStore()
+	ctx := context.Background()
+	runAt := time.Now().Add(time.Hour)
+	store.AddTask(ctx, &models.Task{ID: "tomorrow", Title: "Tomorrow", Status: models.Scheduled, RunAt: &runAt})
+
+	pool := NewTaskPool(5, store)
+	if _, err := pool.Recover(ctx, logger.NewTestLogger()); err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	if pool.Scheduled() != 1 || pool.Len() != 0 {
+		t.Errorf("Expected task to be parked in the scheduler (queued %d, scheduled %d)", pool.Len(), pool.Scheduled())
+	}
+
+	if _, err := pool.Cancel(ctx, "tomorrow"); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if pool.Scheduled() != 0 {
+		t.Error("Cancelled task still scheduled")
+	}
+}
@@ -0,0 +1,39 @@
+package taskpool
+
+import "github.com/shayanmkpr/task-pool/internal/models"
+
+// QueueStats is a snapshot of where the pool's accepted tasks are.
+type QueueStats struct {
+	Capacity   int             `json:"capacity"`  // summed over queues
+	Queued     int             `json:"queued"`    // waiting for a worker
+	Overflow   int             `json:"overflow"`  // spilled to disk, behind the queued tasks
+	Scheduled  int             `json:"scheduled"` // waiting for run_at or a retry
+	Blocked    int             `json:"blocked"`   // waiting for dependencies
+	Running    int             `json:"running"`
+	Paused     int             `json:"paused"`      // queued tasks held back by a pause
+	Limited    int             `json:"limited"`     // queued tasks held back by a concurrency limit
+	OldestWait models.Duration `json:"oldest_wait"` // longest wait among tasks that are not held back
+}
+
+// Stats returns a consistent snapshot of the pool's queues, summed over
+// every named queue.
+func (p *TaskPool) Stats() QueueStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	stats := QueueStats{
+		Scheduled: p.scheduler.len(),
+		Blocked:   p.deps.len(),
+		Running:   len(p.running),
+	}
+	for _, q := range p.queues {
+		stats.Capacity += p.capacityOf(q)
+		stats.Queued += q.Len()
+		stats.Overflow += q.spilled
+		paused, limited, wait := p.waitingLocked(q, now)
+		stats.Paused += paused
+		stats.Limited += limited
+		stats.OldestWait = max(stats.OldestWait, wait)
+	}
+	return stats
+}
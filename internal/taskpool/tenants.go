@@ -0,0 +1,224 @@
+package taskpool
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+// DefaultTenant owns tasks submitted without a tenant.
+const DefaultTenant = "default"
+
+// AnyTenant keys the limits applied to tenants not configured by name.
+const AnyTenant = "*"
+
+var (
+	ErrTenantQuotaExceeded = errors.New("tenant quota exceeded")
+	ErrInvalidTenantConfig = errors.New("invalid tenant config")
+)
+
+// TenantLimits bounds one tenant's use of the pool. Zero Quota or
+// Concurrency means no limit beyond the pool's own.
+type TenantLimits struct {
+	Weight      int `json:"weight"`      // share of workers relative to other tenants, at least 1
+	Quota       int `json:"quota"`       // most tasks queued at once, over every queue
+	Concurrency int `json:"concurrency"` // most tasks running at once
+}
+
+// tenantState is what the pool tracks per tenant. Tenants with neither
+// configured limits nor work are dropped.
+type tenantState struct {
+	name       string
+	limits     TenantLimits
+	configured bool
+	queued     int
+	reserved   int // slots claimed by submissions still writing to the store
+	spilled    int // tasks waiting in a queue's overflow
+	running    int
+	dispatched uint64
+	drained    drainRate
+
+	// pass is the tenant's virtual finish time for stride scheduling: each
+	// dispatch advances it by 1/weight and workers serve the lowest pass.
+	pass float64
+}
+
+func (t *tenantState) atConcurrencyLimit() bool {
+	return t.limits.Concurrency > 0 && t.running >= t.limits.Concurrency
+}
+
+type tenants struct {
+	limits map[string]TenantLimits
+	state  map[string]*tenantState
+	clock  float64 // pass of the tenant served last
+}
+
+// WithTenants sets per-tenant limits, keyed by tenant name. Limits under
+// AnyTenant apply to every tenant not listed. Tenants without limits get
+// weight 1 and no quota or concurrency limit.
+func WithTenants(limits map[string]TenantLimits) Option {
+	return func(p *TaskPool) {
+		p.tenants.limits = maps.Clone(limits)
+		for name := range limits {
+			if name != AnyTenant {
+				p.tenantLocked(name).configured = true
+			}
+		}
+	}
+}
+
+// ParseTenantLimits builds WithTenants limits from three lists in the
+// format of ParseQueueCapacities: weights ("teamA=3,teamB=1"), queue quotas
+// and concurrency limits. AnyTenant ("*") sets the limits for unlisted
+// tenants.
+func ParseTenantLimits(weights, quotas, concurrency string) (map[string]TenantLimits, error) {
+	limits := make(map[string]TenantLimits)
+	for _, list := range []struct {
+		s   string
+		set func(*TenantLimits, int)
+	}{
+		{weights, func(l *TenantLimits, n int) { l.Weight = n }},
+		{quotas, func(l *TenantLimits, n int) { l.Quota = n }},
+		{concurrency, func(l *TenantLimits, n int) { l.Concurrency = n }},
+	} {
+		counts, err := parseNamedCounts(list.s, 0, ErrInvalidTenantConfig)
+		if err != nil {
+			return nil, err
+		}
+		for name, n := range counts {
+			l := limits[name]
+			list.set(&l, n)
+			limits[name] = l
+		}
+	}
+	return limits, nil
+}
+
+// tenantOf returns the tenant that owns task.
+func tenantOf(task *models.Task) string {
+	if task.Tenant == "" {
+		return DefaultTenant
+	}
+	return task.Tenant
+}
+
+// limitsOf returns the limits that apply to the named tenant.
+func (p *TaskPool) limitsOf(name string) TenantLimits {
+	limits, ok := p.tenants.limits[name]
+	if !ok {
+		limits = p.tenants.limits[AnyTenant]
+	}
+	if limits.Weight <= 0 {
+		limits.Weight = 1
+	}
+	return limits
+}
+
+// tenantLocked returns the state of the named tenant, creating it if
+// needed. Callers must hold p.mu.
+func (p *TaskPool) tenantLocked(name string) *tenantState {
+	t, ok := p.tenants.state[name]
+	if !ok {
+		if p.tenants.state == nil {
+			p.tenants.state = make(map[string]*tenantState)
+		}
+		t = &tenantState{name: name, limits: p.limitsOf(name)}
+		p.tenants.state[name] = t
+	}
+	return t
+}
+
+// forgetIdleLocked drops t once it has no work, unless it was configured by
+// name. Callers must hold p.mu.
+func (p *TaskPool) forgetIdleLocked(t *tenantState) {
+	if !t.configured && t.queued == 0 && t.reserved == 0 && t.spilled == 0 && t.running == 0 {
+		delete(p.tenants.state, t.name)
+	}
+}
+
+// checkQuotasLocked reports whether each tenant can take n more queued
+// tasks. Callers must hold p.mu.
+func (p *TaskPool) checkQuotasLocked(counts map[string]int) error {
+	for name, n := range counts {
+		quota := p.limitsOf(name).Quota
+		if quota == 0 {
+			continue
+		}
+		used := 0
+		if t, ok := p.tenants.state[name]; ok {
+			used = t.queued + t.reserved + t.spilled
+		}
+		if used+n > quota {
+			return fmt.Errorf("%w: %w for %q", ErrTaskQueueFull, ErrTenantQuotaExceeded, name)
+		}
+	}
+	return nil
+}
+
+// popTenantLocked removes the next task from q, choosing among the tenants
+// with tasks in q by weighted fair share: the tenant with the lowest pass
+// goes first, skipping any at their concurrency limit. Under overload each
+// tenant is therefore dispatched in proportion to its weight, however much
+// it has queued. Callers must hold p.mu.
+func (p *TaskPool) popTenantLocked(q *taskQueue) *models.Task {
+	eligible := make([]*tenantState, 0, len(q.tenants))
+	for name := range q.tenants {
+		if t := p.tenantLocked(name); !t.atConcurrencyLimit() {
+			eligible = append(eligible, t)
+		}
+	}
+	slices.SortFunc(eligible, func(a, b *tenantState) int {
+		return cmp.Or(cmp.Compare(a.pass, b.pass), cmp.Compare(a.name, b.name))
+	})
+
+	for _, t := range eligible {
+		task := p.nextRunnableLocked(q, t.name)
+		if task == nil {
+			continue // everything this tenant has in q is paused or at a concurrency limit
+		}
+		q.size--
+		t.queued--
+		t.running++
+		p.concurrency.acquire(task)
+		t.dispatched++
+		t.drained.record(p.now())
+		p.tenants.clock = t.pass
+		t.pass += 1 / float64(t.limits.Weight)
+		return task
+	}
+	return nil
+}
+
+// TenantStats describes one tenant's limits and use of the pool.
+type TenantStats struct {
+	Name string `json:"name"`
+	TenantLimits
+	Queued     int    `json:"queued"`
+	Overflow   int    `json:"overflow"` // tasks spilled to disk
+	Running    int    `json:"running"`
+	Dispatched uint64 `json:"dispatched"` // tasks handed to workers since start
+}
+
+// TenantStats returns a snapshot of every configured tenant and every other
+// tenant with work in the pool, sorted by name.
+func (p *TaskPool) TenantStats() []TenantStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make([]TenantStats, 0, len(p.tenants.state))
+	for _, name := range slices.Sorted(maps.Keys(p.tenants.state)) {
+		t := p.tenants.state[name]
+		stats = append(stats, TenantStats{
+			Name:         name,
+			TenantLimits: t.limits,
+			Queued:       t.queued,
+			Overflow:     t.spilled,
+			Running:      t.running,
+			Dispatched:   t.dispatched,
+		})
+	}
+	return stats
+}
@@ -0,0 +1,156 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestTenantFairShare tests that backlogged tenants are dispatched in proportion to their weights
+func TestTenantFairShare(t *testing.T) {
+	pool := NewTaskPool(500, store.NewMemoryStore(), WithTenants(map[string]TenantLimits{"big": {Weight: 3}}))
+	// The noisy tenant fills the queue first; the others still get their share.
+	for i := range 300 {
+		pool.enqueue(&models.Task{ID: fmt.Sprintf("noisy-%d", i), Tenant: "noisy"})
+	}
+	for i := range 100 {
+		pool.enqueue(&models.Task{ID: fmt.Sprintf("big-%d", i), Tenant: "big"})
+	}
+
+	counts := make(map[string]int)
+	for range 40 {
+		counts[pool.popLocked().Tenant]++
+	}
+	if counts["big"] != 30 || counts["noisy"] != 10 {
+		t.Errorf("Expected a 3:1 split, got %v", counts)
+	}
+
+	// A tenant that shows up late starts level with the others instead of
+	// being owed every turn it missed.
+	for i := range 10 {
+		pool.enqueue(&models.Task{ID: fmt.Sprintf("late-%d", i), Tenant: "late"})
+	}
+	counts = make(map[string]int)
+	for range 5 {
+		counts[pool.popLocked().Tenant]++
+	}
+	if counts["late"] > 2 {
+		t.Errorf("Expected the late tenant to get its share, not a burst, got %v", counts)
+	}
+}
+
+// TestTenantConcurrencyLimit tests that a tenant at its concurrency limit is skipped until a task finishes
+func TestTenantConcurrencyLimit(t *testing.T) {
+	pool := NewTaskPool(10, store.NewMemoryStore(), WithTenants(map[string]TenantLimits{"a": {Concurrency: 1}}))
+	pool.enqueue(&models.Task{ID: "a1", Tenant: "a"})
+	pool.enqueue(&models.Task{ID: "a2", Tenant: "a"})
+	pool.enqueue(&models.Task{ID: "b1", Tenant: "b"})
+
+	first := pool.popLocked()
+	pool.running[first.ID] = func(error) {}
+	if first.ID != "a1" {
+		t.Fatalf("Expected a1 first, got %s", first.ID)
+	}
+	if task := pool.popLocked(); task == nil || task.ID != "b1" {
+		t.Fatalf("Expected b1 while a is at its limit, got %v", task)
+	}
+	if task := pool.popLocked(); task != nil {
+		t.Fatalf("Expected nothing while a is at its limit, got %s", task.ID)
+	}
+
+	pool.done(first)
+	if task := pool.popLocked(); task == nil || task.ID != "a2" {
+		t.Fatalf("Expected a2 once a1 finished, got %v", task)
+	}
+	stats := pool.TenantStats()
+	if len(stats) != 2 || stats[0].Name != "a" || stats[0].Running != 1 || stats[0].Dispatched != 2 || stats[1].Name != "b" {
+		t.Errorf("Unexpected tenant stats: %+v", stats)
+	}
+}
+
+// TestTenantQuota tests that a tenant cannot queue more than its quota while others still can
+func TestTenantQuota(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewTestLogger()
+	pool := NewTaskPool(10, store.NewMemoryStore(), WithTenants(map[string]TenantLimits{
+		"a":       {Quota: 2},
+		AnyTenant: {Quota: 5},
+	}))
+
+	for i := range 2 {
+		if _, err := pool.AddTask(ctx, log, &models.Task{ID: fmt.Sprintf("a%d", i), Title: "A", Tenant: "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := pool.AddTask(ctx, log, &models.Task{ID: "a2", Title: "A", Tenant: "a"})
+	if !errors.Is(err, ErrTenantQuotaExceeded) || !errors.Is(err, ErrTaskQueueFull) {
+		t.Errorf("Expected ErrTenantQuotaExceeded, got %v", err)
+	}
+	task := &models.Task{ID: "d", Title: "D"}
+	if _, err := pool.AddTask(ctx, log, task); err != nil || task.Tenant != DefaultTenant {
+		t.Errorf("Expected the default tenant to accept the task, got %q, %v", task.Tenant, err)
+	}
+
+	// Unlisted tenants get the AnyTenant quota, counted over a whole batch
+	batch := make([]*models.Task, 6)
+	for i := range batch {
+		batch[i] = &models.Task{ID: fmt.Sprintf("c%d", i), Title: "C", Tenant: "c"}
+	}
+	if err := pool.AddBatch(ctx, log, batch); !errors.Is(err, ErrTenantQuotaExceeded) {
+		t.Errorf("Expected the batch to exceed the quota, got %v", err)
+	}
+	if err := pool.AddBatch(ctx, log, batch[:5]); err != nil {
+		t.Errorf("Expected a batch within the quota to be accepted, got %v", err)
+	}
+
+	// Cancelling a queued task frees its place
+	if _, err := pool.Cancel(ctx, "a0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.AddTask(ctx, log, &models.Task{ID: "a3", Title: "A", Tenant: "a"}); err != nil {
+		t.Errorf("Expected room after a cancel, got %v", err)
+	}
+}
+
+// TestTenantIdempotencyKeys tests that the same key in two tenants makes two tasks
+func TestTenantIdempotencyKeys(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewTestLogger()
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st, WithIdempotency(st, 0))
+
+	idA, _, err := pool.AddTaskIdempotent(ctx, log, "key", "hash", &models.Task{ID: "a", Title: "A", Tenant: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idB, replayed, err := pool.AddTaskIdempotent(ctx, log, "key", "hash", &models.Task{ID: "b", Title: "B", Tenant: "b"})
+	if err != nil || replayed || idA == idB {
+		t.Errorf("Expected a separate task for the second tenant, got %s (replayed %v), %v", idB, replayed, err)
+	}
+	if id, replayed, _ := pool.AddTaskIdempotent(ctx, log, "key", "hash", &models.Task{ID: "a2", Title: "A", Tenant: "a"}); !replayed || id != idA {
+		t.Errorf("Expected a replay within the tenant, got %s (replayed %v)", id, replayed)
+	}
+}
+
+// TestParseTenantLimits tests the -tenant-* flag syntax
+func TestParseTenantLimits(t *testing.T) {
+	limits, err := ParseTenantLimits("a=3,b", "a=100,*=10", "a=4")
+	if err == nil {
+		t.Fatalf("Expected an error for a weight without a value, got %v", limits)
+	}
+	limits, err = ParseTenantLimits("a=3", "a=100,*=10", "a=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits["a"] != (TenantLimits{Weight: 3, Quota: 100, Concurrency: 4}) || limits[AnyTenant] != (TenantLimits{Quota: 10}) {
+		t.Errorf("Unexpected limits: %+v", limits)
+	}
+	if _, err := ParseTenantLimits("", "", "a=0"); !errors.Is(err, ErrInvalidTenantConfig) {
+		t.Errorf("Expected an error for a zero limit, got %v", err)
+	}
+}
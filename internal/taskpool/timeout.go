@@ -0,0 +1,74 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/models"
+)
+
+var (
+	ErrInvalidTask      = errors.New("invalid task")
+	ErrTaskTimedOut     = errors.New("task attempt timed out")
+	ErrDeadlineExceeded = errors.New("task deadline exceeded")
+)
+
+// WithTimeouts sets the per-attempt timeout applied to tasks submitted
+// without one, and the largest timeout a task may request. Zero disables
+// either bound.
+func WithTimeouts(defaultTimeout, maxTimeout time.Duration) Option {
+	return func(p *TaskPool) {
+		p.defaultTimeout = defaultTimeout
+		p.maxTimeout = maxTimeout
+	}
+}
+
+// applyTimeouts validates a new task's timeout and deadline and fills in the
+// server default. Tasks without an explicit timeout are still bounded by the
+// maximum when one is configured.
+func (p *TaskPool) applyTimeouts(task *models.Task) error {
+	if task.Timeout < 0 {
+		return fmt.Errorf("%w: timeout cannot be negative", ErrInvalidTask)
+	}
+	if p.maxTimeout > 0 && task.Timeout.Std() > p.maxTimeout {
+		return fmt.Errorf("%w: timeout exceeds maximum of %v", ErrInvalidTask, p.maxTimeout)
+	}
+	if task.Timeout == 0 {
+		task.Timeout = models.Duration(p.defaultTimeout)
+	}
+	if task.Timeout == 0 {
+		task.Timeout = models.Duration(p.maxTimeout)
+	}
+	if task.Deadline != nil && !task.Deadline.After(p.now()) {
+		return fmt.Errorf("%w: deadline is in the past", ErrInvalidTask)
+	}
+	return nil
+}
+
+// attemptContext bounds a single attempt by the task's timeout and deadline.
+func (p *TaskPool) attemptContext(ctx context.Context, task *models.Task) (context.Context, context.CancelFunc) {
+	cancels := make([]context.CancelFunc, 0, 2)
+	if task.Deadline != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadlineCause(ctx, *task.Deadline, ErrDeadlineExceeded)
+		cancels = append(cancels, cancel)
+	}
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, task.Timeout.Std(), ErrTaskTimedOut)
+		cancels = append(cancels, cancel)
+	}
+	return ctx, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
+
+// beforeDeadline reports whether a retry after delay would still start
+// before the task's deadline.
+func (p *TaskPool) beforeDeadline(task *models.Task, delay time.Duration) bool {
+	return task.Deadline == nil || p.now().Add(delay).Before(*task.Deadline)
+}
@@ -0,0 +1,128 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+func blockUntilDone(ctx context.Context, task *models.Task) (any, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// TestApplyTimeouts tests default and maximum timeout handling at submission
+func TestApplyTimeouts(t *testing.T) {
+	pool := NewTaskPool(5, store.NewMemoryStore(), WithTimeouts(time.Minute, time.Hour))
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	task := &models.Task{ID: "defaulted", Title: "Defaulted"}
+	if _, err := pool.AddTask(ctx, log, task); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	if task.Timeout.Std() != time.Minute {
+		t.Errorf("Expected default timeout 1m, got %v", task.Timeout.Std())
+	}
+
+	tooLong := &models.Task{ID: "too-long", Title: "Too Long", Timeout: models.Duration(2 * time.Hour)}
+	if _, err := pool.AddTask(ctx, log, tooLong); !errors.Is(err, ErrInvalidTask) {
+		t.Errorf("Expected ErrInvalidTask for timeout above maximum, got %v", err)
+	}
+
+	past := time.Now().Add(-time.Second)
+	expired := &models.Task{ID: "expired", Title: "Expired", Deadline: &past}
+	if _, err := pool.AddTask(ctx, log, expired); !errors.Is(err, ErrInvalidTask) {
+		t.Errorf("Expected ErrInvalidTask for past deadline, got %v", err)
+	}
+
+	bounded := NewTaskPool(5, store.NewMemoryStore(), WithTimeouts(0, time.Hour))
+	task = &models.Task{ID: "bounded", Title: "Bounded"}
+	bounded.AddTask(ctx, log, task)
+	if task.Timeout.Std() != time.Hour {
+		t.Errorf("Expected tasks without a timeout to be bounded by the maximum, got %v", task.Timeout.Std())
+	}
+}
+
+// TestWorkerTaskTimeout tests that a slow attempt ends as timed_out
+func TestWorkerTaskTimeout(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	task := &models.Task{ID: "slow", Title: "Slow", Duration: 5, Timeout: models.Duration(50 * time.Millisecond)}
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
+
+	timedOut := waitForTaskStatus(store, task.ID, models.TimedOut, time.Second)
+	if timedOut == nil {
+		t.Fatal("Task was not marked timed_out")
+	}
+	if timedOut.Error != ErrTaskTimedOut.Error() {
+		t.Errorf("Unexpected error: %q", timedOut.Error)
+	}
+}
+
+// TestWorkerTimeoutIsRetried tests that attempt timeouts consume retries before timing out
+func TestWorkerTimeoutIsRetried(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	pool.Handlers.Register("blocks", blockUntilDone)
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	task := &models.Task{
+		ID:      "retried-timeout",
+		Title:   "Retried Timeout",
+		Type:    "blocks",
+		Timeout: models.Duration(30 * time.Millisecond),
+		Retry:   &models.RetryPolicy{MaxAttempts: 3, Backoff: models.BackoffConstant, InitialDelay: models.Duration(10 * time.Millisecond)},
+	}
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
+
+	timedOut := waitForTaskStatus(store, task.ID, models.TimedOut, 2*time.Second)
+	if timedOut == nil {
+		t.Fatal("Task was not marked timed_out")
+	}
+	if timedOut.Attempts != 3 {
+		t.Errorf("Expected 3 attempts before giving up, got %d", timedOut.Attempts)
+	}
+}
+
+// TestWorkerDeadlineStopsRetries tests that an absolute deadline ends the task without further retries
+func TestWorkerDeadlineStopsRetries(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	pool.Handlers.Register("blocks", blockUntilDone)
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	task := &models.Task{
+		ID:       "deadline",
+		Title:    "Deadline",
+		Type:     "blocks",
+		Deadline: &deadline,
+		Retry:    &models.RetryPolicy{MaxAttempts: 5, InitialDelay: models.Duration(10 * time.Millisecond)},
+	}
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
+
+	timedOut := waitForTaskStatus(store, task.ID, models.TimedOut, time.Second)
+	if timedOut == nil {
+		t.Fatal("Task was not marked timed_out")
+	}
+	if timedOut.Attempts != 1 || timedOut.Error != ErrDeadlineExceeded.Error() {
+		t.Errorf("Expected a single attempt ending at the deadline, got %d attempts, error %q", timedOut.Attempts, timedOut.Error)
+	}
+}
@@ -0,0 +1,73 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+
+	"github.com/shayanmkpr/task-pool/internal/events"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// Wait blocks until the task reaches a terminal state or ctx is done. It is
+// driven by the event bus: the store is only read when the task's own
+// terminal event arrives. When ctx ends first, the task's current state is
+// returned together with ctx's error. The store is read without ctx, so an
+// already expired ctx still yields the current state.
+func (p *TaskPool) Wait(ctx context.Context, id string) (*models.Task, error) {
+	// Subscribe before the first read so a transition in between is not lost.
+	sub, _, _ := p.Events.Subscribe(0)
+	defer func() { sub.Close() }()
+
+	task, err := p.lookup(context.Background(), id)
+	if err != nil || task.Status.Terminal() {
+		return task, err
+	}
+
+	last := p.Events.LastID()
+	for {
+		select {
+		case <-ctx.Done():
+			current, err := p.lookup(context.Background(), id)
+			if err != nil {
+				return nil, err
+			}
+			return current, ctx.Err()
+		case e, ok := <-sub.C:
+			if !ok {
+				// Cut off for falling behind; resume from the ring buffer.
+				var backlog []events.Event
+				sub, backlog, _ = p.Events.Subscribe(last)
+				for _, e := range backlog {
+					if e.TaskID == id && e.Status.Terminal() {
+						return p.lookup(context.Background(), id)
+					}
+				}
+				continue
+			}
+			last = e.ID
+			if e.Type == events.TaskStatus && e.TaskID == id && e.Status.Terminal() {
+				return p.lookup(context.Background(), id)
+			}
+		}
+	}
+}
+
+// GetTask returns the task with the given ID from the task store or, once
+// it has been dead-lettered, from the dead-letter store.
+func (p *TaskPool) GetTask(ctx context.Context, id string) (*models.Task, error) {
+	return p.lookup(ctx, id)
+}
+
+// lookup finds a task in the task store or, once it has been dead-lettered,
+// in the dead-letter store.
+func (p *TaskPool) lookup(ctx context.Context, id string) (*models.Task, error) {
+	task, err := p.Store.GetTask(ctx, id)
+	if errors.Is(err, store.ErrTaskNotFound) && p.deadLetters != nil {
+		dl, dlErr := p.deadLetters.GetDeadLetter(ctx, id)
+		if dlErr == nil {
+			return dl.Task, nil
+		}
+	}
+	return task, err
+}
@@ -0,0 +1,75 @@
+package taskpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// TestWaitReturnsWhenTaskFinishes tests that Wait wakes up on the task's terminal event
+func TestWaitReturnsWhenTaskFinishes(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(5, st)
+	release := make(chan struct{})
+	pool.Handlers.Register("gated", func(ctx context.Context, task *models.Task) (any, error) {
+		<-release
+		return "ok", nil
+	})
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	pool.AddTask(context.Background(), logger.NewTestLogger(), &models.Task{ID: "gated", Title: "Gated", Type: "gated"})
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	task, err := pool.Wait(ctx, "gated")
+	if err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if task.Status != models.Completed || string(task.Result) != `"ok"` {
+		t.Errorf("Expected completed task with result, got %+v", task)
+	}
+}
+
+// TestWaitTimeout tests that Wait gives up with the current state when ctx ends
+func TestWaitTimeout(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(5, st)
+	pool.AddTask(context.Background(), logger.NewTestLogger(), &models.Task{ID: "queued", Title: "Queued"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	task, err := pool.Wait(ctx, "queued")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if task == nil || task.Status != models.Pending {
+		t.Errorf("Expected current pending state, got %+v", task)
+	}
+}
+
+// TestWaitFinishedAndMissing tests the immediate answers for terminal, dead-lettered and unknown tasks
+func TestWaitFinishedAndMissing(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(5, st, WithDeadLetters(st))
+	ctx := context.Background()
+	st.AddTask(ctx, &models.Task{ID: "done", Title: "Done", Status: models.Completed})
+	st.AddDeadLetter(ctx, &models.DeadLetter{ID: "dead", Task: &models.Task{ID: "dead", Status: models.Failed}})
+
+	if task, err := pool.Wait(ctx, "done"); err != nil || task.Status != models.Completed {
+		t.Errorf("Expected completed task immediately, got %+v, %v", task, err)
+	}
+	if task, err := pool.Wait(ctx, "dead"); err != nil || task.Status != models.Failed {
+		t.Errorf("Expected dead-lettered task to be found, got %+v, %v", task, err)
+	}
+	if _, err := pool.Wait(ctx, "missing"); !errors.Is(err, store.ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, got %v", err)
+	}
+}
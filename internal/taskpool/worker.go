@@ -1,9 +1,15 @@
 package taskpool
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"sync/atomic"
 	"time"
 
+	"github.com/shayanmkpr/task-pool/internal/events"
 	"github.com/shayanmkpr/task-pool/internal/models"
 )
 
@@ -11,7 +17,10 @@ type Worker struct {
 	ID       int
 	TaskPool *TaskPool
 	Quit     chan struct{}
-	Assigned chan *models.Task
+
+	busy   atomic.Bool
+	queues atomic.Pointer[QueueWeights] // nil means every queue
+	exited chan struct{}                // closed when the worker's goroutine returns
 }
 
 func NewWorker(id int, pool *TaskPool) *Worker {
@@ -19,43 +28,155 @@ func NewWorker(id int, pool *TaskPool) *Worker {
 		ID:       id,
 		TaskPool: pool,
 		Quit:     make(chan struct{}),
-		Assigned: make(chan *models.Task, 1),
+		exited:   make(chan struct{}),
 	}
 }
 
 func (w *Worker) Start() {
 	go func() {
-		defer close(w.Assigned)
+		defer close(w.exited)
 		for {
-			select {
-			case task := <-w.TaskPool.Tasks: // reading from a buffered channel. This handles the Queue logic.
-				w.process(task)
-			case <-w.Quit:
-				// close(w.Assigned) // close the assigned channel.
+			task, ctx := w.TaskPool.nextFrom(w.Quit, w.Queues) // blocks on the worker's queues until a task or Quit arrives.
+			if task == nil {
 				fmt.Printf("Worker %d shutting down\n", w.ID) //fix
 				return
 			}
+			w.busy.Store(true)
+			w.process(ctx, task)
+			w.TaskPool.done(task)
+			w.busy.Store(false)
+			w.TaskPool.Events.Publish(events.Event{Type: events.WorkerIdle, WorkerID: w.ID})
 		}
 	}()
 }
 
-func (w *Worker) process(task *models.Task) {
-	defer func() { // not sure
+// Queues returns the queues the worker takes tasks from; nil means all.
+func (w *Worker) Queues() QueueWeights {
+	if q := w.queues.Load(); q != nil {
+		return *q
+	}
+	return nil
+}
+
+// SetQueues assigns the worker to queues. It takes effect the next time the
+// worker looks for a task; a worker blocked waiting needs the pool woken.
+func (w *Worker) SetQueues(weights QueueWeights) {
+	if len(weights) == 0 {
+		w.queues.Store(nil)
+		return
+	}
+	weights = maps.Clone(weights)
+	w.queues.Store(&weights)
+}
+
+// Busy reports whether the worker is running a task.
+func (w *Worker) Busy() bool { return w.busy.Load() }
+
+// Exited reports whether a stopped worker has finished its last task and
+// returned.
+func (w *Worker) Exited() bool {
+	select {
+	case <-w.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *Worker) process(ctx context.Context, task *models.Task) {
+	pool := w.TaskPool
+	taskType := taskTypeOf(task)
+	handler, ok := pool.Handlers.Lookup(taskType)
+	if !ok {
+		w.finish(task, models.Failed, fmt.Sprintf("no handler registered for task type %q", taskType))
+		return
+	}
+	if task.Deadline != nil && !pool.now().Before(*task.Deadline) {
+		w.finish(task, models.TimedOut, ErrDeadlineExceeded.Error())
+		return
+	}
+
+	attemptCtx, cancel := pool.attemptContext(ctx, task)
+	defer cancel()
+
+	task.Status = models.Running
+	task.Attempts++
+	started := pool.now().UTC()
+	pool.updateTask(task)
+	pool.publish(task, w.ID)
+
+	result, err := w.execute(attemptCtx, handler, task)
+	if err == nil && result != nil {
+		task.Result, err = json.Marshal(result)
+	}
+
+	cause := context.Cause(attemptCtx)
+	if err != nil && (errors.Is(cause, ErrTaskTimedOut) || errors.Is(cause, ErrDeadlineExceeded)) {
+		err = cause // report the timeout rather than whatever the handler made of it
+	}
+
+	attempt := models.Attempt{Number: len(task.History) + 1, StartedAt: started, FinishedAt: pool.now().UTC()}
+	if err != nil {
+		attempt.Error = err.Error()
+		task.LastError = attempt.Error
+	}
+	task.History = append(task.History, attempt)
+
+	var (
+		retry bool
+		delay time.Duration
+	)
+	if policy := pool.retryPolicy(task); err != nil && !errors.Is(err, ErrPermanent) && task.Attempts < policy.MaxAttempts {
+		delay = pool.backoffDelay(policy, task.Attempts)
+		retry = pool.beforeDeadline(task, delay)
+	}
+
+	switch {
+	case err == nil:
+		task.Error = ""
+		w.finish(task, models.Completed, "")
+	case errors.Is(cause, ErrTaskCancelled):
+		w.finish(task, models.Cancelled, ErrTaskCancelled.Error())
+	case errors.Is(err, ErrDeadlineExceeded):
+		w.finish(task, models.TimedOut, err.Error())
+	case retry:
+		pool.scheduleRetry(task, delay)
+		fmt.Printf("Worker %d: task %s attempt %d failed, retrying in %v: %v\n", w.ID, task.ID, task.Attempts, delay, err)
+	case errors.Is(err, ErrTaskTimedOut):
+		w.finish(task, models.TimedOut, err.Error())
+	default:
+		w.finish(task, models.Failed, err.Error())
+	}
+}
+
+// finish records a terminal status and logs the outcome.
+func (w *Worker) finish(task *models.Task, status models.Status, reason string) {
+	task.Status = status
+	if reason != "" {
+		task.Error = reason
+	}
+	// Permanent failures move to the dead-letter store when it is enabled.
+	if (status != models.Failed && status != models.TimedOut) || !w.TaskPool.deadLetter(task, reason) {
+		w.TaskPool.updateTask(task)
+	}
+	w.TaskPool.publish(task, w.ID)
+	w.TaskPool.notifyFinished(task)
+	if status == models.Completed {
+		fmt.Printf("Worker %d completed task %s\n", w.ID, task.ID) //fix
+		return
+	}
+	fmt.Printf("Worker %d: task %s %s: %s\n", w.ID, task.ID, status, reason)
+}
+
+// execute runs the handler, turning a panic into an ordinary task error so
+// one misbehaving handler cannot take the worker down with it.
+func (w *Worker) execute(ctx context.Context, handler HandlerFunc, task *models.Task) (result any, err error) {
+	defer func() {
 		if r := recover(); r != nil {
-			task.Status = models.Failed
-			w.TaskPool.Store.UpdateTask(task)
-			fmt.Printf("Worker %d: task %s failed with panic: %v\n", w.ID, task.ID, r)
+			err = fmt.Errorf("handler panicked: %v", r)
 		}
 	}()
-	task.Status = models.Running
-	w.TaskPool.Store.UpdateTask(task)
-	w.Assigned <- task
-	time.Sleep(time.Duration(task.Duration) * time.Second)
-
-	task.Status = models.Completed
-	w.TaskPool.Store.UpdateTask(task)
-	w.Assigned <- nil
-	fmt.Printf("Worker %d completed task %s\n", w.ID, task.ID) //fix
+	return handler(ctx, task)
 }
 
 func (w *Worker) Stop() {
@@ -2,15 +2,19 @@ package taskpool
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/shayanmkpr/task-pool/internal/events"
+	"github.com/shayanmkpr/task-pool/internal/logger"
 	"github.com/shayanmkpr/task-pool/internal/models"
 	"github.com/shayanmkpr/task-pool/internal/store"
 )
 
 // waitForTaskCompletion waits until a task is completed or timeout
-func waitForTaskCompletion(store *store.MemoryStore, taskID string, timeout time.Duration) bool {
+func waitForTaskCompletion(store store.TaskStore, taskID string, timeout time.Duration) bool {
 	ctx := context.Background()
 	deadline := time.Now().Add(timeout)
 
@@ -24,6 +28,21 @@ func waitForTaskCompletion(store *store.MemoryStore, taskID string, timeout time
 	return false
 }
 
+// waitForTaskStatus waits until a task reaches the given status or timeout
+func waitForTaskStatus(store store.TaskStore, taskID string, status models.Status, timeout time.Duration) *models.Task {
+	ctx := context.Background()
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		task, err := store.GetTask(ctx, taskID)
+		if err == nil && task.Status == status {
+			return task
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
 // TestNewWorker tests creating a new worker
 func TestNewWorker(t *testing.T) {
 	store := store.NewMemoryStore()
@@ -56,8 +75,8 @@ func TestWorkerProcessTask(t *testing.T) {
 		Status:      models.Pending,
 	}
 
-	store.AddTask(task)
-	pool.Tasks <- task
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
 
 	// Wait for task to complete (1 second task + buffer)
 	if !waitForTaskCompletion(store, task.ID, 3*time.Second) {
@@ -93,8 +112,8 @@ func TestWorkerLongRunningTask(t *testing.T) {
 		Status:      models.Pending,
 	}
 
-	store.AddTask(task)
-	pool.Tasks <- task
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
 
 	// Wait for task to complete (1 second task + buffer)
 	if !waitForTaskCompletion(store, task.ID, 3*time.Second) {
@@ -112,3 +131,121 @@ func TestWorkerLongRunningTask(t *testing.T) {
 		t.Errorf("Expected task status 'completed', got '%s'", storedTask.Status)
 	}
 }
+
+// TestWorkerDispatchesToHandler tests that workers run the handler registered for the task type
+func TestWorkerDispatchesToHandler(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	pool.Handlers.Register("double", func(ctx context.Context, task *models.Task) (any, error) {
+		var n int
+		if err := json.Unmarshal(task.Payload, &n); err != nil {
+			return nil, err
+		}
+		return n * 2, nil
+	})
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	task := &models.Task{ID: "double-task", Title: "Double", Type: "double", Payload: json.RawMessage("21")}
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
+
+	done := waitForTaskStatus(store, task.ID, models.Completed, 2*time.Second)
+	if done == nil {
+		t.Fatal("Task did not complete within timeout")
+	}
+	if string(done.Result) != "42" {
+		t.Errorf("Expected result 42, got %s", done.Result)
+	}
+}
+
+// TestWorkerHandlerError tests that handler errors and panics are recorded on the task
+func TestWorkerHandlerError(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	pool.Handlers.Register("broken", func(ctx context.Context, task *models.Task) (any, error) {
+		return nil, errors.New("downstream unavailable")
+	})
+	pool.Handlers.Register("panics", func(ctx context.Context, task *models.Task) (any, error) {
+		panic("boom")
+	})
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	for _, task := range []*models.Task{
+		{ID: "broken-task", Title: "Broken", Type: "broken"},
+		{ID: "panic-task", Title: "Panics", Type: "panics"},
+	} {
+		store.AddTask(context.Background(), task)
+		pool.enqueue(task)
+	}
+
+	broken := waitForTaskStatus(store, "broken-task", models.Failed, 2*time.Second)
+	if broken == nil || broken.Error != "downstream unavailable" {
+		t.Errorf("Expected failed task with handler error, got %+v", broken)
+	}
+	panicked := waitForTaskStatus(store, "panic-task", models.Failed, 2*time.Second)
+	if panicked == nil || panicked.Error != "handler panicked: boom" {
+		t.Errorf("Expected failed task with panic error, got %+v", panicked)
+	}
+}
+
+// TestWorkerUnknownTaskType tests that tasks without a registered handler fail with a reason
+func TestWorkerUnknownTaskType(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	worker := NewWorker(1, pool)
+	worker.Start()
+	defer worker.Stop()
+
+	task := &models.Task{ID: "unknown-task", Title: "Unknown", Type: "does-not-exist"}
+	store.AddTask(context.Background(), task)
+	pool.enqueue(task)
+
+	failed := waitForTaskStatus(store, task.ID, models.Failed, 2*time.Second)
+	if failed == nil {
+		t.Fatal("Task was not marked failed")
+	}
+	if failed.Error != `no handler registered for task type "does-not-exist"` {
+		t.Errorf("Unexpected failure reason: %q", failed.Error)
+	}
+}
+
+// TestWorkerPublishesLifecycleEvents tests that a task's transitions and the worker hand-off reach the event bus in order
+func TestWorkerPublishesLifecycleEvents(t *testing.T) {
+	store := store.NewMemoryStore()
+	pool := NewTaskPool(5, store)
+	pool.Handlers.Register("quick", func(ctx context.Context, task *models.Task) (any, error) {
+		return nil, nil
+	})
+	sub, _, _ := pool.Events.Subscribe(0)
+	defer sub.Close()
+
+	worker := NewWorker(7, pool)
+	worker.Start()
+	defer worker.Stop()
+	pool.AddTask(context.Background(), logger.NewTestLogger(), &models.Task{ID: "observed", Title: "Observed", Type: "quick"})
+
+	want := []struct {
+		typ    events.Type
+		status models.Status
+		worker int
+	}{
+		{events.TaskStatus, models.Pending, 0},
+		{events.TaskStatus, models.Running, 7},
+		{events.TaskStatus, models.Completed, 7},
+		{events.WorkerIdle, "", 7},
+	}
+	for i, w := range want {
+		select {
+		case e := <-sub.C:
+			if e.Type != w.typ || e.Status != w.status || e.WorkerID != w.worker {
+				t.Errorf("Event %d: expected %s/%s from worker %d, got %+v", i, w.typ, w.status, w.worker, e)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for event %d", i)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package taskpool
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+// MaxWorkflowTasks bounds how many tasks a single workflow expands to.
+const MaxWorkflowTasks = 1000
+
+var (
+	ErrWorkflowsDisabled = errors.New("workflows are not enabled")
+	ErrInvalidWorkflow   = fmt.Errorf("%w: invalid workflow", ErrInvalidTask)
+)
+
+// WithWorkflows enables SubmitWorkflow and keeps workflow definitions in ws.
+func WithWorkflows(ws store.WorkflowStore) Option {
+	return func(p *TaskPool) {
+		p.workflows = ws
+	}
+}
+
+// SubmitWorkflow expands wf.Definition into tasks wired together with
+// depends_on and adds them as one all-or-nothing batch. Every step after the
+// first receives the previous step's result as its input; a group's result
+// is the list of its members' results, so a chord callback gets them all.
+// A failing step cancels everything that depends on it. wf gets an ID if it
+// has none, and its TaskIDs and Outputs are filled in.
+func (p *TaskPool) SubmitWorkflow(ctx context.Context, logger *logger.Logger, wf *models.Workflow) error {
+	if p.workflows == nil {
+		return ErrWorkflowsDisabled
+	}
+	if wf.ID == "" {
+		wf.ID = uuid.New().String()
+	}
+	b := &workflowBuilder{id: wf.ID, tenant: wf.Tenant}
+	outputs, err := b.step("workflow", wf.Definition, nil)
+	if err != nil {
+		return err
+	}
+
+	wf.TaskIDs = make([]string, len(b.tasks))
+	for i, task := range b.tasks {
+		wf.TaskIDs[i] = task.ID
+	}
+	wf.Outputs = outputs
+	wf.CreatedAt = p.now().UTC()
+
+	// The definition goes first so every task's workflow_id resolves.
+	if err := p.workflows.AddWorkflow(ctx, wf); err != nil {
+		return fmt.Errorf("failed to store workflow: %w", err)
+	}
+	if err := p.AddBatch(ctx, logger, b.tasks); err != nil {
+		if err := p.workflows.DeleteWorkflow(context.Background(), wf.ID); err != nil {
+			logger.Error("failed to roll back workflow", "workflow_id", wf.ID, "error", err)
+		}
+		return err
+	}
+	logger.Info("workflow submitted", "workflow_id", wf.ID, "tasks", len(b.tasks))
+	return nil
+}
+
+// workflowBuilder turns a workflow definition into tasks.
+type workflowBuilder struct {
+	id     string
+	tenant string
+	tasks  []*models.Task
+}
+
+// step adds the tasks for s, each depending on after, and returns the tasks
+// whose results make up the step's result. path names s in error messages.
+func (b *workflowBuilder) step(path string, s models.WorkflowStep, after []string) ([]string, error) {
+	set := 0
+	for _, ok := range []bool{s.Task != nil, s.Chain != nil, s.Group != nil, s.Chord != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("%w: %s must set exactly one of task, chain, group or chord", ErrInvalidWorkflow, path)
+	}
+
+	switch {
+	case s.Task != nil:
+		if len(b.tasks) == MaxWorkflowTasks {
+			return nil, fmt.Errorf("%w: more than %d tasks", ErrInvalidWorkflow, MaxWorkflowTasks)
+		}
+		tpl := s.Task
+		task := &models.Task{
+			ID:             uuid.New().String(),
+			Title:          tpl.Title,
+			Description:    tpl.Description,
+			Type:           tpl.Type,
+			Queue:          tpl.Queue,
+			ConcurrencyKey: tpl.ConcurrencyKey,
+			Tenant:         b.tenant,
+			Payload:        tpl.Payload,
+			Tags:           tpl.Tags,
+			Priority:       tpl.Priority,
+			Retry:          tpl.Retry,
+			Timeout:        tpl.Timeout,
+			DependsOn:      slices.Clone(after),
+			PassResults:    len(after) > 0,
+			WorkflowID:     b.id,
+		}
+		b.tasks = append(b.tasks, task)
+		return []string{task.ID}, nil
+	case s.Chain != nil:
+		if len(s.Chain) == 0 {
+			return nil, fmt.Errorf("%w: %s.chain is empty", ErrInvalidWorkflow, path)
+		}
+		for i, next := range s.Chain {
+			var err error
+			if after, err = b.step(fmt.Sprintf("%s.chain[%d]", path, i), next, after); err != nil {
+				return nil, err
+			}
+		}
+		return after, nil
+	case s.Group != nil:
+		return b.group(path+".group", s.Group, after)
+	default:
+		members, err := b.group(path+".chord.group", s.Chord.Group, after)
+		if err != nil {
+			return nil, err
+		}
+		return b.step(path+".chord.callback", s.Chord.Callback, members)
+	}
+}
+
+func (b *workflowBuilder) group(path string, steps []models.WorkflowStep, after []string) ([]string, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrInvalidWorkflow, path)
+	}
+	var outputs []string
+	for i, member := range steps {
+		out, err := b.step(fmt.Sprintf("%s[%d]", path, i), member, after)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, out...)
+	}
+	return outputs, nil
+}
+
+// Workflow returns the workflow with the current state of its tasks.
+func (p *TaskPool) Workflow(ctx context.Context, id string) (*models.WorkflowState, error) {
+	if p.workflows == nil {
+		return nil, ErrWorkflowsDisabled
+	}
+	wf, err := p.workflows.GetWorkflow(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return p.workflowState(ctx, wf)
+}
+
+// Workflows returns every workflow, oldest first.
+func (p *TaskPool) Workflows(ctx context.Context) ([]*models.WorkflowState, error) {
+	if p.workflows == nil {
+		return nil, ErrWorkflowsDisabled
+	}
+	workflows, err := p.workflows.ListWorkflows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(workflows, func(a, b *models.Workflow) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+	states := make([]*models.WorkflowState, 0, len(workflows))
+	for _, wf := range workflows {
+		state, err := p.workflowState(ctx, wf)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// workflowState derives a workflow's status from its tasks. Once every
+// output task has completed, the result is their joined results.
+func (p *TaskPool) workflowState(ctx context.Context, wf *models.Workflow) (*models.WorkflowState, error) {
+	state := &models.WorkflowState{Workflow: wf, Tasks: make([]models.GraphNode, 0, len(wf.TaskIDs))}
+	byID := make(map[string]*models.Task, len(wf.TaskIDs))
+	for _, id := range wf.TaskIDs {
+		task, err := p.lookup(ctx, id)
+		if errors.Is(err, store.ErrTaskNotFound) {
+			continue // removed from the store since
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up task %s: %w", id, err)
+		}
+		byID[id] = task
+		state.Tasks = append(state.Tasks, models.GraphNode{
+			ID:        task.ID,
+			Title:     task.Title,
+			Status:    task.Status,
+			DependsOn: task.DependsOn,
+		})
+	}
+
+	state.Status = workflowStatus(state.Tasks)
+	if state.Status == models.Completed {
+		results := make([]json.RawMessage, len(wf.Outputs))
+		for i, id := range wf.Outputs {
+			if task, ok := byID[id]; ok {
+				results[i] = task.Result
+			}
+		}
+		state.Result = joinResults(results)
+	}
+	return state, nil
+}
+
+// workflowStatus is pending until a task starts, running while any task
+// is unfinished, and otherwise completed, failed (if any task failed or
+// timed out) or cancelled.
+func workflowStatus(tasks []models.GraphNode) models.Status {
+	var started, unfinished, failed, cancelled bool
+	for _, task := range tasks {
+		switch task.Status {
+		case models.Completed:
+			started = true
+		case models.Failed, models.TimedOut:
+			failed = true
+		case models.Cancelled:
+			cancelled = true
+		case models.Running, models.Retrying:
+			started, unfinished = true, true
+		default:
+			unfinished = true
+		}
+	}
+	switch {
+	case unfinished && (started || failed || cancelled):
+		return models.Running
+	case unfinished:
+		return models.Pending
+	case failed:
+		return models.Failed
+	case cancelled:
+		return models.Cancelled
+	default:
+		return models.Completed
+	}
+}
+
+// joinResults combines results the way a step receives them: a single
+// result as is, several as a JSON array in order. A missing result is null.
+func joinResults(results []json.RawMessage) json.RawMessage {
+	if len(results) == 1 {
+		if results[0] == nil {
+			return json.RawMessage("null")
+		}
+		return results[0]
+	}
+	data, _ := json.Marshal(results)
+	return data
+}
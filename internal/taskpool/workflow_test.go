@@ -0,0 +1,159 @@
+package taskpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shayanmkpr/task-pool/internal/logger"
+	"github.com/shayanmkpr/task-pool/internal/models"
+	"github.com/shayanmkpr/task-pool/internal/store"
+)
+
+func newWorkflowPool(t *testing.T) (*TaskPool, *store.MemoryStore) {
+	t.Helper()
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st, WithWorkflows(st))
+	pool.Handlers.Register("seed", func(ctx context.Context, task *models.Task) (any, error) {
+		return 2, nil
+	})
+	pool.Handlers.Register("mul", func(ctx context.Context, task *models.Task) (any, error) {
+		var in, by int
+		if err := json.Unmarshal(task.Input, &in); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(task.Payload, &by)
+		return in * by, nil
+	})
+	pool.Handlers.Register("sum", func(ctx context.Context, task *models.Task) (any, error) {
+		var in []int
+		if err := json.Unmarshal(task.Input, &in); err != nil {
+			return nil, err
+		}
+		total := 0
+		for _, n := range in {
+			total += n
+		}
+		return total, nil
+	})
+	worker := NewWorker(1, pool)
+	worker.Start()
+	t.Cleanup(worker.Stop)
+	return pool, st
+}
+
+func taskStep(title, typ, payload string) models.WorkflowStep {
+	tpl := &models.TaskTemplate{Title: title, Type: typ}
+	if payload != "" {
+		tpl.Payload = json.RawMessage(payload)
+	}
+	return models.WorkflowStep{Task: tpl}
+}
+
+// waitForWorkflow polls until the workflow reaches a terminal status or timeout
+func waitForWorkflow(t *testing.T, pool *TaskPool, id string) *models.WorkflowState {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		state, err := pool.Workflow(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Workflow failed: %v", err)
+		}
+		if state.Status.Terminal() {
+			return state
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Workflow did not finish within timeout")
+	return nil
+}
+
+// TestWorkflowChainAndChord tests that results flow through a chain into a group and its chord callback
+func TestWorkflowChainAndChord(t *testing.T) {
+	pool, st := newWorkflowPool(t)
+
+	wf := &models.Workflow{Name: "math", Definition: models.WorkflowStep{Chain: []models.WorkflowStep{
+		taskStep("Seed", "seed", ""),
+		{Chord: &models.Chord{
+			Group:    []models.WorkflowStep{taskStep("Double", "mul", "2"), taskStep("Triple", "mul", "3")},
+			Callback: taskStep("Sum", "sum", ""),
+		}},
+	}}}
+	if err := pool.SubmitWorkflow(context.Background(), logger.NewTestLogger(), wf); err != nil {
+		t.Fatalf("SubmitWorkflow failed: %v", err)
+	}
+	if wf.ID == "" || len(wf.TaskIDs) != 4 || len(wf.Outputs) != 1 {
+		t.Fatalf("Unexpected workflow %+v", wf)
+	}
+
+	state := waitForWorkflow(t, pool, wf.ID)
+	if state.Status != models.Completed || string(state.Result) != "10" {
+		t.Fatalf("Expected completed workflow with result 10, got %s %s", state.Status, state.Result)
+	}
+	callback, _ := st.GetTask(context.Background(), wf.Outputs[0])
+	if string(callback.Input) != "[4,6]" || callback.WorkflowID != wf.ID {
+		t.Errorf("Expected the callback to receive [4,6], got %s", callback.Input)
+	}
+}
+
+// TestWorkflowFailure tests that a failed step fails the workflow and cancels the rest
+func TestWorkflowFailure(t *testing.T) {
+	pool, _ := newWorkflowPool(t)
+
+	wf := &models.Workflow{Definition: models.WorkflowStep{Chain: []models.WorkflowStep{
+		taskStep("Seed", "seed", ""),
+		taskStep("Broken", "missing-handler", ""),
+		{Group: []models.WorkflowStep{taskStep("A", "seed", ""), taskStep("B", "seed", "")}},
+	}}}
+	if err := pool.SubmitWorkflow(context.Background(), logger.NewTestLogger(), wf); err != nil {
+		t.Fatalf("SubmitWorkflow failed: %v", err)
+	}
+
+	state := waitForWorkflow(t, pool, wf.ID)
+	if state.Status != models.Failed || state.Result != nil {
+		t.Fatalf("Expected failed workflow without result, got %s %s", state.Status, state.Result)
+	}
+	for _, task := range state.Tasks[2:] {
+		if task.Status != models.Cancelled {
+			t.Errorf("Expected task %s after the failure cancelled, got %s", task.Title, task.Status)
+		}
+	}
+}
+
+// TestSubmitWorkflowValidation tests malformed definitions and the disabled case
+func TestSubmitWorkflowValidation(t *testing.T) {
+	st := store.NewMemoryStore()
+	pool := NewTaskPool(10, st, WithWorkflows(st))
+	log := logger.NewTestLogger()
+	ctx := context.Background()
+
+	for _, def := range []models.WorkflowStep{
+		{},
+		{Chain: []models.WorkflowStep{}},
+		{Chain: []models.WorkflowStep{taskStep("A", "", ""), {Group: []models.WorkflowStep{{}}}}},
+		{Task: &models.TaskTemplate{Title: "A"}, Group: []models.WorkflowStep{taskStep("B", "", "")}},
+	} {
+		if err := pool.SubmitWorkflow(ctx, log, &models.Workflow{Definition: def}); !errors.Is(err, ErrInvalidWorkflow) {
+			t.Errorf("%+v: expected ErrInvalidWorkflow, got %v", def, err)
+		}
+	}
+	if list, _ := st.ListWorkflows(ctx); len(list) != 0 {
+		t.Errorf("Expected no stored workflows, got %d", len(list))
+	}
+
+	// A workflow whose first step does not fit leaves nothing behind
+	full := NewTaskPool(1, st, WithWorkflows(st))
+	def := models.WorkflowStep{Group: []models.WorkflowStep{taskStep("A", "", ""), taskStep("B", "", "")}}
+	if err := full.SubmitWorkflow(ctx, log, &models.Workflow{Definition: def}); !errors.Is(err, ErrTaskQueueFull) {
+		t.Errorf("Expected ErrTaskQueueFull, got %v", err)
+	}
+	if list, _ := st.ListWorkflows(ctx); len(list) != 0 {
+		t.Errorf("Expected the workflow rolled back, got %d", len(list))
+	}
+
+	if err := NewTaskPool(10, st).SubmitWorkflow(ctx, log, &models.Workflow{Definition: def}); !errors.Is(err, ErrWorkflowsDisabled) {
+		t.Errorf("Expected ErrWorkflowsDisabled, got %v", err)
+	}
+}